@@ -0,0 +1,142 @@
+// LSP aging in the IS-IS protocol.
+// Counts down remaining lifetimes, refreshes our own LSPs and purges expired ones.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/golang/glog"
+	"time"
+)
+
+const (
+	MAX_AGE              = 1200 // Seconds
+	ZERO_AGE_LIFETIME    = 60   // Seconds a purged LSP is kept around before being deleted
+	LSP_REFRESH_INTERVAL = 900  // Seconds between regenerating our own LSPs
+	AGING_INTERVAL       = 1    // Seconds
+)
+
+func getRemainingLifetime(lsp *IsisLsp) uint16 {
+	return binary.BigEndian.Uint16(lsp.CoreLsp.LspHeader.RemainingLifetime[:])
+}
+
+func setRemainingLifetime(lsp *IsisLsp, lifetime uint16) {
+	binary.BigEndian.PutUint16(lsp.CoreLsp.LspHeader.RemainingLifetime[:], lifetime)
+}
+
+func compareLsp(seq uint32, lifetime uint16, otherSeq uint32, otherLifetime uint16) int {
+	// 1 if the first copy of an LSP is newer, -1 if it is older and 0 if they
+	// are the same. With equal sequence numbers a purge is newer
+	if seq != otherSeq {
+		if seq > otherSeq {
+			return 1
+		}
+		return -1
+	}
+	if (lifetime == 0) != (otherLifetime == 0) {
+		if lifetime == 0 {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+func isOwnLsp(lsp *IsisLsp, sid string) bool {
+	ourSystemID := systemIDToBytes(sid)
+	return bytes.Equal(lsp.LspID[:6], ourSystemID[:])
+}
+
+func purgeLsp(db *IsisDB, lsp *IsisLsp) {
+	// Flood the LSP with a zero lifetime and only its header so everyone else
+	// drops it too. Requires the update db lock to be held
+	glog.Infof("Purging %s lsp %s", levelToString(db.Level), nodeIDName(lsp.LspID[:7]))
+	setRemainingLifetime(lsp, 0)
+	lsp.CoreLsp.FirstTLV = nil
+	// Without the TLVs the checksum needs to be recomputed, purges
+	// keep only the authentication TLV
+	lsp.CoreLsp.LspHeader.Checksum = [2]byte{}
+	authenticateLsp(lsp, db.Level)
+	serializeLsp(lsp.CoreLsp)
+	updateHostnames(lsp)
+	lsp.zeroAgeLifetime = ZERO_AGE_LIFETIME
+	for _, intf := range cfg.interfaces {
+		intf.lock.Lock()
+		if intf.level&db.Level != 0 {
+			setFloodFlags(intf, db.Level, lsp.LspID, true, false)
+		}
+		intf.lock.Unlock()
+	}
+}
+
+func deleteLsp(db *IsisDB, lsp *IsisLsp) {
+	// Remove a purged LSP, along with its flood state on every interface.
+	// Requires the update db lock to be held
+	glog.Infof("Deleting %s lsp %s", levelToString(db.Level), nodeIDName(lsp.LspID[:7]))
+	db.Root = AvlDelete(db.Root, lsp.Key)
+	removeHostname(lsp)
+	for _, intf := range cfg.interfaces {
+		intf.lock.Lock()
+		delete(intf.lspFloodStates[db.Level-1], lsp.Key)
+		intf.lock.Unlock()
+	}
+}
+
+func ageLsps(db *IsisDB, elapsed uint16) bool {
+	// Count down the remaining lifetime of every LSP in the database, purging
+	// the ones which expire and deleting purged ones once they have been held
+	// for long enough. Returns whether SPF needs to run
+	db.DBLock.Lock()
+	defer db.DBLock.Unlock()
+	spf := false
+	for _, node := range AvlGetAll(db.Root) {
+		lsp := node.data.(*IsisLsp)
+		lifetime := getRemainingLifetime(lsp)
+		if lifetime == 0 {
+			if lsp.zeroAgeLifetime <= elapsed {
+				deleteLsp(db, lsp)
+			} else {
+				lsp.zeroAgeLifetime -= elapsed
+			}
+			continue
+		}
+		if lifetime > elapsed {
+			setRemainingLifetime(lsp, lifetime-elapsed)
+			continue
+		}
+		purgeLsp(db, lsp)
+		spf = true
+	}
+	return spf
+}
+
+func isisAging(triggerSPF chan bool) {
+	// Age both databases every AGING_INTERVAL and regenerate our own
+	// LSPs every LSP_REFRESH_INTERVAL
+	sinceRefresh := 0
+	for {
+		time.Sleep(AGING_INTERVAL * time.Second)
+		spf := false
+		for _, db := range []*IsisDB{UpdateDB, L2UpdateDB} {
+			if ageLsps(db, AGING_INTERVAL) {
+				spf = true
+			}
+		}
+		if spf {
+			scheduleSPF(triggerSPF)
+		}
+		sinceRefresh += AGING_INTERVAL
+		if sinceRefresh >= LSP_REFRESH_INTERVAL {
+			sinceRefresh = 0
+			cfg.lock.Lock()
+			sid := cfg.sid
+			cfg.lock.Unlock()
+			if sid != "" {
+				glog.Infof("Refreshing our LSPs")
+				scheduleLocalLsp()
+			}
+		}
+	}
+}
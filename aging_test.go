@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAgeLsps(t *testing.T) {
+	initConfig()
+	intf := &Intf{name: "eth0", level: LEVEL_1, lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	cfg.interfaces = []*Intf{intf}
+	db := buildTestDB([]string{"1111.1111.1111"}, 1)
+	lsp := AvlSearch(db.Root, systemIDToKey("1111.1111.1111")).(*IsisLsp)
+	lsp.CoreLsp.FirstTLV = getIPReachTLV([]*Intf{})
+	setRemainingLifetime(lsp, 5)
+	if ageLsps(db, 3) || getRemainingLifetime(lsp) != 2 {
+		t.Fatalf("Expected lifetime 2, got %d", getRemainingLifetime(lsp))
+	}
+	// Expiring purges the LSP and floods it
+	if !ageLsps(db, 2) || getRemainingLifetime(lsp) != 0 || lsp.CoreLsp.FirstTLV != nil || !intf.lspFloodStates[0][lsp.Key].SRM {
+		t.Fatalf("Expected %s to be purged", nodeIDToString(lsp.LspID[:7]))
+	}
+	// Held for the zero age lifetime then deleted
+	ageLsps(db, ZERO_AGE_LIFETIME-1)
+	if AvlSearch(db.Root, lsp.Key) == nil {
+		t.Fatalf("Expected the purge to be held")
+	}
+	ageLsps(db, 1)
+	if _, inMap := intf.lspFloodStates[0][lsp.Key]; inMap || AvlSearch(db.Root, lsp.Key) != nil {
+		t.Fail()
+	}
+}
+
+func TestReceivePurge(t *testing.T) {
+	// A purge with the same sequence number replaces our copy
+	initConfig()
+	intf := &Intf{name: "eth0", level: LEVEL_1, lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	cfg.interfaces = []*Intf{intf}
+	db := buildTestDB([]string{"1111.1111.1111"}, 4)
+	purge := buildEmptyLSP(LEVEL_1, 4, "1111.1111.1111")
+	setRemainingLifetime(purge, 0)
+	if !receiveLsp(intf, db, purge) {
+		t.Fatalf("Expected the purge to be accepted")
+	}
+	lsp := AvlSearch(db.Root, purge.Key).(*IsisLsp)
+	if getRemainingLifetime(lsp) != 0 || lsp.zeroAgeLifetime != ZERO_AGE_LIFETIME {
+		t.Fail()
+	}
+	// An older copy does not bring it back
+	if receiveLsp(intf, db, buildEmptyLSP(LEVEL_1, 3, "1111.1111.1111")) {
+		t.Fail()
+	}
+}
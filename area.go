@@ -0,0 +1,139 @@
+// Area addresses in the IS-IS protocol.
+// Parses NETs and only forms level 1 adjacencies with routers sharing an area.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/golang/glog"
+	"strings"
+	"sync"
+)
+
+const (
+	MAX_AREA_ADDRESSES = 3 // The MaximumAreaAddresses header field of 0 means 3
+	MAX_AREA_LENGTH    = 13
+)
+
+// 49.0001, used until a NET is configured
+var DEFAULT_AREA = []byte{0x49, 0x00, 0x01}
+
+// Area addresses advertised in our hellos and LSPs, kept separate from the
+// config lock since hellos are built with the interface lock held
+var areas [][]byte = [][]byte{DEFAULT_AREA}
+var areasLock sync.Mutex
+
+func parseNET(net string) ([]byte, string, error) {
+	// Split a NET, the area address followed by the 6 byte system ID and a
+	// zero NSEL i.e. 49.0001.1921.6800.1001.00, into its area address and system ID
+	raw, err := hex.DecodeString(strings.Replace(net, ".", "", -1))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid NET %s: %v", net, err)
+	}
+	if len(raw) < 8 || len(raw) > MAX_AREA_LENGTH+7 {
+		return nil, "", fmt.Errorf("invalid NET %s, must be 8-20 bytes", net)
+	}
+	if raw[len(raw)-1] != 0 {
+		return nil, "", fmt.Errorf("invalid NET %s, the NSEL must be 00", net)
+	}
+	return raw[:len(raw)-7], systemIDToString(raw[len(raw)-7 : len(raw)-1]), nil
+}
+
+func areaToString(area []byte) string {
+	// The AFI byte on its own followed by groups of 2 bytes i.e. 49.0001
+	if len(area) == 0 {
+		return ""
+	}
+	result := hex.EncodeToString(area[:1])
+	for i := 1; i < len(area); i += 2 {
+		end := i + 2
+		if end > len(area) {
+			end = len(area)
+		}
+		result += "." + hex.EncodeToString(area[i:end])
+	}
+	return result
+}
+
+func getAreas() [][]byte {
+	areasLock.Lock()
+	defer areasLock.Unlock()
+	return areas
+}
+
+func setAreas(newAreas [][]byte) {
+	areasLock.Lock()
+	defer areasLock.Unlock()
+	areas = newAreas
+}
+
+func getAreaAddressesTLV(areas [][]byte) *IsisTLV {
+	// TLV 1, each area is a 1 byte length followed by the address
+	var areaTLV IsisTLV
+	areaTLV.typeTLV = ISIS_AREA_ADDRESSES_TLV
+	for _, area := range areas {
+		areaTLV.valueTLV = append(areaTLV.valueTLV, byte(len(area)))
+		areaTLV.valueTLV = append(areaTLV.valueTLV, area...)
+	}
+	areaTLV.lengthTLV = byte(len(areaTLV.valueTLV))
+	return &areaTLV
+}
+
+func getAreaAddresses(firstTLV *IsisTLV) [][]byte {
+	// All the area addresses in the TLV 1s of a PDU
+	result := make([][]byte, 0)
+	for tlv := firstTLV; tlv != nil; tlv = tlv.nextTLV {
+		if tlv.typeTLV == ISIS_AREA_ADDRESSES_TLV {
+			result = append(result, parseAreaAddresses(tlv)...)
+		}
+	}
+	return result
+}
+
+func parseAreaAddresses(areaTLV *IsisTLV) [][]byte {
+	result := make([][]byte, 0)
+	for i := 0; i < int(areaTLV.lengthTLV); {
+		length := int(areaTLV.valueTLV[i])
+		if length == 0 || i+1+length > int(areaTLV.lengthTLV) {
+			glog.Infof("Malformed area address TLV %v", areaTLV.valueTLV)
+			break
+		}
+		result = append(result, areaTLV.valueTLV[i+1:i+1+length])
+		i += 1 + length
+	}
+	return result
+}
+
+func areasMatch(ours [][]byte, theirs [][]byte) bool {
+	for _, area := range ours {
+		for _, other := range theirs {
+			if bytes.Equal(area, other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkAreas(intf *Intf, firstTLV *IsisTLV) bool {
+	// Whether a level 1 hello shares an area with us, counting it on the
+	// interface if not. Requires the interface lock to be held
+	theirs := getAreaAddresses(firstTLV)
+	if areasMatch(getAreas(), theirs) {
+		return true
+	}
+	intf.areaMismatches++
+	glog.Infof("Area mismatch on %s, neighbor areas %v", intf.name, areasToStrings(theirs))
+	return false
+}
+
+func areasToStrings(areas [][]byte) []string {
+	result := make([]string, 0, len(areas))
+	for _, area := range areas {
+		result = append(result, areaToString(area))
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseNET(t *testing.T) {
+	area, sid, err := parseNET("49.0001.1921.6800.1001.00")
+	if err != nil || !bytes.Equal(area, []byte{0x49, 0x00, 0x01}) || sid != "1921.6800.1001" || areaToString(area) != "49.0001" {
+		t.Fatalf("Got area %v sid %s err %v", area, sid, err)
+	}
+	for _, invalid := range []string{"1921.6800.1001.00", "49.0001.1921.6800.1001.01", "49.zz01.1921.6800.1001.00"} {
+		if _, _, err := parseNET(invalid); err == nil {
+			t.Errorf("Expected %s to be invalid", invalid)
+		}
+	}
+}
+
+func TestAreaAddressesTLV(t *testing.T) {
+	ours := [][]byte{[]byte{0x49, 0x00, 0x01}, []byte{0x39, 0x84, 0x0f, 0x80}}
+	tlv := getAreaAddressesTLV(ours)
+	if tlv.lengthTLV != 9 {
+		t.Fatalf("Expected length 9, got %d", tlv.lengthTLV)
+	}
+	theirs := getAreaAddresses(tlv)
+	if len(theirs) != 2 || !bytes.Equal(theirs[1], ours[1]) || !areasMatch(ours, theirs) {
+		t.Fail()
+	}
+	if areasMatch(ours, [][]byte{[]byte{0x49, 0x00, 0x02}}) {
+		t.Fail()
+	}
+}
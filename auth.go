@@ -0,0 +1,383 @@
+// Authentication of IS-IS PDUs in TLV 10 (RFC 5304, RFC 5310).
+// Keys are kept in keychains with send and accept lifetimes so they can be rolled over.
+// +build linux
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/glog"
+	"hash"
+	"sync"
+	"time"
+)
+
+const (
+	AUTH_TYPE_CLEARTEXT        = 1
+	AUTH_TYPE_CRYPTO           = 3 // RFC 5310, a 2 byte key ID then the digest
+	AUTH_TYPE_HMAC_MD5         = 54
+	AUTH_CLEARTEXT             = "cleartext"
+	AUTH_HMAC_MD5              = "hmac-md5"
+	AUTH_HMAC_SHA1             = "hmac-sha-1"
+	AUTH_HMAC_SHA256           = "hmac-sha-256"
+	AUTH_NONE                  = "none"
+	AUTH_KEY_ID_LENGTH         = 2
+	MAX_AUTH_KEY_ID            = 0xffff
+	MAX_CLEARTEXT_PASSWORD     = MAX_TLV_LENGTH - 1
+	RFC_5310_APAD              = 0x878fe1f3 // What the digest is set to while it is computed
+	LSP_REMAINING_LIFETIME_POS = 10
+	SEND_KEY_CHECK_INTERVAL    = 1 // Seconds
+)
+
+type AuthKey struct {
+	id        uint16
+	algorithm string
+	secret    []byte
+	// Zero times leave that end of a lifetime open
+	sendStart   time.Time
+	sendEnd     time.Time
+	acceptStart time.Time
+	acceptEnd   time.Time
+}
+
+// Keyed by keychain name, the keychains used by each level are indexed by level - 1
+var keychains = make(map[string][]*AuthKey)
+var levelKeychains [2]string
+var keychainsLock sync.Mutex
+
+func stringToAuthAlgorithm(algorithm string) (string, error) {
+	switch algorithm {
+	case AUTH_CLEARTEXT, AUTH_HMAC_MD5, AUTH_HMAC_SHA1, AUTH_HMAC_SHA256:
+		return algorithm, nil
+	}
+	return "", fmt.Errorf("unsupported authentication algorithm %s, must be %s, %s, %s or %s", algorithm,
+		AUTH_CLEARTEXT, AUTH_HMAC_MD5, AUTH_HMAC_SHA1, AUTH_HMAC_SHA256)
+}
+
+func getAuthHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case AUTH_HMAC_MD5:
+		return md5.New
+	case AUTH_HMAC_SHA1:
+		return sha1.New
+	case AUTH_HMAC_SHA256:
+		return sha256.New
+	}
+	return nil
+}
+
+func inLifetime(start time.Time, end time.Time, now time.Time) bool {
+	return (start.IsZero() || !now.Before(start)) && (end.IsZero() || now.Before(end))
+}
+
+func setKey(keychain string, key *AuthKey) {
+	// Add a key to a keychain, replacing any key with the same ID
+	keychainsLock.Lock()
+	defer keychainsLock.Unlock()
+	keys := make([]*AuthKey, 0)
+	for _, existing := range keychains[keychain] {
+		if existing.id != key.id {
+			keys = append(keys, existing)
+		}
+	}
+	keychains[keychain] = append(keys, key)
+}
+
+func removeKey(keychain string, id uint16) bool {
+	keychainsLock.Lock()
+	defer keychainsLock.Unlock()
+	for i, existing := range keychains[keychain] {
+		if existing.id == id {
+			keychains[keychain] = append(keychains[keychain][:i:i], keychains[keychain][i+1:]...)
+			if len(keychains[keychain]) == 0 {
+				delete(keychains, keychain)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func getKeys(keychain string) []*AuthKey {
+	keychainsLock.Lock()
+	defer keychainsLock.Unlock()
+	return keychains[keychain]
+}
+
+func setLevelKeychain(level byte, keychain string) {
+	keychainsLock.Lock()
+	defer keychainsLock.Unlock()
+	for _, l := range getLevels(level) {
+		levelKeychains[l-1] = keychain
+	}
+}
+
+func getLevelKeychain(level byte) string {
+	keychainsLock.Lock()
+	defer keychainsLock.Unlock()
+	return levelKeychains[level-1]
+}
+
+func usesKeychain(keychain string) bool {
+	// Whether our LSPs are authenticated with a keychain
+	keychainsLock.Lock()
+	defer keychainsLock.Unlock()
+	return levelKeychains[0] == keychain || levelKeychains[1] == keychain
+}
+
+func selectSendKey(keys []*AuthKey, now time.Time) *AuthKey {
+	// Of the keys we may send with, the one whose send lifetime started last.
+	// While two keys overlap that is the new one
+	var selected *AuthKey
+	for _, key := range keys {
+		if !inLifetime(key.sendStart, key.sendEnd, now) {
+			continue
+		}
+		if selected == nil || key.sendStart.After(selected.sendStart) ||
+			(key.sendStart.Equal(selected.sendStart) && key.id > selected.id) {
+			selected = key
+		}
+	}
+	return selected
+}
+
+func getSendKey(keychain string) *AuthKey {
+	// nil if PDUs are sent without authentication
+	if keychain == "" {
+		return nil
+	}
+	key := selectSendKey(getKeys(keychain), time.Now())
+	if key == nil {
+		glog.V(1).Infof("Keychain %s has no key to send with", keychain)
+	}
+	return key
+}
+
+func sendKeysChanged(sendKeys *[2]*AuthKey, now time.Time) bool {
+	// Whether the key either level sends with is no longer the one in sendKeys,
+	// which is updated to the keys selected now
+	changed := false
+	for _, level := range []byte{LEVEL_1, LEVEL_2} {
+		var key *AuthKey
+		if keychain := getLevelKeychain(level); keychain != "" {
+			key = selectSendKey(getKeys(keychain), now)
+		}
+		if key != sendKeys[level-1] {
+			sendKeys[level-1] = key
+			changed = true
+		}
+	}
+	return changed
+}
+
+func isisKeyRollover() {
+	// Our LSPs are signed when they are generated, so once a send lifetime
+	// starts or ends they are generated again with the key selected now.
+	// Otherwise the copies flooded with the old key would be dropped by
+	// neighbors which stopped accepting it, until our next refresh
+	var sendKeys [2]*AuthKey
+	sendKeysChanged(&sendKeys, time.Now())
+	for {
+		time.Sleep(SEND_KEY_CHECK_INTERVAL * time.Second)
+		if !sendKeysChanged(&sendKeys, time.Now()) {
+			continue
+		}
+		cfg.lock.Lock()
+		sid := cfg.sid
+		cfg.lock.Unlock()
+		if sid != "" {
+			glog.Infof("Send keys changed, regenerating our LSPs")
+			scheduleLocalLsp()
+		}
+	}
+}
+
+func getAuthTLV(key *AuthKey) *IsisTLV {
+	// TLV 10 for a key, nil without one. The cryptographic types hold
+	// what the digest is computed with until the PDU is signed
+	if key == nil {
+		return nil
+	}
+	tlv := &IsisTLV{typeTLV: ISIS_AUTH_TLV}
+	switch key.algorithm {
+	case AUTH_CLEARTEXT:
+		tlv.valueTLV = append([]byte{AUTH_TYPE_CLEARTEXT}, key.secret...)
+	case AUTH_HMAC_MD5:
+		tlv.valueTLV = append([]byte{AUTH_TYPE_HMAC_MD5}, make([]byte, md5.Size)...)
+	default:
+		tlv.valueTLV = make([]byte, 1+AUTH_KEY_ID_LENGTH)
+		tlv.valueTLV[0] = AUTH_TYPE_CRYPTO
+		binary.BigEndian.PutUint16(tlv.valueTLV[1:], key.id)
+		tlv.valueTLV = append(tlv.valueTLV, getApad(getAuthHash(key.algorithm)().Size())...)
+	}
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+	return tlv
+}
+
+func getApad(length int) []byte {
+	// RFC 5310 Apad, 0x878FE1F3 repeated to the length of the digest
+	apad := make([]byte, length+4)
+	for i := 0; i < length; i += 4 {
+		binary.BigEndian.PutUint32(apad[i:], RFC_5310_APAD)
+	}
+	return apad[:length]
+}
+
+func findAuthTLV(pdu []byte) int {
+	// Offset of the value of the first TLV 10 in a serialized PDU without
+	// the ethernet header, -1 if there is none
+	for i := int(pdu[1]); i+2 <= len(pdu); i += 2 + int(pdu[i+1]) {
+		if i+2+int(pdu[i+1]) > len(pdu) {
+			break
+		}
+		if pdu[i] == ISIS_AUTH_TLV {
+			return i + 2
+		}
+	}
+	return -1
+}
+
+func computeDigest(key *AuthKey, pdu []byte, offset int) []byte {
+	// HMAC of a PDU whose TLV 10 value starts at offset. The digest itself is
+	// zero for HMAC-MD5 and Apad for RFC 5310, LSPs also have their remaining
+	// lifetime and checksum zeroed since those change as they are flooded
+	digestStart := offset + 1
+	if key.algorithm != AUTH_HMAC_MD5 {
+		digestStart += AUTH_KEY_ID_LENGTH
+	}
+	newHash := getAuthHash(key.algorithm)
+	digestLength := newHash().Size()
+	if offset < 0 || digestStart+digestLength > len(pdu) {
+		return nil
+	}
+	data := append([]byte{}, pdu...)
+	if key.algorithm == AUTH_HMAC_MD5 {
+		copy(data[digestStart:digestStart+digestLength], make([]byte, digestLength))
+	} else {
+		copy(data[digestStart:digestStart+digestLength], getApad(digestLength))
+	}
+	if data[4] == L1_LSP_PDU_TYPE || data[4] == L2_LSP_PDU_TYPE {
+		copy(data[LSP_REMAINING_LIFETIME_POS:LSP_REMAINING_LIFETIME_POS+2], []byte{0, 0})
+		copy(data[LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET:LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET+2], []byte{0, 0})
+	}
+	mac := hmac.New(newHash, key.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func signPDU(pdu []byte, key *AuthKey) []byte {
+	// Fill in the digest of the TLV 10 in a serialized PDU,
+	// which must have been built with getAuthTLV(key)
+	if key == nil || key.algorithm == AUTH_CLEARTEXT {
+		return pdu
+	}
+	offset := findAuthTLV(pdu)
+	digest := computeDigest(key, pdu, offset)
+	copy(pdu[offset+int(pdu[offset-1])-len(digest):], digest)
+	return pdu
+}
+
+func getAuthTLVLength(level byte) int {
+	// Room to leave in each fragment of our LSPs for the TLV 10
+	if tlv := getAuthTLV(getSendKey(getLevelKeychain(level))); tlv != nil {
+		return 2 + int(tlv.lengthTLV)
+	}
+	return 0
+}
+
+func authenticateLsp(lsp *IsisLsp, level byte) {
+	// Put a TLV 10 first in one of our own LSPs, or a purge, and sign it.
+	// The checksum is left zero so serializing it fills that in afterwards
+	key := getSendKey(getLevelKeychain(level))
+	lsp.CoreLsp.LspHeader.Checksum = [2]byte{}
+	authTLV := getAuthTLV(key)
+	if authTLV == nil {
+		return
+	}
+	authTLV.nextTLV = lsp.CoreLsp.FirstTLV
+	lsp.CoreLsp.FirstTLV = authTLV
+	if key.algorithm != AUTH_CLEARTEXT {
+		pdu := signPDU(serializeLsp(lsp.CoreLsp), key)
+		offset := findAuthTLV(pdu)
+		copy(authTLV.valueTLV, pdu[offset:offset+int(authTLV.lengthTLV)])
+		lsp.CoreLsp.LspHeader.Checksum = [2]byte{}
+	}
+}
+
+func checkDigest(key *AuthKey, pdu []byte, offset int, digest []byte) bool {
+	// Whether the digest received in a TLV 10 is the one we compute, a PDU
+	// with no room for a digest never passes
+	computed := computeDigest(key, pdu, offset)
+	return computed != nil && hmac.Equal(digest, computed)
+}
+
+func checkAuthentication(pdu []byte, keys []*AuthKey, now time.Time) bool {
+	// Whether a PDU, without the ethernet header, carries a TLV 10
+	// matching one of the keys we currently accept
+	offset := findAuthTLV(pdu)
+	if offset < 0 || pdu[offset-1] == 0 {
+		return false
+	}
+	value := pdu[offset : offset+int(pdu[offset-1])]
+	for _, key := range keys {
+		if !inLifetime(key.acceptStart, key.acceptEnd, now) {
+			continue
+		}
+		switch value[0] {
+		case AUTH_TYPE_CLEARTEXT:
+			if key.algorithm == AUTH_CLEARTEXT && hmac.Equal(value[1:], key.secret) {
+				return true
+			}
+		case AUTH_TYPE_HMAC_MD5:
+			if key.algorithm == AUTH_HMAC_MD5 && len(value) == 1+md5.Size && checkDigest(key, pdu, offset, value[1:]) {
+				return true
+			}
+		case AUTH_TYPE_CRYPTO:
+			if key.algorithm == AUTH_HMAC_SHA1 || key.algorithm == AUTH_HMAC_SHA256 {
+				if len(value) == 1+AUTH_KEY_ID_LENGTH+getAuthHash(key.algorithm)().Size() &&
+					binary.BigEndian.Uint16(value[1:]) == key.id && checkDigest(key, pdu, offset, value[1+AUTH_KEY_ID_LENGTH:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func authenticatePDU(intf *Intf, pdu []byte) bool {
+	// Whether a received PDU, without the ethernet header, passes the authentication
+	// configured for it: the keychain of the interface for hellos and of the level
+	// for LSPs and SNPs. Anything is accepted without a keychain. Failures are
+	// counted on the interface
+	pduType := pdu[4]
+	intf.lock.Lock()
+	keychain := intf.helloKeychain
+	intf.lock.Unlock()
+	switch pduType {
+	case L1_LSP_PDU_TYPE, L1_CSNP_PDU_TYPE, L1_PSNP_PDU_TYPE:
+		keychain = getLevelKeychain(LEVEL_1)
+	case L2_LSP_PDU_TYPE, L2_CSNP_PDU_TYPE, L2_PSNP_PDU_TYPE:
+		keychain = getLevelKeychain(LEVEL_2)
+	}
+	if keychain == "" || checkAuthentication(pdu, getKeys(keychain), time.Now()) {
+		return true
+	}
+	intf.lock.Lock()
+	intf.authFailures++
+	intf.lock.Unlock()
+	glog.Infof("Got a PDU of type 0x%x on %s which failed %s authentication, dropping", pduType, intf.name, keychain)
+	return false
+}
+
+func parseLifetime(lifetime string) (time.Time, error) {
+	// RFC 3339 i.e. 2017-01-02T15:04:05Z, empty leaves that end of the lifetime open
+	if lifetime == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, lifetime)
+}
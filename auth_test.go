@@ -0,0 +1,127 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthHello(t *testing.T) {
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	for _, algorithm := range []string{AUTH_CLEARTEXT, AUTH_HMAC_MD5, AUTH_HMAC_SHA1, AUTH_HMAC_SHA256} {
+		key := &AuthKey{id: 7, algorithm: algorithm, secret: []byte("secret")}
+		hello := buildTestP2PHello(intf, "1111.1111.1111")
+		hello.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(key), hello.FirstTLV})
+		pdu := signPDU(serializeP2PHelloPDU(hello), key)
+		if !checkAuthentication(pdu, []*AuthKey{key}, time.Now()) {
+			t.Fatalf("Expected %s authentication to pass", algorithm)
+		}
+		wrongSecret := &AuthKey{id: 7, algorithm: algorithm, secret: []byte("other")}
+		if checkAuthentication(pdu, []*AuthKey{wrongSecret}, time.Now()) {
+			t.Fatalf("Expected %s authentication with the wrong secret to fail", algorithm)
+		}
+		if algorithm != AUTH_CLEARTEXT {
+			pdu[len(pdu)-1] ^= 0x01
+			if checkAuthentication(pdu, []*AuthKey{key}, time.Now()) {
+				t.Fatalf("Expected a modified %s PDU to fail", algorithm)
+			}
+		}
+	}
+	// The key ID has to match for RFC 5310
+	key := &AuthKey{id: 1, algorithm: AUTH_HMAC_SHA256, secret: []byte("secret")}
+	hello := buildTestP2PHello(intf, "1111.1111.1111")
+	hello.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(key), hello.FirstTLV})
+	pdu := signPDU(serializeP2PHelloPDU(hello), key)
+	if checkAuthentication(pdu, []*AuthKey{&AuthKey{id: 2, algorithm: AUTH_HMAC_SHA256, secret: []byte("secret")}}, time.Now()) {
+		t.Fail()
+	}
+}
+
+func TestAuthLsp(t *testing.T) {
+	initConfig()
+	setKey("lsp", &AuthKey{id: 1, algorithm: AUTH_HMAC_MD5, secret: []byte("secret")})
+	setLevelKeychain(LEVEL_1, "lsp")
+	lsp := buildEmptyLSP(LEVEL_1, 3, "1111.1111.1111")
+	lsp.CoreLsp.FirstTLV = getAreaAddressesTLV([][]byte{DEFAULT_AREA})
+	authenticateLsp(lsp, LEVEL_1)
+	pdu := serializeLsp(lsp.CoreLsp)
+	if getTLV(lsp.CoreLsp.FirstTLV, ISIS_AUTH_TLV) == nil || !verifyLspChecksum(pdu) {
+		t.Fatalf("Expected a signed LSP with a valid checksum")
+	}
+	// Aging and the checksum are outside the digest
+	setRemainingLifetime(lsp, 100)
+	pdu = serializeLsp(lsp.CoreLsp)
+	if !checkAuthentication(pdu, getKeys("lsp"), time.Now()) {
+		t.Fatalf("Expected the aged LSP to pass authentication")
+	}
+	// Failures are counted on the interface the PDU came in on
+	intf := &Intf{name: "eth0"}
+	pdu[len(pdu)-1] ^= 0x01
+	if authenticatePDU(intf, pdu) || intf.authFailures != 1 {
+		t.Fail()
+	}
+	// Level 2 has no keychain so anything goes
+	pdu[4] = L2_LSP_PDU_TYPE
+	if !authenticatePDU(intf, pdu) {
+		t.Fail()
+	}
+	setLevelKeychain(LEVEL_1_2, "")
+	removeKey("lsp", 1)
+}
+
+func TestAuthTruncated(t *testing.T) {
+	// A TLV 10 with no room for the digest at the end of a PDU never passes
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	for _, key := range []*AuthKey{&AuthKey{id: 1, algorithm: AUTH_HMAC_MD5, secret: []byte("secret")},
+		&AuthKey{id: 1, algorithm: AUTH_HMAC_SHA256, secret: []byte("secret")}} {
+		for _, value := range [][]byte{[]byte{AUTH_TYPE_HMAC_MD5}, []byte{AUTH_TYPE_CRYPTO, 0, 1}, []byte{AUTH_TYPE_HMAC_MD5, 0}} {
+			hello := buildTestP2PHello(intf, "1111.1111.1111")
+			hello.FirstTLV = linkTLVs([]*IsisTLV{hello.FirstTLV, &IsisTLV{typeTLV: ISIS_AUTH_TLV, lengthTLV: byte(len(value)), valueTLV: value}})
+			pdu := serializeP2PHelloPDU(hello)
+			if checkAuthentication(pdu[:findAuthTLV(pdu)+len(value)], []*AuthKey{key}, time.Now()) {
+				t.Fatalf("Expected a truncated TLV 10 %v to fail %s authentication", value, key.algorithm)
+			}
+		}
+	}
+}
+
+func TestSendKeysChanged(t *testing.T) {
+	// A send key taking over as time passes is noticed without any config change
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	setKey("rollover", &AuthKey{id: 1, algorithm: AUTH_HMAC_SHA256, secret: []byte("old"), sendEnd: now.Add(time.Hour)})
+	setKey("rollover", &AuthKey{id: 2, algorithm: AUTH_HMAC_SHA256, secret: []byte("new"), sendStart: now.Add(time.Minute)})
+	setLevelKeychain(LEVEL_1, "rollover")
+	var sendKeys [2]*AuthKey
+	if !sendKeysChanged(&sendKeys, now) || sendKeys[0].id != 1 || sendKeys[1] != nil {
+		t.Fatalf("Expected to send with key 1, got %v", sendKeys)
+	}
+	if sendKeysChanged(&sendKeys, now.Add(30*time.Second)) {
+		t.Fatalf("Expected the send key to stay the same")
+	}
+	if !sendKeysChanged(&sendKeys, now.Add(2*time.Minute)) || sendKeys[0].id != 2 {
+		t.Fatalf("Expected to send with key 2, got %v", sendKeys)
+	}
+	setLevelKeychain(LEVEL_1_2, "")
+	removeKey("rollover", 1)
+	removeKey("rollover", 2)
+}
+
+func TestKeyRollover(t *testing.T) {
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	oldKey := &AuthKey{id: 1, algorithm: AUTH_HMAC_SHA256, secret: []byte("old"),
+		sendEnd: now.Add(time.Hour), acceptEnd: now.Add(2 * time.Hour)}
+	newKey := &AuthKey{id: 2, algorithm: AUTH_HMAC_SHA256, secret: []byte("new"),
+		sendStart: now.Add(-time.Minute), acceptStart: now.Add(-time.Hour)}
+	keys := []*AuthKey{oldKey, newKey}
+	// The newest key is sent with as soon as its send lifetime starts
+	if selectSendKey(keys, now.Add(-2*time.Minute)) != oldKey || selectSendKey(keys, now) != newKey {
+		t.Fatalf("Unexpected send keys")
+	}
+	// The old key is still accepted until its accept lifetime ends
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	hello := buildTestP2PHello(intf, "1111.1111.1111")
+	hello.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(oldKey), hello.FirstTLV})
+	pdu := signPDU(serializeP2PHelloPDU(hello), oldKey)
+	if !checkAuthentication(pdu, keys, now.Add(90*time.Minute)) || checkAuthentication(pdu, keys, now.Add(3*time.Hour)) {
+		t.Fail()
+	}
+}
@@ -149,6 +149,7 @@ func AvlDelete(root *AvlNode, key uint64) *AvlNode {
 				tmp = tmp.left
 			}
 			root.key = tmp.key
+			root.data = tmp.data
 			root.right = AvlDelete(root.right, tmp.key)
 		}
 	}
@@ -233,6 +234,25 @@ func AvlGetAll(root *AvlNode) []*AvlNode {
 	return nodes
 }
 
+func AvlGetRange(root *AvlNode, start uint64, end uint64) []*AvlNode {
+	// In order nodes with keys from start to end inclusive, only visiting
+	// the subtrees which can hold keys in the range
+	nodes := make([]*AvlNode, 0)
+	if root == nil {
+		return nodes
+	}
+	if root.key > start {
+		nodes = append(nodes, AvlGetRange(root.left, start, end)...)
+	}
+	if root.key >= start && root.key <= end {
+		nodes = append(nodes, root)
+	}
+	if root.key < end {
+		nodes = append(nodes, AvlGetRange(root.right, start, end)...)
+	}
+	return nodes
+}
+
 func AvlPrint(node *AvlNode) {
 	// Find a way to pretty print the nodes
 	if node != nil {
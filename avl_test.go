@@ -83,6 +83,21 @@ func TestAvlDelete(t *testing.T) {
 	}
 }
 
+func TestAvlDeleteData(t *testing.T) {
+	// A node with two children takes the key and the data of its successor
+	var root *AvlNode
+	var i uint64
+	for i = 0; i < 7; i++ {
+		root = AvlInsert(root, i, &DummyData{data: int(i)}, false)
+	}
+	root = AvlDelete(root, root.key)
+	for _, node := range AvlGetAll(root) {
+		if uint64(node.data.(*DummyData).data) != node.key {
+			t.Fatalf("Node %d has the data of %v", node.key, node.data)
+		}
+	}
+}
+
 func TestAvlGetHeight(t *testing.T) {
 	var root *AvlNode
 	root = &AvlNode{key: 2, left: nil, right: nil, height: 1}
@@ -148,3 +163,14 @@ func TestAvlOverwrite(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestAvlGetRange(t *testing.T) {
+	var root *AvlNode
+	for _, key := range []uint64{50, 20, 80, 10, 30, 60, 90, 25} {
+		root = AvlInsert(root, key, &DummyData{data: int(key)}, false)
+	}
+	nodes := AvlGetRange(root, 25, 60)
+	if len(nodes) != 4 || nodes[0].key != 25 || nodes[3].key != 60 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,430 @@
+// Bidirectional forwarding detection (RFC 5880, RFC 5881) for adjacencies.
+// A session going down after it came up brings its adjacency down straight away.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/glog"
+	"golang.org/x/sys/unix"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	BFD_PORT                = 3784  // Single hop control packets are sent to this port
+	BFD_SOURCE_PORT         = 49152 // and from one in the range 49152-65535
+	BFD_TTL                 = 255
+	BFD_VERSION             = 1
+	BFD_PACKET_LENGTH       = 24
+	BFD_STATE_ADMIN_DOWN    = 0
+	BFD_STATE_DOWN          = 1
+	BFD_STATE_INIT          = 2
+	BFD_STATE_UP            = 3
+	BFD_DIAG_NONE           = 0
+	BFD_DIAG_DETECT_EXPIRED = 1
+	BFD_DIAG_NEIGHBOR_DOWN  = 3
+	BFD_FLAG_AUTH           = 0x04
+	BFD_FLAG_MULTIPOINT     = 0x01
+	BFD_TX_INTERVAL         = 300  // Milliseconds in between control packets once the session is up
+	BFD_SLOW_TX_INTERVAL    = 1000 // and until it is
+	BFD_RX_INTERVAL         = 300  // Milliseconds in between control packets we can receive
+	BFD_DETECT_MULT         = 3    // Control packets our neighbor can miss before the session goes down
+	BFD_TIMER_PERIOD        = 50   // Milliseconds in between checking the transmit and detection timers
+	BFD_ENABLED             = "enabled"
+	BFD_DISABLED            = "disabled"
+)
+
+type BfdPacket struct {
+	// Fields need to be exported for the binary encoding, the intervals are in microseconds
+	VersionDiag               byte
+	StateFlags                byte
+	DetectMult                byte
+	Length                    byte
+	MyDiscriminator           uint32
+	YourDiscriminator         uint32
+	DesiredMinTxInterval      uint32
+	RequiredMinRxInterval     uint32
+	RequiredMinEchoRxInterval uint32
+}
+
+// An asynchronous mode session over UDP with the address an UP neighbor sent
+// in its hellos. Authentication, demand mode and the echo function are not
+// supported, and timers only ever speed up once the session is up so they
+// are never negotiated with poll sequences
+type BfdSession struct {
+	intfName            string
+	peer                *net.UDPAddr
+	state               byte
+	diag                byte
+	localDiscriminator  uint32
+	remoteDiscriminator uint32
+	remoteState         byte
+	remoteDetectMult    byte
+	remoteMinRx         uint32 // Microseconds, as sent by our neighbor
+	remoteMinTx         uint32
+	lastRx              time.Time
+	nextTx              time.Time
+}
+
+// Sessions by the address of the neighbor
+var bfdSessions = make(map[string]*BfdSession)
+var bfdLastDiscriminator uint32
+var bfdLock sync.Mutex
+
+func bfdStateToString(state byte) string {
+	switch state {
+	case BFD_STATE_ADMIN_DOWN:
+		return "ADMIN DOWN"
+	case BFD_STATE_DOWN:
+		return "DOWN"
+	case BFD_STATE_INIT:
+		return "INIT"
+	}
+	return "UP"
+}
+
+func getBfdSessionsString(intfName string) string {
+	// The sessions on an interface for GetIntf
+	bfdLock.Lock()
+	defer bfdLock.Unlock()
+	sessions := ""
+	for neighbor, session := range bfdSessions {
+		if session.intfName == intfName {
+			sessions += ", BFD session with " + neighbor + " " + bfdStateToString(session.state)
+		}
+	}
+	return sessions
+}
+
+func newBfdSession(intfName string, neighborIP net.IP) *BfdSession {
+	// Requires the bfd lock to be held
+	bfdLastDiscriminator++
+	return &BfdSession{intfName: intfName, peer: &net.UDPAddr{IP: neighborIP, Port: BFD_PORT}, state: BFD_STATE_DOWN,
+		localDiscriminator: bfdLastDiscriminator, remoteMinRx: 1}
+}
+
+func serializeBfdPacket(packet *BfdPacket) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, packet)
+	return buf.Bytes()
+}
+
+func deserializeBfdPacket(raw_bytes []byte) (*BfdPacket, error) {
+	// Decode a control packet, discarding it if it fails the checks of RFC 5880 section 6.8.6
+	if len(raw_bytes) < BFD_PACKET_LENGTH {
+		return nil, fmt.Errorf("BFD packet of %d bytes too short", len(raw_bytes))
+	}
+	var packet BfdPacket
+	binary.Read(bytes.NewBuffer(raw_bytes), binary.BigEndian, &packet)
+	switch {
+	case packet.VersionDiag>>5 != BFD_VERSION:
+		return nil, fmt.Errorf("unsupported BFD version %d", packet.VersionDiag>>5)
+	case int(packet.Length) < BFD_PACKET_LENGTH || int(packet.Length) > len(raw_bytes):
+		return nil, fmt.Errorf("BFD packet length %d invalid", packet.Length)
+	case packet.DetectMult == 0:
+		return nil, fmt.Errorf("BFD detect multiplier of zero")
+	case packet.StateFlags&BFD_FLAG_MULTIPOINT != 0:
+		return nil, fmt.Errorf("BFD multipoint bit set")
+	case packet.StateFlags&BFD_FLAG_AUTH != 0:
+		return nil, fmt.Errorf("BFD authentication is not supported")
+	case packet.MyDiscriminator == 0:
+		return nil, fmt.Errorf("BFD packet without a discriminator")
+	case packet.YourDiscriminator == 0 && packet.StateFlags>>6 != BFD_STATE_DOWN && packet.StateFlags>>6 != BFD_STATE_ADMIN_DOWN:
+		return nil, fmt.Errorf("BFD packet in state %s without our discriminator", bfdStateToString(packet.StateFlags>>6))
+	}
+	return &packet, nil
+}
+
+func getBfdMinTx(session *BfdSession) uint32 {
+	// The interval we would like to send at in microseconds, no faster
+	// than once a second until the session is up
+	if session.state == BFD_STATE_UP {
+		return BFD_TX_INTERVAL * 1000
+	}
+	return BFD_SLOW_TX_INTERVAL * 1000
+}
+
+func buildBfdPacket(session *BfdSession) *BfdPacket {
+	return &BfdPacket{VersionDiag: BFD_VERSION<<5 | session.diag, StateFlags: session.state << 6, DetectMult: BFD_DETECT_MULT,
+		Length: BFD_PACKET_LENGTH, MyDiscriminator: session.localDiscriminator, YourDiscriminator: session.remoteDiscriminator,
+		DesiredMinTxInterval: getBfdMinTx(session), RequiredMinRxInterval: BFD_RX_INTERVAL * 1000}
+}
+
+func getBfdTxInterval(session *BfdSession) time.Duration {
+	// The slower of what we want and what our neighbor can take, less up to 25% of jitter
+	interval := getBfdMinTx(session)
+	if session.remoteMinRx > interval {
+		interval = session.remoteMinRx
+	}
+	return time.Duration(interval-uint32(rand.Int63n(int64(interval/4)+1))) * time.Microsecond
+}
+
+func getBfdDetectionTime(session *BfdSession) time.Duration {
+	interval := uint32(BFD_RX_INTERVAL * 1000)
+	if session.remoteMinTx > interval {
+		interval = session.remoteMinTx
+	}
+	return time.Duration(session.remoteDetectMult) * time.Duration(interval) * time.Microsecond
+}
+
+func setBfdState(session *BfdSession, state byte, diag byte, now time.Time) {
+	glog.Infof("BFD session with %v on %s %s -> %s", session.peer.IP, session.intfName, bfdStateToString(session.state), bfdStateToString(state))
+	session.state = state
+	session.diag = diag
+	// Let our neighbor know straight away
+	session.nextTx = now
+}
+
+func processBfdPacket(session *BfdSession, packet *BfdPacket, now time.Time) bool {
+	// Run the state machine of RFC 5880 section 6.8.6 for a received control
+	// packet. Returns whether the session went down. Requires the bfd lock to be held
+	session.remoteDiscriminator = packet.MyDiscriminator
+	session.remoteState = packet.StateFlags >> 6
+	session.remoteDetectMult = packet.DetectMult
+	session.remoteMinRx = packet.RequiredMinRxInterval
+	session.remoteMinTx = packet.DesiredMinTxInterval
+	session.lastRx = now
+	up := session.state == BFD_STATE_UP
+	switch {
+	case session.state == BFD_STATE_ADMIN_DOWN:
+		return false
+	case session.remoteState == BFD_STATE_ADMIN_DOWN:
+		if session.state != BFD_STATE_DOWN {
+			setBfdState(session, BFD_STATE_DOWN, BFD_DIAG_NEIGHBOR_DOWN, now)
+		}
+	case session.state == BFD_STATE_DOWN:
+		if session.remoteState == BFD_STATE_DOWN {
+			setBfdState(session, BFD_STATE_INIT, BFD_DIAG_NONE, now)
+		} else if session.remoteState == BFD_STATE_INIT {
+			setBfdState(session, BFD_STATE_UP, BFD_DIAG_NONE, now)
+		}
+	case session.state == BFD_STATE_INIT:
+		if session.remoteState == BFD_STATE_INIT || session.remoteState == BFD_STATE_UP {
+			setBfdState(session, BFD_STATE_UP, BFD_DIAG_NONE, now)
+		}
+	case session.state == BFD_STATE_UP:
+		if session.remoteState == BFD_STATE_DOWN {
+			setBfdState(session, BFD_STATE_DOWN, BFD_DIAG_NEIGHBOR_DOWN, now)
+		}
+	}
+	return up && session.state != BFD_STATE_UP
+}
+
+func checkBfdDetection(session *BfdSession, now time.Time) bool {
+	// Whether the detection time passed without hearing from our neighbor,
+	// taking the session down. Requires the bfd lock to be held
+	if session.state != BFD_STATE_INIT && session.state != BFD_STATE_UP {
+		return false
+	}
+	if now.Sub(session.lastRx) <= getBfdDetectionTime(session) {
+		return false
+	}
+	up := session.state == BFD_STATE_UP
+	setBfdState(session, BFD_STATE_DOWN, BFD_DIAG_DETECT_EXPIRED, now)
+	session.remoteDiscriminator = 0
+	return up
+}
+
+func findBfdSession(packet *BfdPacket, source net.IP) *BfdSession {
+	// Our discriminator picks the session once our neighbor knows it, until
+	// then the source address does. Requires the bfd lock to be held
+	for _, session := range bfdSessions {
+		if packet.YourDiscriminator != 0 && session.localDiscriminator == packet.YourDiscriminator {
+			return session
+		}
+	}
+	if session, ok := bfdSessions[source.String()]; ok && packet.YourDiscriminator == 0 {
+		return session
+	}
+	return nil
+}
+
+func syncBfdSessions(interfaces []*Intf, now time.Time) {
+	// Run a session for every UP adjacency we have an address for on
+	// interfaces with BFD enabled and remove the rest
+	wanted := make(map[string]string)
+	for _, intf := range interfaces {
+		intf.lock.Lock()
+		for _, adj := range intf.adjacencies {
+			if intf.bfd && adj.state == "UP" && adj.neighborIP != nil {
+				wanted[adj.neighborIP.String()] = intf.name
+			}
+		}
+		intf.lock.Unlock()
+	}
+	bfdLock.Lock()
+	defer bfdLock.Unlock()
+	for neighbor, session := range bfdSessions {
+		if wanted[neighbor] != session.intfName {
+			glog.Infof("Removing BFD session with %s on %s", neighbor, session.intfName)
+			delete(bfdSessions, neighbor)
+		}
+	}
+	for neighbor, intfName := range wanted {
+		if _, ok := bfdSessions[neighbor]; !ok {
+			glog.Infof("Starting BFD session with %s on %s", neighbor, intfName)
+			session := newBfdSession(intfName, net.ParseIP(neighbor))
+			session.nextTx = now
+			bfdSessions[neighbor] = session
+		}
+	}
+}
+
+func bfdAdjacencyDown(intf *Intf, neighborIP net.IP, sid string, ourMac []byte, now time.Time) bool {
+	// Bring down the adjacency with a neighbor whose BFD session went down the
+	// same way an expired hold timer does. Returns whether our LSPs need to be
+	// regenerated. Requires the interface lock to be held
+	for _, adj := range intf.adjacencies {
+		if adj.state == "UP" && adj.neighborIP.Equal(neighborIP) {
+			glog.Infof("BFD session with %v on %s down, bringing the adjacency down", neighborIP, intf.name)
+			adj.holdExpiry = now
+		}
+	}
+	return expireAdjacencies(intf, sid, ourMac, now)
+}
+
+func bfdDown(intfName string, neighborIP net.IP, triggerSPF chan bool) {
+	cfg.lock.Lock()
+	sid := cfg.sid
+	var intf *Intf
+	for _, i := range cfg.interfaces {
+		if i.name == intfName {
+			intf = i
+		}
+	}
+	cfg.lock.Unlock()
+	if intf == nil {
+		return
+	}
+	intf.lock.Lock()
+	affected := bfdAdjacencyDown(intf, neighborIP, sid, getMac(intf.name), time.Now())
+	intf.lock.Unlock()
+	if affected {
+		scheduleLocalLsp()
+		scheduleSPF(triggerSPF)
+	}
+}
+
+func bfdListen(address *net.UDPAddr) (*net.UDPConn, error) {
+	// Control packets are sent with a TTL of 255 and dropped by the
+	// kernel unless received with one, so they cannot come from further away
+	conn, err := net.ListenUDP("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	raw.Control(func(fd uintptr) {
+		if err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TTL, BFD_TTL); err == nil {
+			err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MINTTL, BFD_TTL)
+		}
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func sendBfdPacket(conn *net.UDPConn, session *BfdSession) {
+	// Requires the bfd lock to be held
+	if _, err := conn.WriteToUDP(serializeBfdPacket(buildBfdPacket(session)), session.peer); err != nil {
+		glog.V(1).Infof("Unable to send BFD packet to %v: %v", session.peer.IP, err)
+	}
+}
+
+func recvBfdPacket(conn *net.UDPConn) (*BfdPacket, net.IP, error) {
+	buf := make([]byte, 128)
+	length, source, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	packet, err := deserializeBfdPacket(buf[:length])
+	return packet, source.IP, err
+}
+
+func isisBfdRecv(conn *net.UDPConn, triggerSPF chan bool) {
+	// Forever receiving control packets for all the sessions
+	for {
+		packet, source, err := recvBfdPacket(conn)
+		if err != nil && source == nil {
+			glog.Errorf("Unable to receive BFD packets: %v", err)
+			return
+		} else if err != nil {
+			glog.V(1).Infof("Dropping BFD packet from %v: %v", source, err)
+			continue
+		}
+		bfdLock.Lock()
+		session := findBfdSession(packet, source)
+		if session == nil {
+			bfdLock.Unlock()
+			glog.V(2).Infof("No BFD session for packet from %v", source)
+			continue
+		}
+		down := processBfdPacket(session, packet, time.Now())
+		intfName, neighborIP := session.intfName, session.peer.IP
+		bfdLock.Unlock()
+		if down {
+			bfdDown(intfName, neighborIP, triggerSPF)
+		}
+	}
+}
+
+func isisBfdTimers(conn *net.UDPConn, triggerSPF chan bool) {
+	// Every BFD_TIMER_PERIOD start and stop sessions as adjacencies come and
+	// go, send the control packets which are due and check for neighbors we
+	// have stopped hearing from
+	for {
+		time.Sleep(BFD_TIMER_PERIOD * time.Millisecond)
+		cfg.lock.Lock()
+		sid := cfg.sid
+		interfaces := cfg.interfaces
+		cfg.lock.Unlock()
+		if sid == "" {
+			continue
+		}
+		now := time.Now()
+		syncBfdSessions(interfaces, now)
+		down := make([]*BfdSession, 0)
+		bfdLock.Lock()
+		for _, session := range bfdSessions {
+			if checkBfdDetection(session, now) {
+				down = append(down, &BfdSession{intfName: session.intfName, peer: session.peer})
+			}
+			if session.remoteMinRx != 0 && !now.Before(session.nextTx) {
+				sendBfdPacket(conn, session)
+				session.nextTx = now.Add(getBfdTxInterval(session))
+			}
+		}
+		bfdLock.Unlock()
+		for _, session := range down {
+			bfdDown(session.intfName, session.peer.IP, triggerSPF)
+		}
+	}
+}
+
+func bfdInit(triggerSPF chan bool) {
+	// Start receiving on the BFD port and sending from the source port
+	recvConn, err := bfdListen(&net.UDPAddr{Port: BFD_PORT})
+	if err != nil {
+		glog.Errorf("Unable to listen for BFD: %v", err)
+		return
+	}
+	sendConn, err := bfdListen(&net.UDPAddr{Port: BFD_SOURCE_PORT})
+	if err != nil {
+		glog.Errorf("Unable to open the BFD source port: %v", err)
+		recvConn.Close()
+		return
+	}
+	go isisBfdRecv(recvConn, triggerSPF)
+	go isisBfdTimers(sendConn, triggerSPF)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBfdPacket(t *testing.T) {
+	session := &BfdSession{peer: &net.UDPAddr{IP: net.ParseIP("10.0.0.2")}, state: BFD_STATE_UP, localDiscriminator: 7, remoteDiscriminator: 9}
+	packet, err := deserializeBfdPacket(serializeBfdPacket(buildBfdPacket(session)))
+	if err != nil || packet.MyDiscriminator != 7 || packet.YourDiscriminator != 9 || packet.StateFlags>>6 != BFD_STATE_UP ||
+		packet.DesiredMinTxInterval != BFD_TX_INTERVAL*1000 {
+		t.Fatalf("Unexpected packet %+v: %v", packet, err)
+	}
+	// Only a session which is down can leave out our discriminator
+	session.remoteDiscriminator = 0
+	if _, err := deserializeBfdPacket(serializeBfdPacket(buildBfdPacket(session))); err == nil {
+		t.Fail()
+	}
+}
+
+func TestBfdStateMachine(t *testing.T) {
+	now := time.Now()
+	a := &BfdSession{peer: &net.UDPAddr{}, state: BFD_STATE_DOWN, localDiscriminator: 1}
+	b := &BfdSession{peer: &net.UDPAddr{}, state: BFD_STATE_DOWN, localDiscriminator: 2}
+	// Down -> Init -> Up on both ends
+	processBfdPacket(b, buildBfdPacket(a), now)
+	processBfdPacket(a, buildBfdPacket(b), now)
+	processBfdPacket(b, buildBfdPacket(a), now)
+	if a.state != BFD_STATE_UP || b.state != BFD_STATE_UP {
+		t.Fatalf("Expected both sessions UP, got %s and %s", bfdStateToString(a.state), bfdStateToString(b.state))
+	}
+	// Not hearing from b for the detection time takes a down
+	if checkBfdDetection(a, now.Add(getBfdDetectionTime(a))) || !checkBfdDetection(a, now.Add(getBfdDetectionTime(a)+time.Millisecond)) {
+		t.Fatalf("Expected a to go down once the detection time passed")
+	}
+	if a.diag != BFD_DIAG_DETECT_EXPIRED || a.remoteDiscriminator != 0 {
+		t.Fail()
+	}
+	// and b goes down as soon as it hears about it
+	if !processBfdPacket(b, buildBfdPacket(a), now) || b.diag != BFD_DIAG_NEIGHBOR_DOWN {
+		t.Fail()
+	}
+}
+
+func TestBfdAdjacencyDown(t *testing.T) {
+	// A stand-in peer on the loopback brings the session up, then goes quiet
+	initConfig()
+	cfg.sid = "1111.1111.1111"
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, bfd: true,
+		adjacencies: []*Adjacency{&Adjacency{state: "UP", level: LEVEL_1, neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12},
+			neighborIP: net.ParseIP("127.0.0.1"), intfName: "eth0"}}}
+	cfg.interfaces = []*Intf{intf}
+	updateDBInit()
+	conn, err := bfdListen(&net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	peerConn, err := bfdListen(&net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peerConn.Close()
+	now := time.Now()
+	syncBfdSessions(cfg.interfaces, now)
+	session := bfdSessions["127.0.0.1"]
+	if session == nil {
+		t.Fatalf("Expected a session for the UP adjacency")
+	}
+	session.peer = peerConn.LocalAddr().(*net.UDPAddr)
+	peer := &BfdSession{peer: conn.LocalAddr().(*net.UDPAddr), state: BFD_STATE_DOWN, localDiscriminator: 100}
+	conn.SetReadDeadline(now.Add(time.Second))
+	peerConn.SetReadDeadline(now.Add(time.Second))
+	for i := 0; i < 2; i++ {
+		sendBfdPacket(conn, session)
+		packet, _, err := recvBfdPacket(peerConn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		processBfdPacket(peer, packet, now)
+		sendBfdPacket(peerConn, peer)
+		packet, source, err := recvBfdPacket(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if findBfdSession(packet, source) != session {
+			t.Fatalf("Expected the packet to match our session")
+		}
+		processBfdPacket(session, packet, now)
+	}
+	if session.state != BFD_STATE_UP || peer.state != BFD_STATE_UP {
+		t.Fatalf("Expected both sessions UP, got %s and %s", bfdStateToString(session.state), bfdStateToString(peer.state))
+	}
+	// The peer goes quiet and the adjacency goes down straight away
+	if !checkBfdDetection(session, now.Add(time.Second+getBfdDetectionTime(session))) {
+		t.Fatalf("Expected the session to go down")
+	}
+	if !bfdAdjacencyDown(intf, net.ParseIP("127.0.0.1"), cfg.sid, nil, now) || intf.adjacencies[0].state != "NEW" {
+		t.Fatalf("Expected the adjacency to go down, got %s", intf.adjacencies[0].state)
+	}
+	syncBfdSessions(cfg.interfaces, now)
+	if len(bfdSessions) != 0 {
+		t.Fail()
+	}
+	initConfig()
+}
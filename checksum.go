@@ -0,0 +1,58 @@
+// LSP checksums in the IS-IS protocol.
+// The ISO 8473 Fletcher checksum, which leaves out the remaining lifetime.
+// +build linux
+
+package main
+
+const (
+	LSP_CHECKSUM_START  = 12 // Offset of the LSP ID in the PDU
+	LSP_CHECKSUM_OFFSET = 12 // Offset of the checksum from the LSP ID
+)
+
+func fletcherSums(data []byte) (int, int) {
+	c0, c1 := 0, 0
+	for _, b := range data {
+		c0 = (c0 + int(b)) % 255
+		c1 = (c1 + c0) % 255
+	}
+	return c0, c1
+}
+
+func fletcherChecksum(data []byte, offset int) [2]byte {
+	// Checksum bytes to go at offset in data, which is expected to be zero
+	// there. Chosen such that both sums over data come out to zero
+	c0, c1 := fletcherSums(data)
+	x := ((len(data)-offset-1)*c0 - c1) % 255
+	if x <= 0 {
+		x += 255
+	}
+	y := 510 - c0 - x
+	if y > 255 {
+		y -= 255
+	}
+	return [2]byte{byte(x), byte(y)}
+}
+
+func setLspChecksum(pdu []byte) [2]byte {
+	// Fill in the checksum of a serialized LSP, without the ethernet header
+	pdu[LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET] = 0
+	pdu[LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET+1] = 0
+	checksum := fletcherChecksum(pdu[LSP_CHECKSUM_START:], LSP_CHECKSUM_OFFSET)
+	copy(pdu[LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET:], checksum[:])
+	return checksum
+}
+
+func verifyLspChecksum(pdu []byte) bool {
+	// A zero checksum means it was never computed, which is only
+	// acceptable on purges where the contents no longer matter
+	if len(pdu) < LSP_HEADER_SIZE {
+		return false
+	}
+	remainingLifetime := pdu[10:12]
+	checksum := pdu[LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET : LSP_CHECKSUM_START+LSP_CHECKSUM_OFFSET+2]
+	if checksum[0] == 0 && checksum[1] == 0 {
+		return remainingLifetime[0] == 0 && remainingLifetime[1] == 0
+	}
+	c0, c1 := fletcherSums(pdu[LSP_CHECKSUM_START:])
+	return c0 == 0 && c1 == 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFletcherChecksum(t *testing.T) {
+	lsp := buildEmptyLSP(LEVEL_1, 7, "1111.1111.1111")
+	lsp.CoreLsp.FirstTLV = getAreaAddressesTLV([][]byte{DEFAULT_AREA})
+	pdu := serializeLsp(lsp.CoreLsp)
+	if lsp.CoreLsp.LspHeader.Checksum == [2]byte{} || !verifyLspChecksum(pdu) {
+		t.Fatalf("Expected a valid checksum, got %v", lsp.CoreLsp.LspHeader.Checksum)
+	}
+	// Aging doesn't affect the checksum
+	pdu[11]--
+	if !verifyLspChecksum(pdu) {
+		t.Fatalf("Expected the remaining lifetime to be outside the checksum")
+	}
+	pdu[len(pdu)-1] ^= 0x01
+	if verifyLspChecksum(pdu) {
+		t.Fail()
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	// Same sequence number but different contents purges our copy
+	initConfig()
+	intf := &Intf{name: "eth0", level: LEVEL_1, lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	cfg.interfaces = []*Intf{intf}
+	db := buildTestDB([]string{"1111.1111.1111"}, 4)
+	ours := AvlSearch(db.Root, systemIDToKey("1111.1111.1111")).(*IsisLsp)
+	serializeLsp(ours.CoreLsp)
+	received := buildEmptyLSP(LEVEL_1, 4, "1111.1111.1111")
+	received.CoreLsp.FirstTLV = getAreaAddressesTLV([][]byte{DEFAULT_AREA})
+	serializeLsp(received.CoreLsp)
+	if !receiveLsp(intf, db, received) || getRemainingLifetime(ours) != 0 || !intf.lspFloodStates[0][ours.Key].SRM {
+		t.Fail()
+	}
+}
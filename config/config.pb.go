@@ -36,7 +36,7 @@ func (m *IntfRequest) Reset()         { *m = IntfRequest{} }
 func (m *IntfRequest) String() string { return proto.CompactTextString(m) }
 func (*IntfRequest) ProtoMessage()    {}
 func (*IntfRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{0}
+	return fileDescriptor_config_f995d73f016a58d0, []int{0}
 }
 func (m *IntfRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_IntfRequest.Unmarshal(m, b)
@@ -74,7 +74,7 @@ func (m *IntfReply) Reset()         { *m = IntfReply{} }
 func (m *IntfReply) String() string { return proto.CompactTextString(m) }
 func (*IntfReply) ProtoMessage()    {}
 func (*IntfReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{1}
+	return fileDescriptor_config_f995d73f016a58d0, []int{1}
 }
 func (m *IntfReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_IntfReply.Unmarshal(m, b)
@@ -112,7 +112,7 @@ func (m *LspRequest) Reset()         { *m = LspRequest{} }
 func (m *LspRequest) String() string { return proto.CompactTextString(m) }
 func (*LspRequest) ProtoMessage()    {}
 func (*LspRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{2}
+	return fileDescriptor_config_f995d73f016a58d0, []int{2}
 }
 func (m *LspRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LspRequest.Unmarshal(m, b)
@@ -152,7 +152,7 @@ func (m *LspReply) Reset()         { *m = LspReply{} }
 func (m *LspReply) String() string { return proto.CompactTextString(m) }
 func (*LspReply) ProtoMessage()    {}
 func (*LspReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{3}
+	return fileDescriptor_config_f995d73f016a58d0, []int{3}
 }
 func (m *LspReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LspReply.Unmarshal(m, b)
@@ -180,7 +180,10 @@ func (m *LspReply) GetLsp() []string {
 }
 
 type TopoRequest struct {
-	ShTopo               string   `protobuf:"bytes,1,opt,name=shTopo" json:"shTopo,omitempty"`
+	ShTopo string `protobuf:"bytes,1,opt,name=shTopo" json:"shTopo,omitempty"`
+	// Topology to show, 0 for the standard topology or 2 for IPv6 unicast
+	// with multi-topology enabled
+	MtID                 uint32   `protobuf:"varint,2,opt,name=mtID" json:"mtID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -190,7 +193,7 @@ func (m *TopoRequest) Reset()         { *m = TopoRequest{} }
 func (m *TopoRequest) String() string { return proto.CompactTextString(m) }
 func (*TopoRequest) ProtoMessage()    {}
 func (*TopoRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{4}
+	return fileDescriptor_config_f995d73f016a58d0, []int{4}
 }
 func (m *TopoRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TopoRequest.Unmarshal(m, b)
@@ -217,6 +220,13 @@ func (m *TopoRequest) GetShTopo() string {
 	return ""
 }
 
+func (m *TopoRequest) GetMtID() uint32 {
+	if m != nil {
+		return m.MtID
+	}
+	return 0
+}
+
 type TopoReply struct {
 	// Similar to interface requests, can specify a specific lsp
 	// or an empty string will return all of them
@@ -230,7 +240,7 @@ func (m *TopoReply) Reset()         { *m = TopoReply{} }
 func (m *TopoReply) String() string { return proto.CompactTextString(m) }
 func (*TopoReply) ProtoMessage()    {}
 func (*TopoReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{5}
+	return fileDescriptor_config_f995d73f016a58d0, []int{5}
 }
 func (m *TopoReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TopoReply.Unmarshal(m, b)
@@ -257,6 +267,154 @@ func (m *TopoReply) GetTopo() []string {
 	return nil
 }
 
+// Constrained SPF over the traffic engineering database of a level
+type PathRequest struct {
+	// System ID or hostname of the head end, empty for us
+	Source string `protobuf:"bytes,1,opt,name=source" json:"source,omitempty"`
+	// System ID or hostname of the tail end
+	Destination string `protobuf:"bytes,2,opt,name=destination" json:"destination,omitempty"`
+	// level-1 or level-2, empty for the lowest level we run
+	Level string `protobuf:"bytes,3,opt,name=level" json:"level,omitempty"`
+	// Reservable bandwidth every link needs in bits per second
+	Bandwidth uint64 `protobuf:"varint,4,opt,name=bandwidth" json:"bandwidth,omitempty"`
+	// Administrative groups links must have at least one of, all of or none of
+	IncludeAny uint32 `protobuf:"varint,5,opt,name=includeAny" json:"includeAny,omitempty"`
+	IncludeAll uint32 `protobuf:"varint,6,opt,name=includeAll" json:"includeAll,omitempty"`
+	ExcludeAny uint32 `protobuf:"varint,7,opt,name=excludeAny" json:"excludeAny,omitempty"`
+	// Metric to minimise, te (the default) or igp
+	Metric               string   `protobuf:"bytes,8,opt,name=metric" json:"metric,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PathRequest) Reset()         { *m = PathRequest{} }
+func (m *PathRequest) String() string { return proto.CompactTextString(m) }
+func (*PathRequest) ProtoMessage()    {}
+func (*PathRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{6}
+}
+func (m *PathRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PathRequest.Unmarshal(m, b)
+}
+func (m *PathRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PathRequest.Marshal(b, m, deterministic)
+}
+func (dst *PathRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PathRequest.Merge(dst, src)
+}
+func (m *PathRequest) XXX_Size() int {
+	return xxx_messageInfo_PathRequest.Size(m)
+}
+func (m *PathRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PathRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PathRequest proto.InternalMessageInfo
+
+func (m *PathRequest) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *PathRequest) GetDestination() string {
+	if m != nil {
+		return m.Destination
+	}
+	return ""
+}
+
+func (m *PathRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func (m *PathRequest) GetBandwidth() uint64 {
+	if m != nil {
+		return m.Bandwidth
+	}
+	return 0
+}
+
+func (m *PathRequest) GetIncludeAny() uint32 {
+	if m != nil {
+		return m.IncludeAny
+	}
+	return 0
+}
+
+func (m *PathRequest) GetIncludeAll() uint32 {
+	if m != nil {
+		return m.IncludeAll
+	}
+	return 0
+}
+
+func (m *PathRequest) GetExcludeAny() uint32 {
+	if m != nil {
+		return m.ExcludeAny
+	}
+	return 0
+}
+
+func (m *PathRequest) GetMetric() string {
+	if m != nil {
+		return m.Metric
+	}
+	return ""
+}
+
+type PathReply struct {
+	// Each node from the head end to the tail end and the link to it
+	Hops                 []string `protobuf:"bytes,1,rep,name=hops" json:"hops,omitempty"`
+	Cost                 uint32   `protobuf:"varint,2,opt,name=cost" json:"cost,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PathReply) Reset()         { *m = PathReply{} }
+func (m *PathReply) String() string { return proto.CompactTextString(m) }
+func (*PathReply) ProtoMessage()    {}
+func (*PathReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{7}
+}
+func (m *PathReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PathReply.Unmarshal(m, b)
+}
+func (m *PathReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PathReply.Marshal(b, m, deterministic)
+}
+func (dst *PathReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PathReply.Merge(dst, src)
+}
+func (m *PathReply) XXX_Size() int {
+	return xxx_messageInfo_PathReply.Size(m)
+}
+func (m *PathReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PathReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PathReply proto.InternalMessageInfo
+
+func (m *PathReply) GetHops() []string {
+	if m != nil {
+		return m.Hops
+	}
+	return nil
+}
+
+func (m *PathReply) GetCost() uint32 {
+	if m != nil {
+		return m.Cost
+	}
+	return 0
+}
+
 type SystemIDRequest struct {
 	ShSystemID           string   `protobuf:"bytes,1,opt,name=shSystemID" json:"shSystemID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -268,7 +426,7 @@ func (m *SystemIDRequest) Reset()         { *m = SystemIDRequest{} }
 func (m *SystemIDRequest) String() string { return proto.CompactTextString(m) }
 func (*SystemIDRequest) ProtoMessage()    {}
 func (*SystemIDRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{6}
+	return fileDescriptor_config_f995d73f016a58d0, []int{8}
 }
 func (m *SystemIDRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SystemIDRequest.Unmarshal(m, b)
@@ -296,7 +454,19 @@ func (m *SystemIDRequest) GetShSystemID() string {
 }
 
 type SystemIDReply struct {
-	Sid                  string   `protobuf:"bytes,1,opt,name=sid" json:"sid,omitempty"`
+	Sid         string   `protobuf:"bytes,1,opt,name=sid" json:"sid,omitempty"`
+	Area        []string `protobuf:"bytes,2,rep,name=area" json:"area,omitempty"`
+	Hostname    string   `protobuf:"bytes,3,opt,name=hostname" json:"hostname,omitempty"`
+	MetricStyle string   `protobuf:"bytes,4,opt,name=metricStyle" json:"metricStyle,omitempty"`
+	// Whether our LSPs have the overload bit set
+	Overload bool `protobuf:"varint,5,opt,name=overload" json:"overload,omitempty"`
+	// Labels of our SRGB with segment routing enabled, empty otherwise
+	Srgb string `protobuf:"bytes,6,opt,name=srgb" json:"srgb,omitempty"`
+	// Equal cost paths kept for each destination
+	MaxPaths uint32 `protobuf:"varint,7,opt,name=maxPaths" json:"maxPaths,omitempty"`
+	// Throttle timers of SPF and the generation of our LSPs
+	SpfThrottle          string   `protobuf:"bytes,8,opt,name=spfThrottle" json:"spfThrottle,omitempty"`
+	LspThrottle          string   `protobuf:"bytes,9,opt,name=lspThrottle" json:"lspThrottle,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -306,7 +476,7 @@ func (m *SystemIDReply) Reset()         { *m = SystemIDReply{} }
 func (m *SystemIDReply) String() string { return proto.CompactTextString(m) }
 func (*SystemIDReply) ProtoMessage()    {}
 func (*SystemIDReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{7}
+	return fileDescriptor_config_f995d73f016a58d0, []int{9}
 }
 func (m *SystemIDReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SystemIDReply.Unmarshal(m, b)
@@ -333,6 +503,62 @@ func (m *SystemIDReply) GetSid() string {
 	return ""
 }
 
+func (m *SystemIDReply) GetArea() []string {
+	if m != nil {
+		return m.Area
+	}
+	return nil
+}
+
+func (m *SystemIDReply) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *SystemIDReply) GetMetricStyle() string {
+	if m != nil {
+		return m.MetricStyle
+	}
+	return ""
+}
+
+func (m *SystemIDReply) GetOverload() bool {
+	if m != nil {
+		return m.Overload
+	}
+	return false
+}
+
+func (m *SystemIDReply) GetSrgb() string {
+	if m != nil {
+		return m.Srgb
+	}
+	return ""
+}
+
+func (m *SystemIDReply) GetMaxPaths() uint32 {
+	if m != nil {
+		return m.MaxPaths
+	}
+	return 0
+}
+
+func (m *SystemIDReply) GetSpfThrottle() string {
+	if m != nil {
+		return m.SpfThrottle
+	}
+	return ""
+}
+
+func (m *SystemIDReply) GetLspThrottle() string {
+	if m != nil {
+		return m.LspThrottle
+	}
+	return ""
+}
+
 // The request message containing the system id to use
 type SystemIDCfgRequest struct {
 	Sid                  string   `protobuf:"bytes,1,opt,name=sid" json:"sid,omitempty"`
@@ -345,7 +571,7 @@ func (m *SystemIDCfgRequest) Reset()         { *m = SystemIDCfgRequest{} }
 func (m *SystemIDCfgRequest) String() string { return proto.CompactTextString(m) }
 func (*SystemIDCfgRequest) ProtoMessage()    {}
 func (*SystemIDCfgRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{8}
+	return fileDescriptor_config_f995d73f016a58d0, []int{10}
 }
 func (m *SystemIDCfgRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SystemIDCfgRequest.Unmarshal(m, b)
@@ -384,7 +610,7 @@ func (m *SystemIDCfgReply) Reset()         { *m = SystemIDCfgReply{} }
 func (m *SystemIDCfgReply) String() string { return proto.CompactTextString(m) }
 func (*SystemIDCfgReply) ProtoMessage()    {}
 func (*SystemIDCfgReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_config_7eec158168b3a239, []int{9}
+	return fileDescriptor_config_f995d73f016a58d0, []int{11}
 }
 func (m *SystemIDCfgReply) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SystemIDCfgReply.Unmarshal(m, b)
@@ -411,153 +637,1880 @@ func (m *SystemIDCfgReply) GetAck() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*IntfRequest)(nil), "config.IntfRequest")
-	proto.RegisterType((*IntfReply)(nil), "config.IntfReply")
-	proto.RegisterType((*LspRequest)(nil), "config.LspRequest")
-	proto.RegisterType((*LspReply)(nil), "config.LspReply")
-	proto.RegisterType((*TopoRequest)(nil), "config.TopoRequest")
-	proto.RegisterType((*TopoReply)(nil), "config.TopoReply")
-	proto.RegisterType((*SystemIDRequest)(nil), "config.SystemIDRequest")
-	proto.RegisterType((*SystemIDReply)(nil), "config.SystemIDReply")
-	proto.RegisterType((*SystemIDCfgRequest)(nil), "config.SystemIDCfgRequest")
-	proto.RegisterType((*SystemIDCfgReply)(nil), "config.SystemIDCfgReply")
+// Per interface configuration, the interface is selected by name
+type IntfCfgRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Either broadcast (the default) or p2p, empty leaves it unchanged
+	CircuitType string `protobuf:"bytes,2,opt,name=circuitType" json:"circuitType,omitempty"`
+	// DIS election priority on broadcast circuits (1-127, default 64), 0 leaves it unchanged
+	Priority uint32 `protobuf:"varint,3,opt,name=priority" json:"priority,omitempty"`
+	// Levels to run on the interface, level-1, level-2 or level-1-2 (the default),
+	// limited by the levels the instance runs. Empty leaves it unchanged
+	Level string `protobuf:"bytes,4,opt,name=level" json:"level,omitempty"`
+	// Whether hellos are padded out to the interface MTU, enabled (the default)
+	// or disabled. Empty leaves it unchanged
+	HelloPadding string `protobuf:"bytes,5,opt,name=helloPadding" json:"helloPadding,omitempty"`
+	// Link metric in the IPv6 topology with multi-topology enabled (default 10),
+	// 0 leaves it unchanged
+	Ipv6Metric uint32 `protobuf:"varint,6,opt,name=ipv6Metric" json:"ipv6Metric,omitempty"`
+	// Keychain to authenticate hellos with, none removes it. Empty leaves it unchanged
+	HelloKeychain string `protobuf:"bytes,7,opt,name=helloKeychain" json:"helloKeychain,omitempty"`
+	// BFD sessions with the UP neighbors, enabled or disabled (the default).
+	// Empty leaves it unchanged
+	Bfd string `protobuf:"bytes,8,opt,name=bfd" json:"bfd,omitempty"`
+	// Traffic engineering attributes advertised with the interface's links,
+	// 0 leaves each unchanged. The TE metric defaults to the link metric
+	TeMetric uint32 `protobuf:"varint,9,opt,name=teMetric" json:"teMetric,omitempty"`
+	// Maximum and maximum reservable bandwidth in bits per second
+	MaxBandwidth        uint64 `protobuf:"varint,10,opt,name=maxBandwidth" json:"maxBandwidth,omitempty"`
+	ReservableBandwidth uint64 `protobuf:"varint,11,opt,name=reservableBandwidth" json:"reservableBandwidth,omitempty"`
+	// Administrative group bit mask i.e. 0x5, 0 removes it. Empty leaves it unchanged
+	AdminGroup string `protobuf:"bytes,12,opt,name=adminGroup" json:"adminGroup,omitempty"`
+	// Link metric in the standard topology (default 10, up to 16777214 with wide
+	// metrics and 63 with narrow ones), 0 leaves it unchanged
+	Metric               uint32   `protobuf:"varint,13,opt,name=metric" json:"metric,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// Client API for Configure service
-
-type ConfigureClient interface {
-	ConfigureSystemID(ctx context.Context, in *SystemIDCfgRequest, opts ...grpc.CallOption) (*SystemIDCfgReply, error)
+func (m *IntfCfgRequest) Reset()         { *m = IntfCfgRequest{} }
+func (m *IntfCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*IntfCfgRequest) ProtoMessage()    {}
+func (*IntfCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{12}
 }
-
-type configureClient struct {
-	cc *grpc.ClientConn
+func (m *IntfCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IntfCfgRequest.Unmarshal(m, b)
 }
-
-func NewConfigureClient(cc *grpc.ClientConn) ConfigureClient {
-	return &configureClient{cc}
+func (m *IntfCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IntfCfgRequest.Marshal(b, m, deterministic)
 }
-
-func (c *configureClient) ConfigureSystemID(ctx context.Context, in *SystemIDCfgRequest, opts ...grpc.CallOption) (*SystemIDCfgReply, error) {
-	out := new(SystemIDCfgReply)
-	err := grpc.Invoke(ctx, "/config.Configure/ConfigureSystemID", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (dst *IntfCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntfCfgRequest.Merge(dst, src)
+}
+func (m *IntfCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_IntfCfgRequest.Size(m)
+}
+func (m *IntfCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntfCfgRequest.DiscardUnknown(m)
 }
 
-// Server API for Configure service
+var xxx_messageInfo_IntfCfgRequest proto.InternalMessageInfo
 
-type ConfigureServer interface {
-	ConfigureSystemID(context.Context, *SystemIDCfgRequest) (*SystemIDCfgReply, error)
+func (m *IntfCfgRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
 }
 
-func RegisterConfigureServer(s *grpc.Server, srv ConfigureServer) {
-	s.RegisterService(&_Configure_serviceDesc, srv)
+func (m *IntfCfgRequest) GetCircuitType() string {
+	if m != nil {
+		return m.CircuitType
+	}
+	return ""
 }
 
-func _Configure_ConfigureSystemID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SystemIDCfgRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(ConfigureServer).ConfigureSystemID(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/config.Configure/ConfigureSystemID",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ConfigureServer).ConfigureSystemID(ctx, req.(*SystemIDCfgRequest))
+func (m *IntfCfgRequest) GetPriority() uint32 {
+	if m != nil {
+		return m.Priority
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
 }
 
-var _Configure_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "config.Configure",
-	HandlerType: (*ConfigureServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "ConfigureSystemID",
-			Handler:    _Configure_ConfigureSystemID_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "config.proto",
+func (m *IntfCfgRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
 }
 
-// Client API for State service
+func (m *IntfCfgRequest) GetHelloPadding() string {
+	if m != nil {
+		return m.HelloPadding
+	}
+	return ""
+}
 
-type StateClient interface {
-	// Could also support streaming updates out this system ?
-	GetIntf(ctx context.Context, in *IntfRequest, opts ...grpc.CallOption) (*IntfReply, error)
-	GetLsp(ctx context.Context, in *LspRequest, opts ...grpc.CallOption) (*LspReply, error)
-	GetSystemID(ctx context.Context, in *SystemIDRequest, opts ...grpc.CallOption) (*SystemIDReply, error)
-	GetTopo(ctx context.Context, in *TopoRequest, opts ...grpc.CallOption) (*TopoReply, error)
+func (m *IntfCfgRequest) GetIpv6Metric() uint32 {
+	if m != nil {
+		return m.Ipv6Metric
+	}
+	return 0
 }
 
-type stateClient struct {
-	cc *grpc.ClientConn
+func (m *IntfCfgRequest) GetHelloKeychain() string {
+	if m != nil {
+		return m.HelloKeychain
+	}
+	return ""
 }
 
-func NewStateClient(cc *grpc.ClientConn) StateClient {
-	return &stateClient{cc}
+func (m *IntfCfgRequest) GetBfd() string {
+	if m != nil {
+		return m.Bfd
+	}
+	return ""
 }
 
-func (c *stateClient) GetIntf(ctx context.Context, in *IntfRequest, opts ...grpc.CallOption) (*IntfReply, error) {
-	out := new(IntfReply)
-	err := grpc.Invoke(ctx, "/config.State/GetIntf", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *IntfCfgRequest) GetTeMetric() uint32 {
+	if m != nil {
+		return m.TeMetric
 	}
-	return out, nil
+	return 0
 }
 
-func (c *stateClient) GetLsp(ctx context.Context, in *LspRequest, opts ...grpc.CallOption) (*LspReply, error) {
-	out := new(LspReply)
-	err := grpc.Invoke(ctx, "/config.State/GetLsp", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *IntfCfgRequest) GetMaxBandwidth() uint64 {
+	if m != nil {
+		return m.MaxBandwidth
 	}
-	return out, nil
+	return 0
 }
 
-func (c *stateClient) GetSystemID(ctx context.Context, in *SystemIDRequest, opts ...grpc.CallOption) (*SystemIDReply, error) {
-	out := new(SystemIDReply)
-	err := grpc.Invoke(ctx, "/config.State/GetSystemID", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *IntfCfgRequest) GetReservableBandwidth() uint64 {
+	if m != nil {
+		return m.ReservableBandwidth
 	}
-	return out, nil
+	return 0
 }
 
-func (c *stateClient) GetTopo(ctx context.Context, in *TopoRequest, opts ...grpc.CallOption) (*TopoReply, error) {
-	out := new(TopoReply)
-	err := grpc.Invoke(ctx, "/config.State/GetTopo", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *IntfCfgRequest) GetAdminGroup() string {
+	if m != nil {
+		return m.AdminGroup
 	}
-	return out, nil
+	return ""
 }
 
-// Server API for State service
+func (m *IntfCfgRequest) GetMetric() uint32 {
+	if m != nil {
+		return m.Metric
+	}
+	return 0
+}
 
-type StateServer interface {
-	// Could also support streaming updates out this system ?
-	GetIntf(context.Context, *IntfRequest) (*IntfReply, error)
+type IntfCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IntfCfgReply) Reset()         { *m = IntfCfgReply{} }
+func (m *IntfCfgReply) String() string { return proto.CompactTextString(m) }
+func (*IntfCfgReply) ProtoMessage()    {}
+func (*IntfCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{13}
+}
+func (m *IntfCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IntfCfgReply.Unmarshal(m, b)
+}
+func (m *IntfCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IntfCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *IntfCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntfCfgReply.Merge(dst, src)
+}
+func (m *IntfCfgReply) XXX_Size() int {
+	return xxx_messageInfo_IntfCfgReply.Size(m)
+}
+func (m *IntfCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntfCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntfCfgReply proto.InternalMessageInfo
+
+func (m *IntfCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Levels the whole instance runs, level-1 (the default), level-2 or level-1-2
+type LevelCfgRequest struct {
+	Level                string   `protobuf:"bytes,1,opt,name=level" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LevelCfgRequest) Reset()         { *m = LevelCfgRequest{} }
+func (m *LevelCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*LevelCfgRequest) ProtoMessage()    {}
+func (*LevelCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{14}
+}
+func (m *LevelCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LevelCfgRequest.Unmarshal(m, b)
+}
+func (m *LevelCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LevelCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *LevelCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LevelCfgRequest.Merge(dst, src)
+}
+func (m *LevelCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_LevelCfgRequest.Size(m)
+}
+func (m *LevelCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LevelCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LevelCfgRequest proto.InternalMessageInfo
+
+func (m *LevelCfgRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type LevelCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LevelCfgReply) Reset()         { *m = LevelCfgReply{} }
+func (m *LevelCfgReply) String() string { return proto.CompactTextString(m) }
+func (*LevelCfgReply) ProtoMessage()    {}
+func (*LevelCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{15}
+}
+func (m *LevelCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LevelCfgReply.Unmarshal(m, b)
+}
+func (m *LevelCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LevelCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *LevelCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LevelCfgReply.Merge(dst, src)
+}
+func (m *LevelCfgReply) XXX_Size() int {
+	return xxx_messageInfo_LevelCfgReply.Size(m)
+}
+func (m *LevelCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_LevelCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LevelCfgReply proto.InternalMessageInfo
+
+func (m *LevelCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// A full NET i.e. 49.0001.1921.6800.1001.00, the area address followed by
+// the system ID and a zero NSEL. Configuring another NET with the same system
+// ID adds its area, up to 3, a different system ID replaces the areas
+type NETCfgRequest struct {
+	Net                  string   `protobuf:"bytes,1,opt,name=net" json:"net,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NETCfgRequest) Reset()         { *m = NETCfgRequest{} }
+func (m *NETCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*NETCfgRequest) ProtoMessage()    {}
+func (*NETCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{16}
+}
+func (m *NETCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NETCfgRequest.Unmarshal(m, b)
+}
+func (m *NETCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NETCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *NETCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NETCfgRequest.Merge(dst, src)
+}
+func (m *NETCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_NETCfgRequest.Size(m)
+}
+func (m *NETCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NETCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NETCfgRequest proto.InternalMessageInfo
+
+func (m *NETCfgRequest) GetNet() string {
+	if m != nil {
+		return m.Net
+	}
+	return ""
+}
+
+type NETCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NETCfgReply) Reset()         { *m = NETCfgReply{} }
+func (m *NETCfgReply) String() string { return proto.CompactTextString(m) }
+func (*NETCfgReply) ProtoMessage()    {}
+func (*NETCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{17}
+}
+func (m *NETCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NETCfgReply.Unmarshal(m, b)
+}
+func (m *NETCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NETCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *NETCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NETCfgReply.Merge(dst, src)
+}
+func (m *NETCfgReply) XXX_Size() int {
+	return xxx_messageInfo_NETCfgReply.Size(m)
+}
+func (m *NETCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_NETCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NETCfgReply proto.InternalMessageInfo
+
+func (m *NETCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Hostname advertised in our LSPs, defaults to the hostname of the machine
+type HostnameCfgRequest struct {
+	Hostname             string   `protobuf:"bytes,1,opt,name=hostname" json:"hostname,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HostnameCfgRequest) Reset()         { *m = HostnameCfgRequest{} }
+func (m *HostnameCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*HostnameCfgRequest) ProtoMessage()    {}
+func (*HostnameCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{18}
+}
+func (m *HostnameCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HostnameCfgRequest.Unmarshal(m, b)
+}
+func (m *HostnameCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HostnameCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *HostnameCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HostnameCfgRequest.Merge(dst, src)
+}
+func (m *HostnameCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_HostnameCfgRequest.Size(m)
+}
+func (m *HostnameCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HostnameCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HostnameCfgRequest proto.InternalMessageInfo
+
+func (m *HostnameCfgRequest) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+type HostnameCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HostnameCfgReply) Reset()         { *m = HostnameCfgReply{} }
+func (m *HostnameCfgReply) String() string { return proto.CompactTextString(m) }
+func (*HostnameCfgReply) ProtoMessage()    {}
+func (*HostnameCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{19}
+}
+func (m *HostnameCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HostnameCfgReply.Unmarshal(m, b)
+}
+func (m *HostnameCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HostnameCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *HostnameCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HostnameCfgReply.Merge(dst, src)
+}
+func (m *HostnameCfgReply) XXX_Size() int {
+	return xxx_messageInfo_HostnameCfgReply.Size(m)
+}
+func (m *HostnameCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HostnameCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HostnameCfgReply proto.InternalMessageInfo
+
+func (m *HostnameCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// One of narrow (TLV 2 and 128), wide (TLV 22 and 135) or transition (both)
+type MetricStyleCfgRequest struct {
+	Style                string   `protobuf:"bytes,1,opt,name=style" json:"style,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MetricStyleCfgRequest) Reset()         { *m = MetricStyleCfgRequest{} }
+func (m *MetricStyleCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*MetricStyleCfgRequest) ProtoMessage()    {}
+func (*MetricStyleCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{20}
+}
+func (m *MetricStyleCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricStyleCfgRequest.Unmarshal(m, b)
+}
+func (m *MetricStyleCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricStyleCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *MetricStyleCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricStyleCfgRequest.Merge(dst, src)
+}
+func (m *MetricStyleCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_MetricStyleCfgRequest.Size(m)
+}
+func (m *MetricStyleCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricStyleCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricStyleCfgRequest proto.InternalMessageInfo
+
+func (m *MetricStyleCfgRequest) GetStyle() string {
+	if m != nil {
+		return m.Style
+	}
+	return ""
+}
+
+type MetricStyleCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MetricStyleCfgReply) Reset()         { *m = MetricStyleCfgReply{} }
+func (m *MetricStyleCfgReply) String() string { return proto.CompactTextString(m) }
+func (*MetricStyleCfgReply) ProtoMessage()    {}
+func (*MetricStyleCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{21}
+}
+func (m *MetricStyleCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricStyleCfgReply.Unmarshal(m, b)
+}
+func (m *MetricStyleCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricStyleCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *MetricStyleCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricStyleCfgReply.Merge(dst, src)
+}
+func (m *MetricStyleCfgReply) XXX_Size() int {
+	return xxx_messageInfo_MetricStyleCfgReply.Size(m)
+}
+func (m *MetricStyleCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricStyleCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricStyleCfgReply proto.InternalMessageInfo
+
+func (m *MetricStyleCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Whether IPv6 runs in a topology of its own (RFC 5120), enabled or disabled
+// (the default)
+type MultiTopologyCfgRequest struct {
+	State                string   `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MultiTopologyCfgRequest) Reset()         { *m = MultiTopologyCfgRequest{} }
+func (m *MultiTopologyCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*MultiTopologyCfgRequest) ProtoMessage()    {}
+func (*MultiTopologyCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{22}
+}
+func (m *MultiTopologyCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultiTopologyCfgRequest.Unmarshal(m, b)
+}
+func (m *MultiTopologyCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultiTopologyCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *MultiTopologyCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiTopologyCfgRequest.Merge(dst, src)
+}
+func (m *MultiTopologyCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_MultiTopologyCfgRequest.Size(m)
+}
+func (m *MultiTopologyCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiTopologyCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiTopologyCfgRequest proto.InternalMessageInfo
+
+func (m *MultiTopologyCfgRequest) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+type MultiTopologyCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MultiTopologyCfgReply) Reset()         { *m = MultiTopologyCfgReply{} }
+func (m *MultiTopologyCfgReply) String() string { return proto.CompactTextString(m) }
+func (*MultiTopologyCfgReply) ProtoMessage()    {}
+func (*MultiTopologyCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{23}
+}
+func (m *MultiTopologyCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultiTopologyCfgReply.Unmarshal(m, b)
+}
+func (m *MultiTopologyCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultiTopologyCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *MultiTopologyCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiTopologyCfgReply.Merge(dst, src)
+}
+func (m *MultiTopologyCfgReply) XXX_Size() int {
+	return xxx_messageInfo_MultiTopologyCfgReply.Size(m)
+}
+func (m *MultiTopologyCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiTopologyCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiTopologyCfgReply proto.InternalMessageInfo
+
+func (m *MultiTopologyCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// A key in a keychain, which is created with its first key. The algorithm is
+// cleartext, hmac-md5 (RFC 5304), hmac-sha-1 or hmac-sha-256 (RFC 5310).
+// Lifetimes are RFC 3339 times i.e. 2017-01-02T15:04:05Z, an empty start or
+// end leaves that end open. An empty secret removes the key
+type KeychainCfgRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	KeyID                uint32   `protobuf:"varint,2,opt,name=keyID" json:"keyID,omitempty"`
+	Algorithm            string   `protobuf:"bytes,3,opt,name=algorithm" json:"algorithm,omitempty"`
+	Secret               string   `protobuf:"bytes,4,opt,name=secret" json:"secret,omitempty"`
+	SendStart            string   `protobuf:"bytes,5,opt,name=sendStart" json:"sendStart,omitempty"`
+	SendEnd              string   `protobuf:"bytes,6,opt,name=sendEnd" json:"sendEnd,omitempty"`
+	AcceptStart          string   `protobuf:"bytes,7,opt,name=acceptStart" json:"acceptStart,omitempty"`
+	AcceptEnd            string   `protobuf:"bytes,8,opt,name=acceptEnd" json:"acceptEnd,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeychainCfgRequest) Reset()         { *m = KeychainCfgRequest{} }
+func (m *KeychainCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*KeychainCfgRequest) ProtoMessage()    {}
+func (*KeychainCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{24}
+}
+func (m *KeychainCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeychainCfgRequest.Unmarshal(m, b)
+}
+func (m *KeychainCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeychainCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *KeychainCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeychainCfgRequest.Merge(dst, src)
+}
+func (m *KeychainCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_KeychainCfgRequest.Size(m)
+}
+func (m *KeychainCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeychainCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeychainCfgRequest proto.InternalMessageInfo
+
+func (m *KeychainCfgRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *KeychainCfgRequest) GetKeyID() uint32 {
+	if m != nil {
+		return m.KeyID
+	}
+	return 0
+}
+
+func (m *KeychainCfgRequest) GetAlgorithm() string {
+	if m != nil {
+		return m.Algorithm
+	}
+	return ""
+}
+
+func (m *KeychainCfgRequest) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+func (m *KeychainCfgRequest) GetSendStart() string {
+	if m != nil {
+		return m.SendStart
+	}
+	return ""
+}
+
+func (m *KeychainCfgRequest) GetSendEnd() string {
+	if m != nil {
+		return m.SendEnd
+	}
+	return ""
+}
+
+func (m *KeychainCfgRequest) GetAcceptStart() string {
+	if m != nil {
+		return m.AcceptStart
+	}
+	return ""
+}
+
+func (m *KeychainCfgRequest) GetAcceptEnd() string {
+	if m != nil {
+		return m.AcceptEnd
+	}
+	return ""
+}
+
+type KeychainCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeychainCfgReply) Reset()         { *m = KeychainCfgReply{} }
+func (m *KeychainCfgReply) String() string { return proto.CompactTextString(m) }
+func (*KeychainCfgReply) ProtoMessage()    {}
+func (*KeychainCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{25}
+}
+func (m *KeychainCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeychainCfgReply.Unmarshal(m, b)
+}
+func (m *KeychainCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeychainCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *KeychainCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeychainCfgReply.Merge(dst, src)
+}
+func (m *KeychainCfgReply) XXX_Size() int {
+	return xxx_messageInfo_KeychainCfgReply.Size(m)
+}
+func (m *KeychainCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeychainCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeychainCfgReply proto.InternalMessageInfo
+
+func (m *KeychainCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Keychain to authenticate the LSPs and SNPs of level-1, level-2 or
+// level-1-2 with, none removes it
+type LevelAuthCfgRequest struct {
+	Level                string   `protobuf:"bytes,1,opt,name=level" json:"level,omitempty"`
+	Keychain             string   `protobuf:"bytes,2,opt,name=keychain" json:"keychain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LevelAuthCfgRequest) Reset()         { *m = LevelAuthCfgRequest{} }
+func (m *LevelAuthCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*LevelAuthCfgRequest) ProtoMessage()    {}
+func (*LevelAuthCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{26}
+}
+func (m *LevelAuthCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LevelAuthCfgRequest.Unmarshal(m, b)
+}
+func (m *LevelAuthCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LevelAuthCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *LevelAuthCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LevelAuthCfgRequest.Merge(dst, src)
+}
+func (m *LevelAuthCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_LevelAuthCfgRequest.Size(m)
+}
+func (m *LevelAuthCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LevelAuthCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LevelAuthCfgRequest proto.InternalMessageInfo
+
+func (m *LevelAuthCfgRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func (m *LevelAuthCfgRequest) GetKeychain() string {
+	if m != nil {
+		return m.Keychain
+	}
+	return ""
+}
+
+type LevelAuthCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LevelAuthCfgReply) Reset()         { *m = LevelAuthCfgReply{} }
+func (m *LevelAuthCfgReply) String() string { return proto.CompactTextString(m) }
+func (*LevelAuthCfgReply) ProtoMessage()    {}
+func (*LevelAuthCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{27}
+}
+func (m *LevelAuthCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LevelAuthCfgReply.Unmarshal(m, b)
+}
+func (m *LevelAuthCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LevelAuthCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *LevelAuthCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LevelAuthCfgReply.Merge(dst, src)
+}
+func (m *LevelAuthCfgReply) XXX_Size() int {
+	return xxx_messageInfo_LevelAuthCfgReply.Size(m)
+}
+func (m *LevelAuthCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_LevelAuthCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LevelAuthCfgReply proto.InternalMessageInfo
+
+func (m *LevelAuthCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Whether our LSPs have the overload bit set so no transit traffic is routed
+// through us, enabled or disabled (the default)
+type OverloadCfgRequest struct {
+	State                string   `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OverloadCfgRequest) Reset()         { *m = OverloadCfgRequest{} }
+func (m *OverloadCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*OverloadCfgRequest) ProtoMessage()    {}
+func (*OverloadCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{28}
+}
+func (m *OverloadCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OverloadCfgRequest.Unmarshal(m, b)
+}
+func (m *OverloadCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OverloadCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *OverloadCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OverloadCfgRequest.Merge(dst, src)
+}
+func (m *OverloadCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_OverloadCfgRequest.Size(m)
+}
+func (m *OverloadCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_OverloadCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OverloadCfgRequest proto.InternalMessageInfo
+
+func (m *OverloadCfgRequest) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+type OverloadCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OverloadCfgReply) Reset()         { *m = OverloadCfgReply{} }
+func (m *OverloadCfgReply) String() string { return proto.CompactTextString(m) }
+func (*OverloadCfgReply) ProtoMessage()    {}
+func (*OverloadCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{29}
+}
+func (m *OverloadCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OverloadCfgReply.Unmarshal(m, b)
+}
+func (m *OverloadCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OverloadCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *OverloadCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OverloadCfgReply.Merge(dst, src)
+}
+func (m *OverloadCfgReply) XXX_Size() int {
+	return xxx_messageInfo_OverloadCfgReply.Size(m)
+}
+func (m *OverloadCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_OverloadCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OverloadCfgReply proto.InternalMessageInfo
+
+func (m *OverloadCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Segment routing with the MPLS data plane, enabled or disabled (the default),
+// and the first label and size of our SRGB (16000 and 8000 by default). An empty
+// state or zero start or range leaves them unchanged
+type SegmentRoutingCfgRequest struct {
+	State                string   `protobuf:"bytes,1,opt,name=state" json:"state,omitempty"`
+	SrgbStart            uint32   `protobuf:"varint,2,opt,name=srgbStart" json:"srgbStart,omitempty"`
+	SrgbRange            uint32   `protobuf:"varint,3,opt,name=srgbRange" json:"srgbRange,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SegmentRoutingCfgRequest) Reset()         { *m = SegmentRoutingCfgRequest{} }
+func (m *SegmentRoutingCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*SegmentRoutingCfgRequest) ProtoMessage()    {}
+func (*SegmentRoutingCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{30}
+}
+func (m *SegmentRoutingCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SegmentRoutingCfgRequest.Unmarshal(m, b)
+}
+func (m *SegmentRoutingCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SegmentRoutingCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *SegmentRoutingCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SegmentRoutingCfgRequest.Merge(dst, src)
+}
+func (m *SegmentRoutingCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_SegmentRoutingCfgRequest.Size(m)
+}
+func (m *SegmentRoutingCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SegmentRoutingCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SegmentRoutingCfgRequest proto.InternalMessageInfo
+
+func (m *SegmentRoutingCfgRequest) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *SegmentRoutingCfgRequest) GetSrgbStart() uint32 {
+	if m != nil {
+		return m.SrgbStart
+	}
+	return 0
+}
+
+func (m *SegmentRoutingCfgRequest) GetSrgbRange() uint32 {
+	if m != nil {
+		return m.SrgbRange
+	}
+	return 0
+}
+
+type SegmentRoutingCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SegmentRoutingCfgReply) Reset()         { *m = SegmentRoutingCfgReply{} }
+func (m *SegmentRoutingCfgReply) String() string { return proto.CompactTextString(m) }
+func (*SegmentRoutingCfgReply) ProtoMessage()    {}
+func (*SegmentRoutingCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{31}
+}
+func (m *SegmentRoutingCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SegmentRoutingCfgReply.Unmarshal(m, b)
+}
+func (m *SegmentRoutingCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SegmentRoutingCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *SegmentRoutingCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SegmentRoutingCfgReply.Merge(dst, src)
+}
+func (m *SegmentRoutingCfgReply) XXX_Size() int {
+	return xxx_messageInfo_SegmentRoutingCfgReply.Size(m)
+}
+func (m *SegmentRoutingCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SegmentRoutingCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SegmentRoutingCfgReply proto.InternalMessageInfo
+
+func (m *SegmentRoutingCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// A prefix such as a loopback address to advertise with a SID, an index into
+// the SRGB. With noPhp our neighbors keep the label on rather than popping it
+type PrefixSIDCfgRequest struct {
+	Prefix string `protobuf:"bytes,1,opt,name=prefix" json:"prefix,omitempty"`
+	Index  uint32 `protobuf:"varint,2,opt,name=index" json:"index,omitempty"`
+	NoPhp  bool   `protobuf:"varint,3,opt,name=noPhp" json:"noPhp,omitempty"`
+	// Stop advertising the prefix
+	Remove               bool     `protobuf:"varint,4,opt,name=remove" json:"remove,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PrefixSIDCfgRequest) Reset()         { *m = PrefixSIDCfgRequest{} }
+func (m *PrefixSIDCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*PrefixSIDCfgRequest) ProtoMessage()    {}
+func (*PrefixSIDCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{32}
+}
+func (m *PrefixSIDCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrefixSIDCfgRequest.Unmarshal(m, b)
+}
+func (m *PrefixSIDCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrefixSIDCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *PrefixSIDCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrefixSIDCfgRequest.Merge(dst, src)
+}
+func (m *PrefixSIDCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_PrefixSIDCfgRequest.Size(m)
+}
+func (m *PrefixSIDCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrefixSIDCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrefixSIDCfgRequest proto.InternalMessageInfo
+
+func (m *PrefixSIDCfgRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *PrefixSIDCfgRequest) GetIndex() uint32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *PrefixSIDCfgRequest) GetNoPhp() bool {
+	if m != nil {
+		return m.NoPhp
+	}
+	return false
+}
+
+func (m *PrefixSIDCfgRequest) GetRemove() bool {
+	if m != nil {
+		return m.Remove
+	}
+	return false
+}
+
+type PrefixSIDCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PrefixSIDCfgReply) Reset()         { *m = PrefixSIDCfgReply{} }
+func (m *PrefixSIDCfgReply) String() string { return proto.CompactTextString(m) }
+func (*PrefixSIDCfgReply) ProtoMessage()    {}
+func (*PrefixSIDCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{33}
+}
+func (m *PrefixSIDCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrefixSIDCfgReply.Unmarshal(m, b)
+}
+func (m *PrefixSIDCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrefixSIDCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *PrefixSIDCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrefixSIDCfgReply.Merge(dst, src)
+}
+func (m *PrefixSIDCfgReply) XXX_Size() int {
+	return xxx_messageInfo_PrefixSIDCfgReply.Size(m)
+}
+func (m *PrefixSIDCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrefixSIDCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrefixSIDCfgReply proto.InternalMessageInfo
+
+func (m *PrefixSIDCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Equal cost paths SPF keeps and installs for each destination (1-64, default 4),
+// 0 leaves it unchanged. Used from the next SPF run
+type SPFCfgRequest struct {
+	MaxPaths             uint32   `protobuf:"varint,1,opt,name=maxPaths" json:"maxPaths,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SPFCfgRequest) Reset()         { *m = SPFCfgRequest{} }
+func (m *SPFCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*SPFCfgRequest) ProtoMessage()    {}
+func (*SPFCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{34}
+}
+func (m *SPFCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SPFCfgRequest.Unmarshal(m, b)
+}
+func (m *SPFCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SPFCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *SPFCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SPFCfgRequest.Merge(dst, src)
+}
+func (m *SPFCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_SPFCfgRequest.Size(m)
+}
+func (m *SPFCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SPFCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SPFCfgRequest proto.InternalMessageInfo
+
+func (m *SPFCfgRequest) GetMaxPaths() uint32 {
+	if m != nil {
+		return m.MaxPaths
+	}
+	return 0
+}
+
+type SPFCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SPFCfgReply) Reset()         { *m = SPFCfgReply{} }
+func (m *SPFCfgReply) String() string { return proto.CompactTextString(m) }
+func (*SPFCfgReply) ProtoMessage()    {}
+func (*SPFCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{35}
+}
+func (m *SPFCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SPFCfgReply.Unmarshal(m, b)
+}
+func (m *SPFCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SPFCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *SPFCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SPFCfgReply.Merge(dst, src)
+}
+func (m *SPFCfgReply) XXX_Size() int {
+	return xxx_messageInfo_SPFCfgReply.Size(m)
+}
+func (m *SPFCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SPFCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SPFCfgReply proto.InternalMessageInfo
+
+func (m *SPFCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+// Throttle the runs of SPF or the generation of our LSPs. The first trigger
+// after a quiet period waits initialWait, the next secondaryWait, and each
+// one after that doubles the wait up to maxWait. All in milliseconds, 0
+// leaves a timer unchanged.
+type ThrottleCfgRequest struct {
+	// Either spf or lsp
+	Throttle             string   `protobuf:"bytes,1,opt,name=throttle" json:"throttle,omitempty"`
+	InitialWait          uint32   `protobuf:"varint,2,opt,name=initialWait" json:"initialWait,omitempty"`
+	SecondaryWait        uint32   `protobuf:"varint,3,opt,name=secondaryWait" json:"secondaryWait,omitempty"`
+	MaxWait              uint32   `protobuf:"varint,4,opt,name=maxWait" json:"maxWait,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ThrottleCfgRequest) Reset()         { *m = ThrottleCfgRequest{} }
+func (m *ThrottleCfgRequest) String() string { return proto.CompactTextString(m) }
+func (*ThrottleCfgRequest) ProtoMessage()    {}
+func (*ThrottleCfgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{36}
+}
+func (m *ThrottleCfgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ThrottleCfgRequest.Unmarshal(m, b)
+}
+func (m *ThrottleCfgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ThrottleCfgRequest.Marshal(b, m, deterministic)
+}
+func (dst *ThrottleCfgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ThrottleCfgRequest.Merge(dst, src)
+}
+func (m *ThrottleCfgRequest) XXX_Size() int {
+	return xxx_messageInfo_ThrottleCfgRequest.Size(m)
+}
+func (m *ThrottleCfgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ThrottleCfgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ThrottleCfgRequest proto.InternalMessageInfo
+
+func (m *ThrottleCfgRequest) GetThrottle() string {
+	if m != nil {
+		return m.Throttle
+	}
+	return ""
+}
+
+func (m *ThrottleCfgRequest) GetInitialWait() uint32 {
+	if m != nil {
+		return m.InitialWait
+	}
+	return 0
+}
+
+func (m *ThrottleCfgRequest) GetSecondaryWait() uint32 {
+	if m != nil {
+		return m.SecondaryWait
+	}
+	return 0
+}
+
+func (m *ThrottleCfgRequest) GetMaxWait() uint32 {
+	if m != nil {
+		return m.MaxWait
+	}
+	return 0
+}
+
+type ThrottleCfgReply struct {
+	Ack                  string   `protobuf:"bytes,1,opt,name=ack" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ThrottleCfgReply) Reset()         { *m = ThrottleCfgReply{} }
+func (m *ThrottleCfgReply) String() string { return proto.CompactTextString(m) }
+func (*ThrottleCfgReply) ProtoMessage()    {}
+func (*ThrottleCfgReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_config_f995d73f016a58d0, []int{37}
+}
+func (m *ThrottleCfgReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ThrottleCfgReply.Unmarshal(m, b)
+}
+func (m *ThrottleCfgReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ThrottleCfgReply.Marshal(b, m, deterministic)
+}
+func (dst *ThrottleCfgReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ThrottleCfgReply.Merge(dst, src)
+}
+func (m *ThrottleCfgReply) XXX_Size() int {
+	return xxx_messageInfo_ThrottleCfgReply.Size(m)
+}
+func (m *ThrottleCfgReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ThrottleCfgReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ThrottleCfgReply proto.InternalMessageInfo
+
+func (m *ThrottleCfgReply) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*IntfRequest)(nil), "config.IntfRequest")
+	proto.RegisterType((*IntfReply)(nil), "config.IntfReply")
+	proto.RegisterType((*LspRequest)(nil), "config.LspRequest")
+	proto.RegisterType((*LspReply)(nil), "config.LspReply")
+	proto.RegisterType((*TopoRequest)(nil), "config.TopoRequest")
+	proto.RegisterType((*TopoReply)(nil), "config.TopoReply")
+	proto.RegisterType((*PathRequest)(nil), "config.PathRequest")
+	proto.RegisterType((*PathReply)(nil), "config.PathReply")
+	proto.RegisterType((*SystemIDRequest)(nil), "config.SystemIDRequest")
+	proto.RegisterType((*SystemIDReply)(nil), "config.SystemIDReply")
+	proto.RegisterType((*SystemIDCfgRequest)(nil), "config.SystemIDCfgRequest")
+	proto.RegisterType((*SystemIDCfgReply)(nil), "config.SystemIDCfgReply")
+	proto.RegisterType((*IntfCfgRequest)(nil), "config.IntfCfgRequest")
+	proto.RegisterType((*IntfCfgReply)(nil), "config.IntfCfgReply")
+	proto.RegisterType((*LevelCfgRequest)(nil), "config.LevelCfgRequest")
+	proto.RegisterType((*LevelCfgReply)(nil), "config.LevelCfgReply")
+	proto.RegisterType((*NETCfgRequest)(nil), "config.NETCfgRequest")
+	proto.RegisterType((*NETCfgReply)(nil), "config.NETCfgReply")
+	proto.RegisterType((*HostnameCfgRequest)(nil), "config.HostnameCfgRequest")
+	proto.RegisterType((*HostnameCfgReply)(nil), "config.HostnameCfgReply")
+	proto.RegisterType((*MetricStyleCfgRequest)(nil), "config.MetricStyleCfgRequest")
+	proto.RegisterType((*MetricStyleCfgReply)(nil), "config.MetricStyleCfgReply")
+	proto.RegisterType((*MultiTopologyCfgRequest)(nil), "config.MultiTopologyCfgRequest")
+	proto.RegisterType((*MultiTopologyCfgReply)(nil), "config.MultiTopologyCfgReply")
+	proto.RegisterType((*KeychainCfgRequest)(nil), "config.KeychainCfgRequest")
+	proto.RegisterType((*KeychainCfgReply)(nil), "config.KeychainCfgReply")
+	proto.RegisterType((*LevelAuthCfgRequest)(nil), "config.LevelAuthCfgRequest")
+	proto.RegisterType((*LevelAuthCfgReply)(nil), "config.LevelAuthCfgReply")
+	proto.RegisterType((*OverloadCfgRequest)(nil), "config.OverloadCfgRequest")
+	proto.RegisterType((*OverloadCfgReply)(nil), "config.OverloadCfgReply")
+	proto.RegisterType((*SegmentRoutingCfgRequest)(nil), "config.SegmentRoutingCfgRequest")
+	proto.RegisterType((*SegmentRoutingCfgReply)(nil), "config.SegmentRoutingCfgReply")
+	proto.RegisterType((*PrefixSIDCfgRequest)(nil), "config.PrefixSIDCfgRequest")
+	proto.RegisterType((*PrefixSIDCfgReply)(nil), "config.PrefixSIDCfgReply")
+	proto.RegisterType((*SPFCfgRequest)(nil), "config.SPFCfgRequest")
+	proto.RegisterType((*SPFCfgReply)(nil), "config.SPFCfgReply")
+	proto.RegisterType((*ThrottleCfgRequest)(nil), "config.ThrottleCfgRequest")
+	proto.RegisterType((*ThrottleCfgReply)(nil), "config.ThrottleCfgReply")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Configure service
+
+type ConfigureClient interface {
+	ConfigureSystemID(ctx context.Context, in *SystemIDCfgRequest, opts ...grpc.CallOption) (*SystemIDCfgReply, error)
+	ConfigureIntf(ctx context.Context, in *IntfCfgRequest, opts ...grpc.CallOption) (*IntfCfgReply, error)
+	ConfigureLevel(ctx context.Context, in *LevelCfgRequest, opts ...grpc.CallOption) (*LevelCfgReply, error)
+	ConfigureNET(ctx context.Context, in *NETCfgRequest, opts ...grpc.CallOption) (*NETCfgReply, error)
+	ConfigureHostname(ctx context.Context, in *HostnameCfgRequest, opts ...grpc.CallOption) (*HostnameCfgReply, error)
+	ConfigureMetricStyle(ctx context.Context, in *MetricStyleCfgRequest, opts ...grpc.CallOption) (*MetricStyleCfgReply, error)
+	ConfigureMultiTopology(ctx context.Context, in *MultiTopologyCfgRequest, opts ...grpc.CallOption) (*MultiTopologyCfgReply, error)
+	ConfigureKeychain(ctx context.Context, in *KeychainCfgRequest, opts ...grpc.CallOption) (*KeychainCfgReply, error)
+	ConfigureLevelAuth(ctx context.Context, in *LevelAuthCfgRequest, opts ...grpc.CallOption) (*LevelAuthCfgReply, error)
+	ConfigureOverload(ctx context.Context, in *OverloadCfgRequest, opts ...grpc.CallOption) (*OverloadCfgReply, error)
+	ConfigureSegmentRouting(ctx context.Context, in *SegmentRoutingCfgRequest, opts ...grpc.CallOption) (*SegmentRoutingCfgReply, error)
+	ConfigurePrefixSID(ctx context.Context, in *PrefixSIDCfgRequest, opts ...grpc.CallOption) (*PrefixSIDCfgReply, error)
+	ConfigureSPF(ctx context.Context, in *SPFCfgRequest, opts ...grpc.CallOption) (*SPFCfgReply, error)
+	ConfigureThrottle(ctx context.Context, in *ThrottleCfgRequest, opts ...grpc.CallOption) (*ThrottleCfgReply, error)
+}
+
+type configureClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewConfigureClient(cc *grpc.ClientConn) ConfigureClient {
+	return &configureClient{cc}
+}
+
+func (c *configureClient) ConfigureSystemID(ctx context.Context, in *SystemIDCfgRequest, opts ...grpc.CallOption) (*SystemIDCfgReply, error) {
+	out := new(SystemIDCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureSystemID", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureIntf(ctx context.Context, in *IntfCfgRequest, opts ...grpc.CallOption) (*IntfCfgReply, error) {
+	out := new(IntfCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureIntf", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureLevel(ctx context.Context, in *LevelCfgRequest, opts ...grpc.CallOption) (*LevelCfgReply, error) {
+	out := new(LevelCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureLevel", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureNET(ctx context.Context, in *NETCfgRequest, opts ...grpc.CallOption) (*NETCfgReply, error) {
+	out := new(NETCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureNET", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureHostname(ctx context.Context, in *HostnameCfgRequest, opts ...grpc.CallOption) (*HostnameCfgReply, error) {
+	out := new(HostnameCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureHostname", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureMetricStyle(ctx context.Context, in *MetricStyleCfgRequest, opts ...grpc.CallOption) (*MetricStyleCfgReply, error) {
+	out := new(MetricStyleCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureMetricStyle", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureMultiTopology(ctx context.Context, in *MultiTopologyCfgRequest, opts ...grpc.CallOption) (*MultiTopologyCfgReply, error) {
+	out := new(MultiTopologyCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureMultiTopology", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureKeychain(ctx context.Context, in *KeychainCfgRequest, opts ...grpc.CallOption) (*KeychainCfgReply, error) {
+	out := new(KeychainCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureKeychain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureLevelAuth(ctx context.Context, in *LevelAuthCfgRequest, opts ...grpc.CallOption) (*LevelAuthCfgReply, error) {
+	out := new(LevelAuthCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureLevelAuth", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureOverload(ctx context.Context, in *OverloadCfgRequest, opts ...grpc.CallOption) (*OverloadCfgReply, error) {
+	out := new(OverloadCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureOverload", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureSegmentRouting(ctx context.Context, in *SegmentRoutingCfgRequest, opts ...grpc.CallOption) (*SegmentRoutingCfgReply, error) {
+	out := new(SegmentRoutingCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureSegmentRouting", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigurePrefixSID(ctx context.Context, in *PrefixSIDCfgRequest, opts ...grpc.CallOption) (*PrefixSIDCfgReply, error) {
+	out := new(PrefixSIDCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigurePrefixSID", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureSPF(ctx context.Context, in *SPFCfgRequest, opts ...grpc.CallOption) (*SPFCfgReply, error) {
+	out := new(SPFCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureSPF", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configureClient) ConfigureThrottle(ctx context.Context, in *ThrottleCfgRequest, opts ...grpc.CallOption) (*ThrottleCfgReply, error) {
+	out := new(ThrottleCfgReply)
+	err := grpc.Invoke(ctx, "/config.Configure/ConfigureThrottle", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Configure service
+
+type ConfigureServer interface {
+	ConfigureSystemID(context.Context, *SystemIDCfgRequest) (*SystemIDCfgReply, error)
+	ConfigureIntf(context.Context, *IntfCfgRequest) (*IntfCfgReply, error)
+	ConfigureLevel(context.Context, *LevelCfgRequest) (*LevelCfgReply, error)
+	ConfigureNET(context.Context, *NETCfgRequest) (*NETCfgReply, error)
+	ConfigureHostname(context.Context, *HostnameCfgRequest) (*HostnameCfgReply, error)
+	ConfigureMetricStyle(context.Context, *MetricStyleCfgRequest) (*MetricStyleCfgReply, error)
+	ConfigureMultiTopology(context.Context, *MultiTopologyCfgRequest) (*MultiTopologyCfgReply, error)
+	ConfigureKeychain(context.Context, *KeychainCfgRequest) (*KeychainCfgReply, error)
+	ConfigureLevelAuth(context.Context, *LevelAuthCfgRequest) (*LevelAuthCfgReply, error)
+	ConfigureOverload(context.Context, *OverloadCfgRequest) (*OverloadCfgReply, error)
+	ConfigureSegmentRouting(context.Context, *SegmentRoutingCfgRequest) (*SegmentRoutingCfgReply, error)
+	ConfigurePrefixSID(context.Context, *PrefixSIDCfgRequest) (*PrefixSIDCfgReply, error)
+	ConfigureSPF(context.Context, *SPFCfgRequest) (*SPFCfgReply, error)
+	ConfigureThrottle(context.Context, *ThrottleCfgRequest) (*ThrottleCfgReply, error)
+}
+
+func RegisterConfigureServer(s *grpc.Server, srv ConfigureServer) {
+	s.RegisterService(&_Configure_serviceDesc, srv)
+}
+
+func _Configure_ConfigureSystemID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SystemIDCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureSystemID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureSystemID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureSystemID(ctx, req.(*SystemIDCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureIntf_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntfCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureIntf(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureIntf",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureIntf(ctx, req.(*IntfCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LevelCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureLevel(ctx, req.(*LevelCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureNET_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NETCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureNET(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureNET",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureNET(ctx, req.(*NETCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureHostname_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HostnameCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureHostname(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureHostname",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureHostname(ctx, req.(*HostnameCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureMetricStyle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricStyleCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureMetricStyle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureMetricStyle",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureMetricStyle(ctx, req.(*MetricStyleCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureMultiTopology_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiTopologyCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureMultiTopology(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureMultiTopology",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureMultiTopology(ctx, req.(*MultiTopologyCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureKeychain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeychainCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureKeychain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureKeychain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureKeychain(ctx, req.(*KeychainCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureLevelAuth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LevelAuthCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureLevelAuth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureLevelAuth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureLevelAuth(ctx, req.(*LevelAuthCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureOverload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OverloadCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureOverload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureOverload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureOverload(ctx, req.(*OverloadCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureSegmentRouting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SegmentRoutingCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureSegmentRouting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureSegmentRouting",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureSegmentRouting(ctx, req.(*SegmentRoutingCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigurePrefixSID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrefixSIDCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigurePrefixSID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigurePrefixSID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigurePrefixSID(ctx, req.(*PrefixSIDCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureSPF_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SPFCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureSPF(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureSPF",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureSPF(ctx, req.(*SPFCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Configure_ConfigureThrottle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ThrottleCfgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigureServer).ConfigureThrottle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.Configure/ConfigureThrottle",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigureServer).ConfigureThrottle(ctx, req.(*ThrottleCfgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Configure_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "config.Configure",
+	HandlerType: (*ConfigureServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ConfigureSystemID",
+			Handler:    _Configure_ConfigureSystemID_Handler,
+		},
+		{
+			MethodName: "ConfigureIntf",
+			Handler:    _Configure_ConfigureIntf_Handler,
+		},
+		{
+			MethodName: "ConfigureLevel",
+			Handler:    _Configure_ConfigureLevel_Handler,
+		},
+		{
+			MethodName: "ConfigureNET",
+			Handler:    _Configure_ConfigureNET_Handler,
+		},
+		{
+			MethodName: "ConfigureHostname",
+			Handler:    _Configure_ConfigureHostname_Handler,
+		},
+		{
+			MethodName: "ConfigureMetricStyle",
+			Handler:    _Configure_ConfigureMetricStyle_Handler,
+		},
+		{
+			MethodName: "ConfigureMultiTopology",
+			Handler:    _Configure_ConfigureMultiTopology_Handler,
+		},
+		{
+			MethodName: "ConfigureKeychain",
+			Handler:    _Configure_ConfigureKeychain_Handler,
+		},
+		{
+			MethodName: "ConfigureLevelAuth",
+			Handler:    _Configure_ConfigureLevelAuth_Handler,
+		},
+		{
+			MethodName: "ConfigureOverload",
+			Handler:    _Configure_ConfigureOverload_Handler,
+		},
+		{
+			MethodName: "ConfigureSegmentRouting",
+			Handler:    _Configure_ConfigureSegmentRouting_Handler,
+		},
+		{
+			MethodName: "ConfigurePrefixSID",
+			Handler:    _Configure_ConfigurePrefixSID_Handler,
+		},
+		{
+			MethodName: "ConfigureSPF",
+			Handler:    _Configure_ConfigureSPF_Handler,
+		},
+		{
+			MethodName: "ConfigureThrottle",
+			Handler:    _Configure_ConfigureThrottle_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "config.proto",
+}
+
+// Client API for State service
+
+type StateClient interface {
+	// Could also support streaming updates out this system ?
+	GetIntf(ctx context.Context, in *IntfRequest, opts ...grpc.CallOption) (*IntfReply, error)
+	GetLsp(ctx context.Context, in *LspRequest, opts ...grpc.CallOption) (*LspReply, error)
+	GetSystemID(ctx context.Context, in *SystemIDRequest, opts ...grpc.CallOption) (*SystemIDReply, error)
+	GetTopo(ctx context.Context, in *TopoRequest, opts ...grpc.CallOption) (*TopoReply, error)
+	ComputePath(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*PathReply, error)
+}
+
+type stateClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStateClient(cc *grpc.ClientConn) StateClient {
+	return &stateClient{cc}
+}
+
+func (c *stateClient) GetIntf(ctx context.Context, in *IntfRequest, opts ...grpc.CallOption) (*IntfReply, error) {
+	out := new(IntfReply)
+	err := grpc.Invoke(ctx, "/config.State/GetIntf", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateClient) GetLsp(ctx context.Context, in *LspRequest, opts ...grpc.CallOption) (*LspReply, error) {
+	out := new(LspReply)
+	err := grpc.Invoke(ctx, "/config.State/GetLsp", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateClient) GetSystemID(ctx context.Context, in *SystemIDRequest, opts ...grpc.CallOption) (*SystemIDReply, error) {
+	out := new(SystemIDReply)
+	err := grpc.Invoke(ctx, "/config.State/GetSystemID", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateClient) GetTopo(ctx context.Context, in *TopoRequest, opts ...grpc.CallOption) (*TopoReply, error) {
+	out := new(TopoReply)
+	err := grpc.Invoke(ctx, "/config.State/GetTopo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stateClient) ComputePath(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*PathReply, error) {
+	out := new(PathReply)
+	err := grpc.Invoke(ctx, "/config.State/ComputePath", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for State service
+
+type StateServer interface {
+	// Could also support streaming updates out this system ?
+	GetIntf(context.Context, *IntfRequest) (*IntfReply, error)
 	GetLsp(context.Context, *LspRequest) (*LspReply, error)
 	GetSystemID(context.Context, *SystemIDRequest) (*SystemIDReply, error)
 	GetTopo(context.Context, *TopoRequest) (*TopoReply, error)
+	ComputePath(context.Context, *PathRequest) (*PathReply, error)
 }
 
 func RegisterStateServer(s *grpc.Server, srv StateServer) {
@@ -636,6 +2589,24 @@ func _State_GetTopo_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _State_ComputePath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StateServer).ComputePath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/config.State/ComputePath",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StateServer).ComputePath(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _State_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "config.State",
 	HandlerType: (*StateServer)(nil),
@@ -656,35 +2627,106 @@ var _State_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetTopo",
 			Handler:    _State_GetTopo_Handler,
 		},
+		{
+			MethodName: "ComputePath",
+			Handler:    _State_ComputePath_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "config.proto",
 }
 
-func init() { proto.RegisterFile("config.proto", fileDescriptor_config_7eec158168b3a239) }
-
-var fileDescriptor_config_7eec158168b3a239 = []byte{
-	// 339 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0xcb, 0x4e, 0xc2, 0x40,
-	0x14, 0x86, 0x21, 0x48, 0xb5, 0x07, 0x8d, 0x70, 0xbc, 0x91, 0xc6, 0x78, 0x99, 0xa8, 0x61, 0x45,
-	0x14, 0x1e, 0xc0, 0x05, 0x26, 0x84, 0xc8, 0x0a, 0x5c, 0xb8, 0x45, 0x1c, 0xa0, 0xa1, 0xd2, 0x91,
-	0x19, 0x16, 0x7d, 0x5f, 0x1f, 0xc4, 0x9c, 0xb9, 0xf4, 0x62, 0xdd, 0x9d, 0xcb, 0x7f, 0xfe, 0x7e,
-	0xe9, 0x3f, 0x70, 0x38, 0x8f, 0x37, 0x8b, 0x70, 0xd9, 0x15, 0xdb, 0x58, 0xc5, 0xe8, 0x99, 0x8e,
-	0xdd, 0x43, 0x63, 0xb4, 0x51, 0x8b, 0x09, 0xff, 0xde, 0x71, 0xa9, 0xf0, 0x1c, 0x3c, 0xb9, 0xa2,
-	0x41, 0xbb, 0x7a, 0x53, 0xed, 0xf8, 0x13, 0xdb, 0xb1, 0x6b, 0xf0, 0x8d, 0x4c, 0x44, 0x09, 0x22,
-	0xec, 0x85, 0x46, 0x52, 0xeb, 0xf8, 0x13, 0x5d, 0x33, 0x06, 0x30, 0x96, 0xc2, 0xd9, 0x9c, 0x42,
-	0x5d, 0xae, 0xc6, 0x52, 0x58, 0x17, 0xd3, 0xb0, 0x4b, 0x38, 0xd0, 0x1a, 0xf2, 0x68, 0x42, 0x2d,
-	0xd2, 0x7b, 0xb2, 0xa0, 0x92, 0x48, 0xde, 0x62, 0x11, 0x17, 0x48, 0x68, 0x90, 0x91, 0x50, 0x47,
-	0x24, 0x46, 0x66, 0x49, 0x94, 0x91, 0x68, 0x12, 0xaa, 0xd9, 0x13, 0x1c, 0x4f, 0x13, 0xa9, 0xf8,
-	0xd7, 0xe8, 0xc5, 0x79, 0x5d, 0x01, 0xc8, 0x95, 0x1b, 0x5a, 0xbf, 0xdc, 0x84, 0xdd, 0xc2, 0x51,
-	0x76, 0x62, 0xe9, 0x64, 0xf8, 0x69, 0x95, 0x54, 0xb2, 0x07, 0x40, 0x27, 0x19, 0x2c, 0x96, 0xce,
-	0xb8, 0xac, 0xbb, 0x83, 0x66, 0x41, 0x67, 0xdd, 0x66, 0xf3, 0xb5, 0x53, 0xcd, 0xe6, 0xeb, 0xde,
-	0x3b, 0xf8, 0x03, 0xfd, 0xff, 0x77, 0x5b, 0x8e, 0xaf, 0xd0, 0x4a, 0x1b, 0x77, 0x8b, 0x41, 0xd7,
-	0xc6, 0x55, 0xfe, 0x6a, 0xd0, 0xfe, 0x77, 0x27, 0xa2, 0x84, 0x55, 0x7a, 0x3f, 0x55, 0xa8, 0x4f,
-	0xd5, 0x4c, 0x71, 0xec, 0xc3, 0xfe, 0x90, 0x2b, 0x4a, 0x0d, 0x4f, 0xdc, 0x41, 0x2e, 0xea, 0xa0,
-	0x55, 0x1c, 0xea, 0x73, 0x7c, 0x04, 0x6f, 0xc8, 0xd5, 0x58, 0x0a, 0x44, 0xb7, 0xce, 0x62, 0x0d,
-	0x9a, 0x85, 0x99, 0xb9, 0x78, 0x86, 0xc6, 0x90, 0xab, 0x94, 0xfb, 0xe2, 0x2f, 0x9b, 0xbb, 0x3d,
-	0x2b, 0x2f, 0x8c, 0x81, 0xe1, 0xa4, 0x4c, 0x33, 0xce, 0xdc, 0x43, 0xc8, 0x38, 0xd3, 0xd8, 0x59,
-	0xe5, 0xc3, 0xd3, 0xaf, 0xb8, 0xff, 0x1b, 0x00, 0x00, 0xff, 0xff, 0xa7, 0x05, 0x52, 0x96, 0xd5,
-	0x02, 0x00, 0x00,
+func init() { proto.RegisterFile("config.proto", fileDescriptor_config_f995d73f016a58d0) }
+
+var fileDescriptor_config_f995d73f016a58d0 = []byte{
+	// 1417 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x58, 0xdb, 0x6e, 0xdb, 0x46,
+	0x10, 0x8d, 0x6c, 0xd9, 0x11, 0x47, 0x56, 0x62, 0xaf, 0x1c, 0x9b, 0x61, 0x6e, 0x0e, 0x91, 0x34,
+	0x6e, 0x8a, 0xa6, 0x69, 0x02, 0xb4, 0x28, 0x50, 0xa0, 0xc8, 0xc5, 0x71, 0x83, 0x38, 0xa9, 0x41,
+	0x19, 0x28, 0x50, 0xa0, 0x0f, 0x34, 0xb9, 0x16, 0x09, 0x53, 0x24, 0x43, 0xae, 0x5c, 0xeb, 0xb9,
+	0x7f, 0xd0, 0xf7, 0xf6, 0x53, 0xfa, 0x45, 0x7d, 0xeb, 0x0f, 0x14, 0xb3, 0x37, 0xee, 0x9a, 0x4c,
+	0xfd, 0xb6, 0x73, 0x66, 0xf6, 0xec, 0xec, 0xec, 0x5c, 0x44, 0xc1, 0x5a, 0x54, 0xe4, 0x27, 0xe9,
+	0xf4, 0x49, 0x59, 0x15, 0xac, 0x20, 0xab, 0x42, 0xf2, 0x1f, 0xc2, 0xf0, 0x6d, 0xce, 0x4e, 0x02,
+	0xfa, 0x71, 0x4e, 0x6b, 0x46, 0xb6, 0x60, 0xb5, 0x4e, 0x10, 0x70, 0x7b, 0x3b, 0xbd, 0x5d, 0x27,
+	0x90, 0x92, 0x7f, 0x0f, 0x1c, 0x61, 0x56, 0x66, 0x0b, 0x42, 0xa0, 0x9f, 0x0a, 0x93, 0xe5, 0x5d,
+	0x27, 0xe0, 0x6b, 0xdf, 0x07, 0x38, 0xa8, 0x4b, 0x45, 0xb3, 0x09, 0x2b, 0x75, 0x72, 0x50, 0x97,
+	0x92, 0x45, 0x08, 0xfe, 0x6d, 0x18, 0x70, 0x1b, 0xe4, 0x58, 0x87, 0xe5, 0xac, 0x2e, 0x25, 0x05,
+	0x2e, 0xfd, 0xef, 0x60, 0x78, 0x54, 0x94, 0x85, 0xe5, 0x09, 0x02, 0x8d, 0x27, 0x28, 0xe1, 0xe1,
+	0x33, 0xf6, 0xf6, 0xb5, 0xbb, 0xb4, 0xd3, 0xdb, 0x1d, 0x05, 0x7c, 0x8d, 0xde, 0x89, 0xad, 0xd2,
+	0x3b, 0x26, 0xb6, 0x71, 0xef, 0x70, 0xed, 0xff, 0xdb, 0x83, 0xe1, 0x61, 0xc8, 0x12, 0x93, 0xbc,
+	0x98, 0x57, 0x11, 0xd5, 0xe4, 0x5c, 0x22, 0x3b, 0x30, 0x8c, 0x69, 0xcd, 0xd2, 0x3c, 0x64, 0x69,
+	0x91, 0xf3, 0x33, 0x9c, 0xc0, 0x84, 0xf0, 0x66, 0x19, 0x3d, 0xa3, 0x99, 0xbb, 0x2c, 0x6e, 0xc6,
+	0x05, 0x72, 0x1b, 0x9c, 0xe3, 0x30, 0x8f, 0x7f, 0x4b, 0x63, 0x96, 0xb8, 0xfd, 0x9d, 0xde, 0x6e,
+	0x3f, 0x68, 0x00, 0x72, 0x17, 0x20, 0xcd, 0xa3, 0x6c, 0x1e, 0xd3, 0x17, 0xf9, 0xc2, 0x5d, 0xe1,
+	0x8e, 0x1b, 0x88, 0xa9, 0xcf, 0x32, 0x77, 0xd5, 0xd6, 0x67, 0x19, 0xea, 0xe9, 0xb9, 0xde, 0x7f,
+	0x55, 0xe8, 0x1b, 0x04, 0x6f, 0x33, 0xa3, 0xac, 0x4a, 0x23, 0x77, 0x20, 0x6e, 0x23, 0x24, 0xff,
+	0x39, 0x38, 0xe2, 0xd2, 0x32, 0x2c, 0x49, 0x51, 0xd6, 0x2a, 0x2c, 0xb8, 0x46, 0x2c, 0x2a, 0x6a,
+	0xa6, 0x62, 0x89, 0x6b, 0xff, 0x6b, 0xb8, 0x3e, 0x59, 0xd4, 0x8c, 0xce, 0xde, 0xbe, 0x56, 0xd1,
+	0xba, 0x0b, 0x50, 0x27, 0x0a, 0x94, 0x11, 0x33, 0x10, 0xff, 0xf7, 0x25, 0x18, 0x35, 0x7b, 0xe4,
+	0xeb, 0xd6, 0x69, 0x2c, 0x4d, 0x71, 0x89, 0x47, 0x85, 0x15, 0x0d, 0xdd, 0x25, 0x71, 0x3c, 0xae,
+	0x89, 0x07, 0x83, 0xa4, 0xa8, 0x59, 0x1e, 0xce, 0xa8, 0x0c, 0xa7, 0x96, 0xf1, 0x25, 0xc4, 0x2d,
+	0x26, 0x6c, 0x91, 0x51, 0x1e, 0x53, 0x27, 0x30, 0x21, 0xdc, 0x5d, 0x9c, 0xd1, 0x2a, 0x2b, 0xc2,
+	0x98, 0xc7, 0x74, 0x10, 0x68, 0x19, 0x4f, 0xab, 0xab, 0xe9, 0x31, 0x8f, 0xa5, 0x13, 0xf0, 0x35,
+	0xda, 0xcf, 0xc2, 0x73, 0x0c, 0x48, 0x2d, 0x63, 0xa8, 0x65, 0x3c, 0xad, 0x2e, 0x4f, 0x8e, 0x92,
+	0xaa, 0x60, 0x2c, 0xa3, 0x32, 0x8c, 0x26, 0x84, 0x16, 0x59, 0x5d, 0x6a, 0x0b, 0x47, 0x58, 0x18,
+	0x90, 0xff, 0x19, 0x10, 0x15, 0x84, 0x57, 0x27, 0x53, 0x15, 0xbb, 0x56, 0x24, 0xfc, 0x07, 0xb0,
+	0x6e, 0xd9, 0xc9, 0x78, 0x85, 0xd1, 0xa9, 0xb2, 0x0a, 0xa3, 0x53, 0xff, 0xaf, 0x65, 0xb8, 0x86,
+	0x15, 0x67, 0x50, 0x11, 0xe8, 0xf3, 0x50, 0x09, 0xab, 0xbe, 0x0a, 0x53, 0x94, 0x56, 0xd1, 0x3c,
+	0x65, 0x47, 0x8b, 0x92, 0xaa, 0x84, 0x35, 0x20, 0xbc, 0x76, 0x59, 0xa5, 0x45, 0x95, 0xb2, 0x05,
+	0x0f, 0xf2, 0x28, 0xd0, 0x72, 0x93, 0xcc, 0x7d, 0x33, 0x99, 0x7d, 0x58, 0x4b, 0x68, 0x96, 0x15,
+	0x87, 0x61, 0x1c, 0xa7, 0xf9, 0x94, 0x07, 0xd7, 0x09, 0x2c, 0x8c, 0xa7, 0x6c, 0x79, 0xf6, 0xcd,
+	0x7b, 0x91, 0x76, 0x2a, 0x65, 0x35, 0x42, 0x1e, 0xc0, 0x88, 0xdb, 0xbf, 0xa3, 0x8b, 0x28, 0x09,
+	0xd3, 0x9c, 0x47, 0xdc, 0x09, 0x6c, 0x10, 0xaf, 0x7d, 0x7c, 0x12, 0xcb, 0x70, 0xe3, 0x12, 0xbd,
+	0x65, 0x54, 0xb2, 0x3a, 0xc2, 0x5b, 0x25, 0xa3, 0x5f, 0xb3, 0xf0, 0xfc, 0xa5, 0xae, 0x33, 0xe0,
+	0x75, 0x66, 0x61, 0xe4, 0x29, 0x8c, 0x2b, 0x5a, 0xd3, 0xea, 0x2c, 0x3c, 0xce, 0x68, 0x63, 0x3a,
+	0xe4, 0xa6, 0x5d, 0x2a, 0xbc, 0x49, 0x18, 0xcf, 0xd2, 0x7c, 0xbf, 0x2a, 0xe6, 0xa5, 0xbb, 0x26,
+	0x92, 0xbb, 0x41, 0x8c, 0xe2, 0x1a, 0x71, 0x7f, 0xa4, 0xe4, 0xef, 0xc0, 0x9a, 0x7e, 0x9f, 0xee,
+	0x27, 0x7c, 0x04, 0xd7, 0x0f, 0x30, 0xa0, 0xc6, 0x13, 0xea, 0x80, 0xf7, 0x8c, 0x80, 0xfb, 0xf7,
+	0x61, 0xd4, 0x18, 0x76, 0x73, 0xdd, 0x87, 0xd1, 0x87, 0xbd, 0x23, 0x3b, 0xaf, 0x72, 0xca, 0x94,
+	0x49, 0x4e, 0x99, 0x7f, 0x0f, 0x86, 0xca, 0xa4, 0x9b, 0xe3, 0x29, 0x90, 0x1f, 0x65, 0x79, 0x19,
+	0x44, 0x66, 0x11, 0xf6, 0xec, 0x22, 0xc4, 0x54, 0xb5, 0x76, 0x74, 0xf3, 0x7e, 0x09, 0x37, 0xde,
+	0x37, 0x75, 0x69, 0xdf, 0xb6, 0x46, 0x48, 0x4f, 0x01, 0x14, 0xfc, 0x47, 0x30, 0xbe, 0x68, 0xde,
+	0xcd, 0xfb, 0x15, 0x6c, 0xbf, 0x9f, 0x67, 0x2c, 0xc5, 0xd6, 0x9e, 0x15, 0xd3, 0xc5, 0x45, 0xe6,
+	0x90, 0x19, 0xcc, 0x21, 0xa3, 0xfe, 0xe7, 0x70, 0xa3, 0xbd, 0xa1, 0x9b, 0xfb, 0x9f, 0x1e, 0x10,
+	0x95, 0x86, 0x97, 0x94, 0xd8, 0x26, 0xac, 0x9c, 0xd2, 0x85, 0x9e, 0x38, 0x42, 0xc0, 0x8e, 0x1f,
+	0x66, 0x53, 0x2c, 0xa3, 0x64, 0x26, 0x9b, 0x57, 0x03, 0xf0, 0xf9, 0x42, 0xa3, 0x8a, 0x32, 0x59,
+	0x59, 0x52, 0xc2, 0x5d, 0x35, 0xcd, 0xe3, 0x09, 0x0b, 0x2b, 0x26, 0xeb, 0xaa, 0x01, 0x88, 0x0b,
+	0x57, 0x51, 0xd8, 0xcb, 0x63, 0xd9, 0xb8, 0x94, 0x88, 0x65, 0x1e, 0x46, 0x11, 0x2d, 0x99, 0xd8,
+	0x29, 0x8a, 0xc9, 0x84, 0xb8, 0x3f, 0x5c, 0xdc, 0xcb, 0x55, 0x41, 0x35, 0x00, 0x3e, 0xa4, 0x75,
+	0xdb, 0xee, 0xa0, 0xec, 0xc3, 0x98, 0xe7, 0xe1, 0x8b, 0x39, 0x4b, 0x2e, 0x4b, 0x5a, 0xcc, 0x9b,
+	0x53, 0x55, 0xdc, 0xa2, 0xed, 0x68, 0xd9, 0x7f, 0x08, 0x1b, 0x36, 0x51, 0xf7, 0x79, 0x8f, 0x81,
+	0xfc, 0x24, 0x3b, 0xf6, 0xa5, 0x6f, 0xfb, 0x00, 0xd6, 0x2d, 0xdb, 0x6e, 0xc6, 0x0c, 0xdc, 0x09,
+	0x9d, 0xce, 0x68, 0xce, 0x82, 0x62, 0xce, 0xd2, 0x7c, 0x7a, 0x19, 0x2f, 0x7f, 0x91, 0x6a, 0x7a,
+	0x2c, 0xe2, 0x2a, 0x5e, 0xb8, 0x01, 0x94, 0x36, 0x08, 0xf3, 0x29, 0x95, 0xdd, 0xb3, 0x01, 0xfc,
+	0xc7, 0xb0, 0xd5, 0x71, 0x5a, 0xb7, 0x67, 0x1f, 0x61, 0x7c, 0x58, 0xd1, 0x93, 0xf4, 0x7c, 0x62,
+	0x8d, 0x87, 0x2d, 0x58, 0x2d, 0x39, 0xac, 0x7e, 0x88, 0x08, 0x09, 0x9d, 0x4d, 0xf3, 0x98, 0x9e,
+	0xab, 0xa4, 0xe3, 0x02, 0xa2, 0x79, 0x71, 0x98, 0x94, 0xdc, 0x95, 0x41, 0x20, 0x04, 0xe4, 0xa8,
+	0xe8, 0xac, 0x38, 0x13, 0x53, 0x72, 0x10, 0x48, 0x09, 0x5f, 0xc1, 0x3e, 0xb2, 0xdb, 0xb3, 0x2f,
+	0x60, 0x34, 0x39, 0x7c, 0x63, 0x77, 0x04, 0x3d, 0x28, 0x7b, 0xf6, 0xa0, 0xc4, 0x26, 0xa3, 0x8c,
+	0xbb, 0xd9, 0xfe, 0xe8, 0x01, 0x51, 0x23, 0xd1, 0xe6, 0x64, 0x12, 0x55, 0x5d, 0x86, 0x19, 0xa3,
+	0x35, 0xcd, 0x53, 0x96, 0x86, 0xd9, 0xcf, 0x61, 0xaa, 0x1e, 0xc1, 0x84, 0x70, 0x9a, 0xd4, 0x34,
+	0x2a, 0xf2, 0x38, 0xac, 0x16, 0xdc, 0x46, 0x3c, 0x85, 0x0d, 0x62, 0xf9, 0xcc, 0xc2, 0x73, 0xae,
+	0xef, 0x73, 0xbd, 0x12, 0x31, 0x79, 0x2c, 0x9f, 0x3a, 0x5d, 0x7f, 0xf6, 0xf7, 0x00, 0x9c, 0x57,
+	0xfc, 0x57, 0xf1, 0xbc, 0xa2, 0xe4, 0x1d, 0x6c, 0x68, 0x41, 0xcd, 0x6b, 0xe2, 0x3d, 0x91, 0x3f,
+	0xa2, 0xdb, 0x93, 0xde, 0x73, 0x3b, 0x75, 0x65, 0xb6, 0xf0, 0xaf, 0x90, 0x1f, 0x60, 0xa4, 0xc9,
+	0x70, 0x6a, 0x90, 0x2d, 0x65, 0x6c, 0xcf, 0x78, 0x6f, 0xb3, 0x85, 0x0b, 0x82, 0x97, 0x70, 0x4d,
+	0x13, 0xf0, 0xd2, 0x22, 0xdb, 0xca, 0xf2, 0xc2, 0x8c, 0xf1, 0x6e, 0xb4, 0x15, 0x82, 0xe3, 0x7b,
+	0x58, 0xd3, 0x1c, 0x1f, 0xf6, 0x8e, 0x88, 0x36, 0xb4, 0x26, 0x8b, 0x37, 0xbe, 0x08, 0x8b, 0xdd,
+	0x66, 0x3c, 0xd4, 0x50, 0x68, 0xe2, 0xd1, 0x1e, 0x2c, 0x9e, 0xdb, 0xa9, 0x13, 0x64, 0x47, 0xb0,
+	0xa9, 0xc9, 0x8c, 0x61, 0x40, 0xee, 0xa8, 0x3d, 0x9d, 0x03, 0xc5, 0xbb, 0xf5, 0x29, 0xb5, 0x60,
+	0xfd, 0x05, 0xb6, 0x1a, 0x56, 0x73, 0x10, 0x90, 0x7b, 0x7a, 0x63, 0xf7, 0x40, 0xf1, 0xee, 0x7c,
+	0xda, 0xa0, 0x7d, 0x7d, 0xfd, 0xfb, 0x45, 0x5f, 0xbf, 0x3d, 0x4a, 0x3c, 0xb7, 0x53, 0x27, 0xc8,
+	0x3e, 0x00, 0xb1, 0x5f, 0x13, 0x1b, 0x25, 0xb9, 0x65, 0x3d, 0x9c, 0xdd, 0x84, 0xbd, 0x9b, 0xdd,
+	0xca, 0xb6, 0x73, 0xaa, 0x4b, 0x36, 0xce, 0xb5, 0x7b, 0xac, 0xe7, 0x76, 0xea, 0x04, 0xd9, 0xaf,
+	0xb0, 0xdd, 0x24, 0xbe, 0xd5, 0xde, 0xc8, 0x8e, 0x4e, 0xf1, 0x4f, 0x34, 0x59, 0xef, 0xee, 0xff,
+	0x58, 0xb4, 0xef, 0xae, 0xdb, 0x53, 0x73, 0xf7, 0x8e, 0x26, 0xe9, 0xdd, 0xec, 0x56, 0xb6, 0xb3,
+	0x7a, 0x72, 0xf8, 0xa6, 0xc9, 0x6a, 0xab, 0xa9, 0x79, 0xe3, 0x8b, 0x70, 0x3b, 0x72, 0xfa, 0xb7,
+	0xbe, 0x8e, 0x5c, 0xbb, 0x91, 0x79, 0x6e, 0xa7, 0x8e, 0x93, 0x3d, 0xfb, 0x73, 0x09, 0x56, 0x26,
+	0x7c, 0xaa, 0x3c, 0x87, 0xab, 0xfb, 0x94, 0xf1, 0x4a, 0x1f, 0x9b, 0x15, 0xad, 0x58, 0x36, 0x6c,
+	0x50, 0xf8, 0xf2, 0x14, 0x56, 0xf7, 0x29, 0x3b, 0xa8, 0x4b, 0x42, 0xf4, 0x63, 0xeb, 0x4f, 0x6a,
+	0x6f, 0xdd, 0xc2, 0x54, 0x5b, 0x19, 0xee, 0x53, 0xa6, 0xbb, 0xd3, 0xf6, 0xc5, 0x0e, 0xd4, 0x6a,
+	0x09, 0xd6, 0x57, 0x9a, 0x7f, 0x45, 0xfa, 0xc9, 0xbf, 0xab, 0xb5, 0x9f, 0xc6, 0x47, 0xb8, 0xb7,
+	0x61, 0x83, 0x62, 0xd3, 0xb7, 0x30, 0x7c, 0x55, 0xcc, 0xca, 0x39, 0xa3, 0x38, 0x13, 0x9a, 0x8d,
+	0xc6, 0x07, 0xb6, 0xb7, 0x61, 0x83, 0x7c, 0xe3, 0xf1, 0x2a, 0xff, 0xeb, 0xe1, 0xf9, 0x7f, 0x03,
+	0x00, 0x3d, 0x98, 0x31, 0x9c, 0x8a, 0x10, 0x00, 0x00,
 }
@@ -5,49 +5,110 @@
 package main
 
 import (
+	"container/heap"
 	"fmt"
 	"github.com/golang/glog"
-	"github.com/vishvananda/netlink"
 	"sync"
 )
 
-var TopoDB *IsisDB
+var TopoDB *IsisDB   // Level 1
+var L2TopoDB *IsisDB // Level 2
+
+// The IPv6 unicast topology with multi-topology enabled
+var IPv6TopoDB *IsisDB
+var L2IPv6TopoDB *IsisDB
+
+// Level 1 prefixes a level 1/2 router advertises into level 2
+var areaPrefixes []IPPrefix
+var areaPrefixesLock sync.Mutex
+
+const (
+	DEFAULT_MAX_PATHS = 4  // Equal cost next hops kept for each destination
+	MAX_PATHS         = 64 // The most that can be configured
+)
 
 type Triple struct {
 	// Either systemID or prefix is set, not both
 	systemID string
 	distance uint32
-	adj      *Adjacency
+	nextHops []*Adjacency // Every equal cost next hop, up to the maximum paths
 }
 
 func (t Triple) String() string {
-	if t.adj == nil {
-		return fmt.Sprintf("SystemID %s Distance %d Next Hop %v", t.systemID, t.distance, t.adj)
-	} else {
-		return fmt.Sprintf("SystemID %s Distance %d Next Hop %s Intf %s", t.systemID, t.distance, systemIDToString(t.adj.neighborSystemID), t.adj.intfName)
+	if len(t.nextHops) == 0 {
+		return fmt.Sprintf("SystemID %s Distance %d Next Hop <nil>", withHostname(t.systemID), t.distance)
+	}
+	triple := fmt.Sprintf("SystemID %s Distance %d", withHostname(t.systemID), t.distance)
+	for _, adj := range t.nextHops {
+		triple += fmt.Sprintf(" Next Hop %s Intf %s", systemIDName(adj.neighborSystemID), adj.intfName)
 	}
+	return triple
 }
 
 func topoDBInit() {
-	TopoDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil}
+	TopoDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_1}
+	L2TopoDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_2}
+	IPv6TopoDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_1}
+	L2IPv6TopoDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_2}
+}
+
+func getTopoDB(level byte, mtID uint16) *IsisDB {
+	if mtID == MT_IPV6_UNICAST {
+		if level == LEVEL_2 {
+			return L2IPv6TopoDB
+		}
+		return IPv6TopoDB
+	}
+	if level == LEVEL_2 {
+		return L2TopoDB
+	}
+	return TopoDB
+}
+
+func clearTopoDB(mtID uint16) {
+	// Forget a topology which is no longer running
+	for _, level := range []byte{LEVEL_1, LEVEL_2} {
+		topoDB := getTopoDB(level, mtID)
+		topoDB.DBLock.Lock()
+		topoDB.Root = nil
+		topoDB.DBLock.Unlock()
+		forgetSPFState(topoDB)
+	}
 }
 
 func isisDecision(triggerSPF chan bool) {
 	// Implementation - similar to the other modules, there is a goroutine
 	// which is blocked on an event channel. The events coming on the channel are simple signals
-	// that the SPF database should be recomputed. Maybe if we want to get fancy they could indicate whether you
-	// only need to do a partial recompute.
-	// This goroutine is not interface specific, rather it is for the entire update db
-	for {
-		glog.V(2).Infof("SPF: Waiting for SPF event")
-		spf := <-triggerSPF
-		glog.V(2).Infof("SPF: Received trigger")
-		// Run SPF on the update db to build the network topology
-		if spf {
-			glog.V(2).Infof("SPF: Compute SPF")
-			computeSPF(UpdateDB, TopoDB, cfg.sid, cfg.interfaces)
+	// that the SPF database should be recomputed. SPF itself works out from the LSPs which
+	// changed whether that takes an incremental SPF or only a partial route calculation.
+	// This goroutine is not interface specific, rather it is for the entire update db.
+	// Signals are coalesced and SPF runs are spaced out by the SPF throttle
+	isisThrottle(THROTTLE_SPF, triggerSPF, runSPF)
+}
+
+func runSPF() {
+	// Run SPF on the update db of each level to build the network topology.
+	// While restarting the routes from before the restart are left alone
+	if isRestarting() {
+		return
+	}
+	for _, level := range getLevels(cfg.level) {
+		// Once per topology, each with its own topology database and routes
+		prefixes := make([]IPPrefix, 0)
+		for _, mtID := range getTopologies() {
+			glog.V(2).Infof("SPF: Compute %s %s SPF", levelToString(level), topologyToString(mtID))
+			paths := computeSPF(getUpdateDB(level), getTopoDB(level, mtID), cfg.sid, cfg.interfaces, mtID)
+			if level == LEVEL_1 && cfg.level == LEVEL_1_2 {
+				prefixes = append(prefixes, getAreaPrefixes(UpdateDB, paths, mtID)...)
+			}
+		}
+		buildTEDB(getUpdateDB(level), getTEDB(level))
+		if level == LEVEL_1 && cfg.level == LEVEL_1_2 && setAreaPrefixes(prefixes) {
+			// Our area changed, let the backbone know
+			scheduleLocalLsp()
 		}
 	}
+	syncRib(cfg.level, getTopologies())
 }
 
 func printPaths(prefix string, paths []*Triple) {
@@ -56,183 +117,374 @@ func printPaths(prefix string, paths []*Triple) {
 	}
 }
 
-func getAdjacencies(source *Triple, unknown []*AvlNode) []*Triple {
-	// Given a source triple return a slice of triples from unknown
-	// Update the costs appropriately based on the cost to source
-	trips := make([]*Triple, 0)
-	for _, node := range unknown {
-		lsp := node.data.(*IsisLsp)
-		if source.systemID == systemIDToString(lsp.LspID[:6]) {
-			glog.V(2).Infof("SPF: Found our systemID %s", source.systemID)
-			// Found our lsp, grabs its neighbors
-			neighbors := lookupNeighbors(lsp)
-			for _, neighbor := range neighbors {
-				glog.V(2).Infof("SPF: Neighbor %s", neighbor.systemID)
-				// Given a neighbor system id find the adjacency
-				trips = append(trips, &Triple{systemID: neighbor.systemID, distance: neighbor.metric, adj: getAdjacency(neighbor.systemID)})
+func getLanAdjacency(localInterfaces []*Intf, level byte, lanID string, neighborSystemID string) *Adjacency {
+	// Find our adjacency with a neighbor on the LAN identified by lanID
+	for _, intf := range localInterfaces {
+		if intf.circuitType != BROADCAST_CIRCUIT || nodeIDToString(intf.lanID[level-1][:]) != lanID {
+			continue
+		}
+		for _, adj := range intf.adjacencies {
+			if adj.state == "UP" && adj.level == level && systemIDToString(adj.neighborSystemID) == neighborSystemID {
+				return adj
 			}
 		}
 	}
-	return trips
+	return nil
 }
 
-func checkInPaths(adj *Triple, paths []*Triple) bool {
-	for _, path := range paths {
-		if adj.systemID == path.systemID {
-			return true
+func mergeNextHops(nextHops []*Adjacency, more []*Adjacency) []*Adjacency {
+	// The union of two sets of equal cost next hops, up to the maximum paths.
+	// Next hops are shared between triples so this always makes a new slice
+	merged := append(make([]*Adjacency, 0, len(nextHops)+len(more)), nextHops...)
+	for _, adj := range more {
+		if len(merged) >= getMaxPaths() {
+			break
+		}
+		found := false
+		for _, nextHop := range merged {
+			found = found || nextHop == adj
+		}
+		if !found {
+			merged = append(merged, adj)
+		}
+	}
+	return merged
+}
+
+func getMaxPaths() int {
+	return int(cfg.maxPaths)
+}
+
+// A node of the SPF graph, a router or a pseudonode
+type spfNode struct {
+	nodeID     [7]byte
+	systemID   string
+	hasLsp     bool // Whether its fragment zero is in the update database
+	overloaded bool
+	links      []spfLink
+	in         []int  // The nodes with a link to this one, once per link
+	prefixes   []byte // Its TLVs other than the neighbor TLVs, to tell what changed
+	reached    bool
+	done       bool
+	touched    bool // Whether its path may have changed in this run
+	distance   uint32
+	nextHops   []*Adjacency
+	path       *Triple
+	routes     map[string]*RibRoute // Its share of the routes
+}
+
+type spfLink struct {
+	to     int
+	metric uint32
+}
+
+// The nodes of the update database with their links in one topology,
+// indexed by node ID
+type spfGraph struct {
+	nodes   []spfNode
+	index   map[[7]byte]int
+	local   int
+	touched []int
+}
+
+func (g *spfGraph) getNode(nodeID []byte) int {
+	// The index of a node, added if not seen yet. Neighbors without an LSP
+	// are still nodes, just without links of their own
+	var key [7]byte
+	copy(key[:], nodeID)
+	i, ok := g.index[key]
+	if !ok {
+		i = len(g.nodes)
+		g.nodes = append(g.nodes, spfNode{nodeID: key, systemID: nodeIDToString(key[:])})
+		g.index[key] = i
+	}
+	return i
+}
+
+type spfCandidate struct {
+	node     int
+	distance uint32
+}
+
+// The tentative nodes ordered by distance. A node whose distance goes down
+// is pushed again and the stale entry skipped when popped
+type spfHeap []spfCandidate
+
+func (h spfHeap) Len() int { return len(h) }
+func (h spfHeap) Less(i, j int) bool {
+	if h[i].distance != h[j].distance {
+		return h[i].distance < h[j].distance
+	}
+	return h[i].node < h[j].node
+}
+func (h spfHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *spfHeap) Push(x interface{}) { *h = append(*h, x.(spfCandidate)) }
+func (h *spfHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+func (g *spfGraph) touch(node *spfNode, i int) {
+	if !node.touched {
+		node.touched = true
+		g.touched = append(g.touched, i)
+	}
+}
+
+func (g *spfGraph) addToTent(tent *spfHeap, i int, distance uint32, nextHops []*Adjacency) {
+	// Update distance and next hops if already present with a longer path,
+	// or add the next hops of another path of the same length. A node already
+	// in paths is taken out again if the path is shorter or adds next hops,
+	// which only happens when SPF resumes from a previous run
+	node := &g.nodes[i]
+	if i == g.local {
+		return
+	}
+	if !node.reached || distance < node.distance {
+		node.reached = true
+		node.done = false
+		node.distance = distance
+		node.nextHops = nextHops
+	} else if distance == node.distance {
+		merged := mergeNextHops(node.nextHops, nextHops)
+		if len(merged) == len(node.nextHops) {
+			return
+		}
+		node.nextHops = merged
+		if !node.done {
+			return
 		}
+		node.done = false
+	} else {
+		return
 	}
-	return false
+	g.touch(node, i)
+	heap.Push(tent, spfCandidate{node: i, distance: distance})
 }
 
-func addAdjToTent(currentDistance uint32, currentAdj *Adjacency, neighbor *Triple, tent *[]*Triple, paths []*Triple) int {
-	// Returns 0 if just an update, or 1 if added
-	// Ignore if already in paths
-	// Update distance if already present
-	// otherwise add
-	if checkInPaths(neighbor, paths) {
-		return 0
+func (g *spfGraph) relax(tent *spfHeap, from int, link spfLink, localInterfaces []*Intf, level byte) {
+	node := &g.nodes[from]
+	nextHops := node.nextHops
+	if len(nextHops) == 0 && node.nodeID[6] != 0 {
+		// A pseudonode we are directly attached to, the next hop is
+		// our adjacency with each router on that LAN
+		if lanAdj := getLanAdjacency(localInterfaces, level, node.systemID, g.nodes[link.to].systemID); lanAdj != nil {
+			nextHops = []*Adjacency{lanAdj}
+		}
 	}
-	found := false
-	for i, system := range *tent {
-		if neighbor.systemID == system.systemID {
-			found = true
-			// Already there, update distance if we can
-			if (neighbor.distance + currentDistance) < system.distance {
-				(*tent)[i].distance = neighbor.distance + currentDistance
+	g.addToTent(tent, link.to, node.distance+link.metric, nextHops)
+}
+
+func (g *spfGraph) seedTent(tent *spfHeap, localInterfaces []*Intf, level byte, mtID uint16) {
+	// Load tent with our local adjacencies
+	for _, intf := range localInterfaces {
+		if intf.circuitType == BROADCAST_CIRCUIT {
+			// Routers on a LAN are reached through the pseudonode
+			if hasTopologyAdjacency(intf, level, mtID) && intf.lanID[level-1] != [7]byte{} {
+				g.addToTent(tent, g.getNode(intf.lanID[level-1][:]), getTopologyMetric(intf, mtID), nil)
+			}
+			continue
+		}
+		for _, adj := range intf.adjacencies {
+			if adj.state == "UP" && adj.level&level != 0 && adjInTopology(intf, adj, mtID) {
+				distance := adj.metric
+				if mtID != MT_STANDARD {
+					distance = getTopologyMetric(intf, mtID)
+				}
+				// Parallel links to the same neighbor are equal cost next hops
+				g.addToTent(tent, g.getNode(append(adj.neighborSystemID[:6:6], 0)), distance, []*Adjacency{adj})
 			}
 		}
 	}
-	if !found {
-		glog.V(2).Infof("Adding system %s", neighbor.systemID)
-		neighbor.distance += currentDistance
-		neighbor.adj = currentAdj
-		*tent = append(*tent, neighbor)
-		return 1
+}
+
+func (g *spfGraph) run(tent *spfHeap, localInterfaces []*Intf, level byte) {
+	// At each step of the algorithm, the closest node in TENT is moved into PATHS and
+	// its neighbors are added to TENT if they are not already there and their associated
+	// costs adjusted accordingly, for the next selection.
+	for tent.Len() > 0 {
+		candidate := heap.Pop(tent).(spfCandidate)
+		node := &g.nodes[candidate.node]
+		if node.done || candidate.distance != node.distance {
+			continue
+		}
+		node.done = true
+		glog.V(2).Infof("SPF: Best candidate %s, cost %d", node.systemID, node.distance)
+		if node.overloaded {
+			// Still reached for its own prefixes but not used to get anywhere else
+			glog.V(1).Infof("SPF: %s is overloaded, not using it for transit", node.systemID)
+			continue
+		}
+		for _, link := range node.links {
+			g.relax(tent, candidate.node, link, localInterfaces, level)
+		}
 	}
-	return 0
 }
 
-func computeSPF(updateDB *IsisDB, topoDB *IsisDB, localSystemID string, localInterfaces []*Intf) {
+func computeSPF(updateDB *IsisDB, topoDB *IsisDB, localSystemID string, localInterfaces []*Intf, mtID uint16) []*Triple {
 	// db.Root is an AVL tree where the nodes contain LSPs
 	// Compute the shortest paths to all the prefixes found in the tree
-	// All prefixes will be leaves
-	// Metric information (distance) and neighbor relationships are contained in the LSP's TLVs
-	// Probably some way to optimize this by not taking both locks
+	// Metric information (distance) and neighbor relationships are contained in the LSP's TLVs,
+	// which are indexed by node ID and Dijkstra runs over that index with a heap
 	// Update the decision DB
 	// The local systemID is our starting point for dijkstra
+	// Only adjacencies at the level of the update db and in the topology are used
+	// The graph, paths and routes are kept from the previous run of the same topology
+	// database, so only the LSPs which changed since are looked at (see ispf.go)
+	// Returns the shortest paths, in order of distance
 	glog.V(2).Info("SPF: Running SPF, taking update database lock")
 	updateDB.DBLock.Lock()
-	// SPF time
-	// Decision DB should still be keyed by system ID, but its contents should be prefixes and their associated costs and next hops?
-	// For the first crack at this let assume there are no-parallel edges (i.e. two adjacencies to the same next hop)
-	// We need 2 lists: paths and tent
-	// Each of the form < string systemID, uint32 metric, *Adjacency>
-	// Don't need to touch the UpdateDB for the first iteration, just use our adjacency database directly
-	paths := make([]*Triple, 0)
-	tent := make([]*Triple, 0)
-	// TODO: optimize this
-	unknown := AvlGetAll(updateDB.Root)
-	localSystemIDIndex := -1
-	for i, node := range unknown {
-		if systemIDToString(node.data.(*IsisLsp).LspID[:6]) == localSystemID {
-			paths = append(paths, &Triple{systemID: localSystemID}) // Leave distance 0 and adj nil
-			localSystemIDIndex = i
-		}
-	}
-	if localSystemIDIndex == -1 {
+	level := updateDB.Level
+	segmentRouting := cfg.segmentRouting && mtID == MT_STANDARD
+	state := getSPFState(topoDB, updateDB, mtID, getLocalSPFInputs(localSystemID, localInterfaces, level, mtID))
+	localID := systemIDToBytes(localSystemID)
+	topology, prefixes, affected := state.updateGraph(updateDB, [7]byte{localID[0], localID[1], localID[2], localID[3], localID[4], localID[5], 0})
+	g := state.graph
+	if !g.nodes[g.local].hasLsp {
 		glog.Errorf("Unable to find our own lsp, cannot compute SPF")
+		forgetSPFState(topoDB)
 		updateDB.DBLock.Unlock()
-		return
+		return nil
+	}
+	tent := &spfHeap{}
+	fresh := state.paths == nil
+	if fresh {
+		g.nodes[g.local].reached = true
+		g.nodes[g.local].done = true
+		g.touch(&g.nodes[g.local], g.local)
 	}
-	// Yeah, yeah this is slow. Remove our own lsp
-	unknown = append(unknown[:localSystemIDIndex], unknown[localSystemIDIndex+1:]...)
+	if fresh || len(topology) != 0 {
+		state.resumeSPF(tent, topology, affected, localInterfaces, level)
+		g.seedTent(tent, localInterfaces, level, mtID)
+		g.run(tent, localInterfaces, level)
+	}
+	changed := state.updatePaths(topoDB)
+	routeNodes := state.getRouteNodes(changed, prefixes, segmentRouting)
+	if fresh {
+		routeNodes = append(routeNodes, g.local)
+	}
+	updated := state.updateRoutes(updateDB, routeNodes, localInterfaces, segmentRouting)
+	glog.V(1).Infof("SPF: %s %s %d topology and %d prefix changes, %d paths and %d routes updated", levelToString(level), topologyToString(mtID),
+		len(topology), len(prefixes), len(changed), updated)
+	printPaths("path", state.paths)
+	AvlPrint(topoDB.Root)
+	// Programmed into the kernel once every level and topology has run
+	routes := make(map[string]*RibRoute, len(state.routes))
+	for key, route := range state.routes {
+		routes[key] = route
+	}
+	updateDB.DBLock.Unlock()
+	setRibRoutes(level, mtID, routes)
+	return state.paths
+}
 
-	// Load tent with our local adjacencies and directly connected prefixes
-	// How to handle directly connected prefixes ?
-	// The system id in the triple can also be a prefix. In real IS-IS however, this would only happen in a L2 router.
-	for _, intf := range localInterfaces {
-		if intf.adj.state == "UP" {
-			tent = append(tent, &Triple{systemID: systemIDToString(intf.adj.neighborSystemID), distance: intf.adj.metric, adj: intf.adj})
-		}
-	}
-	// At each step of the algorithm, the TENT list is examined, and the node with the least cost from the source is moved into PATHS.
-	// When a node is placed in PATHS, all IP prefixes advertised by it are installed in the IS-IS Routing Information Base (RIB)
-	// with the corresponding metric and next hop. The directly connected neighbors of the node that just made it into PATHS are then added
-	// to TENT if they are not already there and their associated costs adjusted accordingly, for the next selection.
-	tentSize := len(tent)
-	printPaths("path", paths)
-	for tentSize > 0 {
-		// Examine each element in tent, looking for the shortest path from ourselves to that node/prefix
-		// After finding the shortest one, update the metric appopriately and add it to paths. Then add all adjacencies for that guy to tent.
-		// If the paths are equal, pick one arbitrarily?
-		glog.V(2).Infof("SPF: tent size %d len tent %d", tentSize, len(tent))
-		printPaths("tent", tent)
-		minCostFromSource := ^uint32(0) // Max uint
-		bestPathIndex := -1
-		for i, candidate := range tent {
-			if candidate.distance < minCostFromSource {
-				glog.V(2).Infof("SPF: Best system %v", candidate)
-				minCostFromSource = candidate.distance
-				bestPathIndex = i
-			}
+func getAreaPrefixes(updateDB *IsisDB, paths []*Triple, mtID uint16) []IPPrefix {
+	// All the prefixes reachable in one topology of our level 1 area, other than
+	// our own which are already advertised. Prefixes with the up/down bit set came
+	// from level 2 and must not be advertised back into it
+	updateDB.DBLock.Lock()
+	defer updateDB.DBLock.Unlock()
+	prefixes := make([]IPPrefix, 0)
+	for _, path := range paths {
+		if path.distance == 0 || isPseudonode(path.systemID) {
+			continue
 		}
-		glog.V(2).Infof("SPF: Best candidate %s, cost %d", tent[bestPathIndex].systemID, minCostFromSource)
-		// We now have the best candidate
-		// Add it to paths
-		paths = append(paths, tent[bestPathIndex])
-		// Save this as the next hop for its neighbors
-		currentNextHop := tent[bestPathIndex].adj
-		// Add all of this new guys adjacencies, some triple, need to find all of its adjacencies
-		bestAdjacencies := getAdjacencies(tent[bestPathIndex], unknown)
-		// Remove from tents
-		tent = append(tent[:bestPathIndex], tent[bestPathIndex+1:]...)
-		glog.V(2).Infof("SPF: tent size %d len tent %d", tentSize, len(tent))
-		tentSize--
-		// Now add all those adjacencies to tent
-		// If the node is already in tent but it has a longer path, then update the distance
-		for _, adj := range bestAdjacencies {
-			added := addAdjToTent(minCostFromSource, currentNextHop, adj, &tent, paths)
-			if added == 1 {
-				glog.V(2).Infof("SPF: added adj: %s", adj.systemID)
-			} else {
-				glog.V(2).Infof("SPF: did not add adj: %s", adj.systemID)
+		for _, prefix := range getTopologyPrefixes(updateDB, path.systemID, mtID) {
+			if !prefix.upDown {
+				prefixes = append(prefixes, IPPrefix{prefix: prefix.prefix, metric: path.distance + prefix.metric})
 			}
-			tentSize += added
-			glog.V(2).Infof("SPF: tent size %d len tent %d", tentSize, len(tent))
 		}
 	}
-	printPaths("path", paths)
-	for _, path := range paths {
-		topoDB.Root = AvlInsert(topoDB.Root, systemIDToKey(path.systemID), path, true)
-		// Install into rib if not our own path
-		if path.systemID != cfg.sid {
-			installRouteFromPath(path)
+	return prefixes
+}
+
+func getTopologyPrefixes(updateDB *IsisDB, systemID string, mtID uint16) []IPPrefix {
+	// The prefixes a system advertises which are routed in a topology, the
+	// standard topology has the IPv6 prefixes of TLV 236 too
+	if mtID != MT_STANDARD {
+		return getMTIPv6Prefixes(updateDB, systemID, mtID)
+	}
+	return append(getIPPrefixes(updateDB, systemID), getIPv6Prefixes(updateDB, systemID)...)
+}
+
+func setAreaPrefixes(prefixes []IPPrefix) bool {
+	// Returns whether the area prefixes changed
+	areaPrefixesLock.Lock()
+	defer areaPrefixesLock.Unlock()
+	changed := len(prefixes) != len(areaPrefixes)
+	for i := 0; !changed && i < len(prefixes); i++ {
+		changed = prefixes[i].prefix.String() != areaPrefixes[i].prefix.String() || prefixes[i].metric != areaPrefixes[i].metric
+	}
+	areaPrefixes = prefixes
+	return changed
+}
+
+func appendAreaPrefixes(reachTLV *IsisTLV) {
+	// Add the level 1 area prefixes of the TLV's address family to a TLV 128, 135, 236 or 237
+	areaPrefixesLock.Lock()
+	defer areaPrefixesLock.Unlock()
+	for i := range areaPrefixes {
+		if (areaPrefixes[i].prefix.IP.To4() == nil) != isIPv6ReachTLV(reachTLV) {
+			continue
+		}
+		if isIPv6ReachTLV(reachTLV) {
+			appendIPv6Prefix(reachTLV, &areaPrefixes[i].prefix, areaPrefixes[i].metric, false)
+		} else if reachTLV.typeTLV == ISIS_EXTENDED_IP_REACH_TLV {
+			appendExtendedPrefix(reachTLV, &areaPrefixes[i].prefix, areaPrefixes[i].metric, false, nil)
+		} else {
+			appendPrefix(reachTLV, &areaPrefixes[i].prefix, areaPrefixes[i].metric)
 		}
 	}
-	AvlPrint(topoDB.Root)
-	updateDB.DBLock.Unlock()
 }
 
-func installRouteFromPath(path *Triple) {
-	// Given a shortest path to a node with its appropriate next hop, install the route
+func installRouteFromPath(updateDB *IsisDB, path *Triple, mtID uint16, routes map[string]*RibRoute) {
+	// Given a shortest path to a node with its equal cost next hops, add its routes to the RIB
 	// route add -net <network which the target router has an ip on> gw <ip of next hop>
-	// We know the next hop required to get to each node in terms of its system id
-	// and the adjacency which that is reachable over. For the route we need the ip address
-	// of the next hop (determine this from the adjacency neighborIP) and the prefixes available on that
-	// remote node (get this from TLV 128 of that remote node)
-	prefixes := getDirectlyConnectedPrefixes(path.systemID)
-	if path.adj == nil || path.adj.neighborIP == nil {
+	// We know the next hops required to get to each node in terms of its system id
+	// and the adjacencies which that is reachable over. For the route we need the ip address
+	// of each next hop (determine this from the adjacency neighborIP) and the prefixes available on that
+	// remote node (get this from TLV 128 of that remote node). IPv6 prefixes from TLV 236,
+	// or TLV 237 in the IPv6 topology, go via the neighbor's link-local address, which
+	// needs the outgoing interface too
+	if len(path.nextHops) == 0 {
 		glog.Errorf("Error adding route no next hop")
 		return
 	}
-	nh := path.adj.neighborIP
-	glog.V(2).Infof("Adding prefixes %v to RIB", prefixes)
-	for _, prefix := range prefixes {
-		route := netlink.Route{Dst: &prefix, Gw: nh}
-		err := netlink.RouteAdd(&route)
-		if err != nil {
-			glog.Errorf("Error adding route %v", err)
+	if mtID == MT_STANDARD {
+		prefixes := getIPPrefixes(updateDB, path.systemID)
+		glog.V(2).Infof("Adding prefixes %v to RIB", prefixes)
+		for i := range prefixes {
+			if nextHops := getRibNextHops(path.nextHops, false); len(nextHops) != 0 {
+				addRibRoute(routes, &RibRoute{prefix: &prefixes[i].prefix, nextHops: nextHops, distance: path.distance + prefixes[i].metric})
+			}
+		}
+	}
+	prefixes := getIPv6Prefixes(updateDB, path.systemID)
+	if mtID != MT_STANDARD {
+		prefixes = getMTIPv6Prefixes(updateDB, path.systemID, mtID)
+	}
+	glog.V(2).Infof("Adding %s IPv6 prefixes %v to RIB", topologyToString(mtID), prefixes)
+	for i := range prefixes {
+		if nextHops := getRibNextHops(path.nextHops, true); len(nextHops) != 0 {
+			addRibRoute(routes, &RibRoute{prefix: &prefixes[i].prefix, nextHops: nextHops, distance: path.distance + prefixes[i].metric})
+		}
+	}
+}
+
+func getRibNextHops(adjacencies []*Adjacency, ipv6 bool) []*RibNextHop {
+	// The next hops of a route over some adjacencies, those we know an
+	// address of the family for
+	nextHops := make([]*RibNextHop, 0, len(adjacencies))
+	for _, adj := range adjacencies {
+		ip := adj.neighborIP
+		if ipv6 {
+			ip = adj.neighborIPv6
+		}
+		if ip != nil {
+			nextHops = append(nextHops, &RibNextHop{ip: ip, linkIndex: getLinkIndex(adj.intfName)})
 		}
 	}
+	return nextHops
 }
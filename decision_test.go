@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"encoding/binary"
 	"flag"
+	"fmt"
 	"github.com/vishvananda/netlink"
 	"net"
 	"testing"
@@ -32,42 +34,42 @@ func TestDecisionSPF3Node(t *testing.T) {
 
 	// R1
 	r1Interfaces := make([]*Intf, 1)
-	r1Interfaces[0] = &Intf{adj: &Adjacency{metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, intfName: "eth0"}}
+	r1Interfaces[0] = &Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, intfName: "eth0"}}}
 	r1Interfaces[0].routes = make([]*net.IPNet, 1)
 	r1Interfaces[0].routes[0] = &net.IPNet{IP: net.IP{172, 20, 0, 0}, Mask: net.IPMask{0xff, 0xff, 0, 0}}
 	r1sid := "1111.1111.1111"
-	r1neighborTLV := getNeighborTLV(r1Interfaces)
+	r1neighborTLV := getNeighborTLV(r1Interfaces, LEVEL_1)
 	r1reachTLV := getIPReachTLV(r1Interfaces)
-	r1lsp := buildEmptyLSP(1, r1sid)
+	r1lsp := buildEmptyLSP(LEVEL_1, 1, r1sid)
 	r1reachTLV.nextTLV = r1neighborTLV
 	r1lsp.CoreLsp.FirstTLV = r1reachTLV
 	UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(r1sid), r1lsp, false)
 
 	// R2
 	r2Interfaces := make([]*Intf, 2)
-	r2Interfaces[0] = &Intf{adj: &Adjacency{metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11}, intfName: "eth0"}}
-	r2Interfaces[1] = &Intf{adj: &Adjacency{metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x13}, intfName: "eth1"}}
+	r2Interfaces[0] = &Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11}, intfName: "eth0"}}}
+	r2Interfaces[1] = &Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x13}, intfName: "eth1"}}}
 	r2Interfaces[0].routes = make([]*net.IPNet, 1)
 	r2Interfaces[1].routes = make([]*net.IPNet, 1)
 	r2Interfaces[0].routes[0] = &net.IPNet{IP: net.IP{172, 20, 0, 0}, Mask: net.IPMask{0xff, 0xff, 0, 0}}
 	r2Interfaces[1].routes[0] = &net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.IPMask{0xff, 0xff, 0, 0}}
 	r2sid := "1111.1111.1112"
-	r2neighborTLV := getNeighborTLV(r2Interfaces)
+	r2neighborTLV := getNeighborTLV(r2Interfaces, LEVEL_1)
 	r2reachTLV := getIPReachTLV(r2Interfaces)
-	r2lsp := buildEmptyLSP(1, r2sid)
+	r2lsp := buildEmptyLSP(LEVEL_1, 1, r2sid)
 	r2reachTLV.nextTLV = r2neighborTLV
 	r2lsp.CoreLsp.FirstTLV = r2reachTLV
 	UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(r2sid), r2lsp, false)
 
 	// R3
 	r3Interfaces := make([]*Intf, 1)
-	r3Interfaces[0] = &Intf{adj: &Adjacency{metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, intfName: "eth0"}}
+	r3Interfaces[0] = &Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, intfName: "eth0"}}}
 	r3Interfaces[0].routes = make([]*net.IPNet, 1)
 	r3Interfaces[0].routes[0] = &net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.IPMask{0xff, 0xff, 0, 0}}
 	r3sid := "1111.1111.1113"
-	r3neighborTLV := getNeighborTLV(r3Interfaces)
+	r3neighborTLV := getNeighborTLV(r3Interfaces, LEVEL_1)
 	r3reachTLV := getIPReachTLV(r3Interfaces)
-	r3lsp := buildEmptyLSP(1, r3sid)
+	r3lsp := buildEmptyLSP(LEVEL_1, 1, r3sid)
 	r3reachTLV.nextTLV = r3neighborTLV
 	r3lsp.CoreLsp.FirstTLV = r3reachTLV
 	UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(r3sid), r3lsp, false)
@@ -79,9 +81,9 @@ func TestDecisionSPF3Node(t *testing.T) {
 	var Topo1 *IsisDB = &IsisDB{}
 	var Topo2 *IsisDB = &IsisDB{}
 	var Topo3 *IsisDB = &IsisDB{}
-	computeSPF(UpdateDB, Topo1, r1sid, r1Interfaces)
-	computeSPF(UpdateDB, Topo2, r2sid, r2Interfaces)
-	computeSPF(UpdateDB, Topo3, r3sid, r3Interfaces)
+	computeSPF(UpdateDB, Topo1, r1sid, r1Interfaces, MT_STANDARD)
+	computeSPF(UpdateDB, Topo2, r2sid, r2Interfaces, MT_STANDARD)
+	computeSPF(UpdateDB, Topo3, r3sid, r3Interfaces, MT_STANDARD)
 	// Inspect the topology learned by each node
 	topo1 := AvlGetAll(Topo1.Root)
 	for _, node := range topo1 {
@@ -118,7 +120,7 @@ func TestInstallRoute(t *testing.T) {
 	testSystemID := "1111.1111.1111"
 	// Neighbor IP is the next hop
 	// Needs to be a realistic next hop or linux wont like it use 172.18.0.100
-	trip := Triple{systemID: testSystemID, adj: &Adjacency{neighborIP: net.ParseIP("172.18.0.100")}}
+	trip := Triple{systemID: testSystemID, nextHops: []*Adjacency{&Adjacency{neighborIP: net.ParseIP("172.18.0.100")}}}
 	// Can't use the real routes
 	routes := make([]*net.IPNet, 0)
 	routes = append(routes, &net.IPNet{IP: net.ParseIP("172.28.0.0").To4(), Mask: []byte{0xff, 0xff, 0, 0}})
@@ -131,7 +133,10 @@ func TestInstallRoute(t *testing.T) {
 	UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(testSystemID), &lsp, false)
 	testRoute := netlink.Route{Dst: &net.IPNet{IP: net.ParseIP("172.28.0.0").To4(), Mask: []byte{0xff, 0xff, 0, 0}}, Gw: net.ParseIP("172.18.0.100")}
 	netlink.RouteDel(&testRoute)
-	installRouteFromPath(&trip)
+	pathRoutes := make(map[string]*RibRoute)
+	installRouteFromPath(UpdateDB, &trip, MT_STANDARD, pathRoutes)
+	setRibRoutes(LEVEL_1, MT_STANDARD, pathRoutes)
+	syncRib(LEVEL_1, getTopologies())
 	// Check whether those routes actually get installed
 	routesInstalled, _ := netlink.RouteList(nil, 0)
 	t.Logf("Installed routes %v", routesInstalled)
@@ -146,3 +151,206 @@ func TestInstallRoute(t *testing.T) {
 	}
 	netlink.RouteDel(&testRoute)
 }
+
+func TestAreaPrefixes(t *testing.T) {
+	// An L1/L2 router advertises the level 1 prefixes of its area into level 2
+	updateDBInit()
+	r2sid := "1111.1111.1112"
+	r2Interfaces := []*Intf{&Intf{routes: []*net.IPNet{&net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.IPMask{0xff, 0xff, 0, 0}}}}}
+	r2lsp := buildEmptyLSP(LEVEL_1, 1, r2sid)
+	r2lsp.CoreLsp.FirstTLV = getIPReachTLV(r2Interfaces)
+	UpdateDB.Root = AvlInsert(UpdateDB.Root, r2lsp.Key, r2lsp, false)
+	paths := []*Triple{&Triple{systemID: "1111.1111.1111"}, &Triple{systemID: r2sid, distance: 10}}
+	if !setAreaPrefixes(getAreaPrefixes(UpdateDB, paths, MT_STANDARD)) {
+		t.Fatalf("Expected the area prefixes to change")
+	}
+	if setAreaPrefixes(getAreaPrefixes(UpdateDB, paths, MT_STANDARD)) {
+		t.Fatalf("Expected the area prefixes to be unchanged")
+	}
+	reachTLV := &IsisTLV{typeTLV: ISIS_IP_INTERNAL_REACH_TLV}
+	appendAreaPrefixes(reachTLV)
+	prefixes := getPrefixesFromTLV(reachTLV)
+	if len(prefixes) != 1 || prefixes[0].prefix.String() != "172.19.0.0/16" || binary.BigEndian.Uint32(reachTLV.valueTLV[8:12]) != 20 {
+		t.Fail()
+	}
+	setAreaPrefixes(nil)
+}
+
+func TestECMP(t *testing.T) {
+	// TOPO: R1 == R2 -- R4 and R1 -- R3 -- R4 with two links between R1 and R2,
+	// all at 10. R4 has three equal cost next hops from R1
+	initConfig()
+	updateDBInit()
+	r1sid, r2sid, r4sid := "1111.1111.1111", "1111.1111.1112", "1111.1111.1114"
+	adj := func(neighbor byte, intfName string, neighborIP byte) *Intf {
+		return &Intf{name: intfName, circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10, state: "UP",
+			neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, neighbor}, intfName: intfName, neighborIP: net.IP{10, 0, 0, neighborIP}}}}
+	}
+	r1Interfaces := []*Intf{adj(0x12, "eth0", 2), adj(0x13, "eth1", 3), adj(0x12, "eth2", 6)}
+	for sid, interfaces := range map[string][]*Intf{r1sid: r1Interfaces, r2sid: []*Intf{adj(0x11, "eth0", 1), adj(0x11, "eth1", 5), adj(0x14, "eth2", 4)},
+		"1111.1111.1113": []*Intf{adj(0x11, "eth0", 1), adj(0x14, "eth1", 4)}, r4sid: []*Intf{adj(0x12, "eth0", 2), adj(0x13, "eth1", 3)}} {
+		lsp := buildEmptyLSP(LEVEL_1, 1, sid)
+		lsp.CoreLsp.FirstTLV = getNeighborTLV(interfaces, LEVEL_1)
+		if sid == r4sid {
+			lsp.CoreLsp.FirstTLV.nextTLV = getIPReachTLV([]*Intf{&Intf{routes: []*net.IPNet{&net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.CIDRMask(16, 32)}}}})
+		}
+		UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(sid), lsp, false)
+	}
+	for _, maxPaths := range []uint32{4, 2} {
+		cfg.maxPaths = maxPaths
+		topoDB := &IsisDB{}
+		computeSPF(UpdateDB, topoDB, r1sid, r1Interfaces, MT_STANDARD)
+		r2 := AvlSearch(topoDB.Root, systemIDToKey(r2sid))
+		if r2 == nil || len(r2.(*Triple).nextHops) != 2 {
+			t.Fatalf("Expected both links to R2, got %v", r2)
+		}
+		expected := 3
+		if int(maxPaths) < expected {
+			expected = int(maxPaths)
+		}
+		r4 := AvlSearch(topoDB.Root, systemIDToKey(r4sid))
+		if r4 == nil || r4.(*Triple).distance != 20 || len(r4.(*Triple).nextHops) != expected {
+			t.Fatalf("Expected %d next hops to R4, got %v", expected, r4)
+		}
+		routes := make(map[string]*RibRoute)
+		installRouteFromPath(UpdateDB, r4.(*Triple), MT_STANDARD, routes)
+		if route := routes["172.19.0.0/16"]; route == nil || len(route.nextHops) != expected || len(route.toNetlink().MultiPath) != expected {
+			t.Fatalf("Expected a multipath route, got %v", route)
+		}
+	}
+}
+
+func buildGridLSDB(rows int, cols int) (*IsisDB, []*Intf) {
+	// A synthetic LSDB of routers in a grid, each linked to the ones next to it
+	// and advertising a prefix, with the interfaces of the router in the corner
+	updateDB := &IsisDB{Level: LEVEL_1}
+	nodeID := func(i int) []byte {
+		return []byte{0x20, 0x20, 0x20, 0x20, byte(i >> 8), byte(i), 0}
+	}
+	neighbors := func(i int) []int {
+		result := make([]int, 0, 4)
+		if i%cols != 0 {
+			result = append(result, i-1)
+		}
+		if i%cols != cols-1 {
+			result = append(result, i+1)
+		}
+		if i >= cols {
+			result = append(result, i-cols)
+		}
+		if i+cols < rows*cols {
+			result = append(result, i+cols)
+		}
+		return result
+	}
+	for i := 0; i < rows*cols; i++ {
+		lsp := buildEmptyLSP(LEVEL_1, 1, systemIDToString(nodeID(i)[:6]))
+		neighborTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IS_REACH_TLV}
+		for _, neighbor := range neighbors(i) {
+			appendExtendedNeighbor(neighborTLV, 10, nodeID(neighbor), nil)
+		}
+		reachTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IP_REACH_TLV}
+		appendExtendedPrefix(reachTLV, &net.IPNet{IP: net.IP{10, byte(i >> 8), byte(i), 0}, Mask: net.CIDRMask(24, 32)}, 10, false, nil)
+		lsp.CoreLsp.FirstTLV = linkTLVs([]*IsisTLV{neighborTLV, reachTLV})
+		updateDB.Root = AvlInsert(updateDB.Root, lspIDToKey(lsp.LspID), lsp, false)
+	}
+	interfaces := make([]*Intf, 0)
+	for i, neighbor := range neighbors(0) {
+		name := fmt.Sprintf("eth%d", i)
+		interfaces = append(interfaces, &Intf{name: name, circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10,
+			state: "UP", neighborSystemID: nodeID(neighbor)[:6], intfName: name, neighborIP: net.IP{172, 18, 0, byte(i + 2)}}}})
+	}
+	return updateDB, interfaces
+}
+
+func linearSPF(updateDB *IsisDB, localSystemID string, localInterfaces []*Intf) map[string]uint32 {
+	// The distances SPF finds, the way it used to find them: a linear scan of
+	// tent for the closest node and of the update database for its LSPs
+	paths := map[string]uint32{localSystemID: 0}
+	tent := make([]*Triple, 0)
+	for _, intf := range localInterfaces {
+		for _, adj := range intf.adjacencies {
+			tent = append(tent, &Triple{systemID: systemIDToString(adj.neighborSystemID), distance: adj.metric})
+		}
+	}
+	lsps := AvlGetAll(updateDB.Root)
+	for len(tent) > 0 {
+		best := 0
+		for i, candidate := range tent {
+			if candidate.distance < tent[best].distance {
+				best = i
+			}
+		}
+		node := tent[best]
+		tent = append(tent[:best], tent[best+1:]...)
+		if _, ok := paths[node.systemID]; ok {
+			continue
+		}
+		paths[node.systemID] = node.distance
+		for _, avlNode := range lsps {
+			lsp := avlNode.data.(*IsisLsp)
+			if nodeIDToString(lsp.LspID[:7]) != node.systemID {
+				continue
+			}
+			for _, neighbor := range lookupNeighbors(lsp, MT_STANDARD) {
+				if _, ok := paths[neighbor.systemID]; !ok {
+					tent = append(tent, &Triple{systemID: neighbor.systemID, distance: node.distance + neighbor.metric})
+				}
+			}
+		}
+	}
+	return paths
+}
+
+func BenchmarkSPF(b *testing.B) {
+	// A full SPF each time, and on the smaller grids the linear scans SPF
+	// used to do for comparison, which take seconds a run at 5000 routers
+	initConfig()
+	cfg.sid = "2020.2020.0000"
+	for _, size := range []struct{ rows, cols int }{{10, 10}, {25, 40}, {50, 100}} {
+		updateDB, interfaces := buildGridLSDB(size.rows, size.cols)
+		topoDB := &IsisDB{Level: LEVEL_1}
+		b.Run(fmt.Sprintf("%dNodes", size.rows*size.cols), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				computeSPF(updateDB, topoDB, cfg.sid, interfaces, MT_STANDARD)
+				forgetSPFState(topoDB)
+			}
+		})
+		if size.rows*size.cols > 1000 {
+			continue
+		}
+		b.Run(fmt.Sprintf("%dNodesLinear", size.rows*size.cols), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linearSPF(updateDB, cfg.sid, interfaces)
+			}
+		})
+	}
+}
+
+func TestSPFGrid(t *testing.T) {
+	// Every router is reached at its grid distance, with both next hops to those off the edges
+	initConfig()
+	cfg.sid = "2020.2020.0000"
+	updateDB, interfaces := buildGridLSDB(10, 10)
+	topoDB := &IsisDB{Level: LEVEL_1}
+	paths := computeSPF(updateDB, topoDB, cfg.sid, interfaces, MT_STANDARD)
+	forgetSPFState(topoDB)
+	linear := linearSPF(updateDB, cfg.sid, interfaces)
+	if len(paths) != 100 || len(linear) != 100 {
+		t.Fatalf("Expected 100 paths, got %d", len(paths))
+	}
+	for i, path := range paths[1:] {
+		if path.distance < paths[i].distance {
+			t.Fatalf("Paths out of order %v %v", paths[i], path)
+		}
+		if path.distance != linear[path.systemID] {
+			t.Fatalf("Expected %v at %d", path, linear[path.systemID])
+		}
+		if path.systemID == "2020.2020.0063" && path.distance != 180 {
+			t.Fatalf("Unexpected path %v", path)
+		}
+		if path.systemID == "2020.2020.000b" && (path.distance != 20 || len(path.nextHops) != 2) {
+			t.Fatalf("Unexpected path %v", path)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Designated Intermediate System (DIS) election on broadcast circuits.
+// The DIS originates a pseudonode LSP listing every router on the LAN.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"github.com/golang/glog"
+	"sync"
+)
+
+const (
+	DEFAULT_PRIORITY = 64
+	MAX_PRIORITY     = 127 // Priority is a 7 bit field
+)
+
+// Pseudonode IDs handed out to our LAN circuits, indexed by ID
+var pseudonodeIDs [256]bool
+var pseudonodeIDsLock sync.Mutex
+
+func getPseudonodeID(intf *Intf) byte {
+	// Needs to be non-zero and unique amongst our LAN circuits. The interface
+	// index won't do past 255, so the lowest free ID is given to a circuit the
+	// first time it needs one and kept from then on. Zero once all 255 are taken.
+	// Requires the interface lock to be held
+	if intf.pseudonodeID != 0 {
+		return intf.pseudonodeID
+	}
+	pseudonodeIDsLock.Lock()
+	defer pseudonodeIDsLock.Unlock()
+	for psn := 1; psn < len(pseudonodeIDs); psn++ {
+		if !pseudonodeIDs[psn] {
+			pseudonodeIDs[psn] = true
+			intf.pseudonodeID = byte(psn)
+			return intf.pseudonodeID
+		}
+	}
+	glog.Errorf("No pseudonode ID left for %s", intf.name)
+	return 0
+}
+
+func isDIS(intf *Intf, level byte, sid string) bool {
+	// Requires the interface lock to be held
+	ourSystemID := systemIDToBytes(sid)
+	lanID := intf.lanID[level-1]
+	return lanID != [7]byte{} && bytes.Equal(lanID[:6], ourSystemID[:])
+}
+
+func electDIS(intf *Intf, level byte, sid string, ourMac []byte) bool {
+	// The router with the highest priority is elected DIS, ties are broken
+	// by the highest mac. Only neighbors we have an UP adjacency with at this
+	// level take part, each level has its own DIS. Returns whether the LAN ID
+	// changed. Requires the interface lock to be held
+	lanID := &intf.lanID[level-1]
+	previous := *lanID
+	var best *Adjacency
+	for _, adj := range intf.adjacencies {
+		if adj.state != "UP" || adj.level != level {
+			continue
+		}
+		if best == nil || adj.priority > best.priority ||
+			(adj.priority == best.priority && bytes.Compare(adj.neighborMac, best.neighborMac) > 0) {
+			best = adj
+		}
+	}
+	if best == nil {
+		// Nobody else on the LAN, no need for a pseudonode
+		*lanID = [7]byte{}
+	} else if intf.priority > best.priority ||
+		(intf.priority == best.priority && bytes.Compare(ourMac, best.neighborMac) > 0) {
+		if psn := getPseudonodeID(intf); psn == 0 {
+			*lanID = [7]byte{}
+		} else {
+			ourSystemID := systemIDToBytes(sid)
+			copy(lanID[:6], ourSystemID[:])
+			lanID[6] = psn
+		}
+	} else if bytes.Equal(best.lanID[:6], best.neighborSystemID) {
+		*lanID = best.lanID
+	} else {
+		// The winner hasn't announced itself as DIS yet, wait for
+		// its next hello to learn the LAN ID
+		*lanID = [7]byte{}
+	}
+	if previous != *lanID {
+		glog.Infof("%s DIS on %s changed from %s to %s", levelToString(level), intf.name, nodeIDName(previous[:]), nodeIDName(lanID[:]))
+		return true
+	}
+	return false
+}
+
+func getPseudonodeNeighborTLV(intf *Intf, level byte, sid string, metricStyle string) *IsisTLV {
+	// The pseudonode is adjacent to every router on the LAN at this level,
+	// ourselves included, at a metric of 0. Listed in the TLVs of our metric
+	// style. Requires the interface lock to be held
+	ourSystemID := systemIDToBytes(sid)
+	nodeIDs := [][]byte{append(ourSystemID[:], 0x00)}
+	for _, adj := range intf.adjacencies {
+		if adj.state == "UP" && adj.level == level {
+			nodeIDs = append(nodeIDs, append(adj.neighborSystemID[:6:6], 0x00))
+		}
+	}
+	var tlvs []*IsisTLV
+	if originatesNarrow(metricStyle) {
+		var neighborsTLV IsisTLV
+		neighborsTLV.typeTLV = ISIS_NEIGHBORS_TLV
+		neighborsTLV.lengthTLV = 1
+		neighborsTLV.valueTLV = append(neighborsTLV.valueTLV, 0x00) // Virtual byte flag
+		for _, nodeID := range nodeIDs {
+			appendNeighbor(&neighborsTLV, 0, nodeID)
+		}
+		tlvs = append(tlvs, &neighborsTLV)
+	}
+	if originatesWide(metricStyle) {
+		extendedTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IS_REACH_TLV}
+		for _, nodeID := range nodeIDs {
+			appendExtendedNeighbor(extendedTLV, 0, nodeID, nil)
+		}
+		tlvs = append(tlvs, extendedTLV)
+	}
+	return linkTLVs(tlvs)
+}
+
+func generatePseudonodeLsps(level byte, sid string) []*IsisLsp {
+	// Build a pseudonode LSP for each LAN we are the DIS on at this level. If
+	// we have resigned as DIS, an empty one withdraws the neighbors we advertised
+	lsps := make([]*IsisLsp, 0)
+	ourSystemID := systemIDToBytes(sid)
+	for _, intf := range cfg.interfaces {
+		intf.lock.Lock()
+		if intf.circuitType == BROADCAST_CIRCUIT && intf.level&level != 0 && isDIS(intf, level, sid) {
+			intf.pseudonodeSeq[level-1] += 1
+			lsp := buildEmptyLSP(level, intf.pseudonodeSeq[level-1], nodeIDToString(intf.lanID[level-1][:]))
+			lsp.CoreLsp.FirstTLV = getPseudonodeNeighborTLV(intf, level, sid, cfg.metricStyle)
+			intf.pseudonodeActive[level-1] = true
+			lsps = append(lsps, lsp)
+		} else if intf.pseudonodeActive[level-1] {
+			intf.pseudonodeSeq[level-1] += 1
+			lsp := buildEmptyLSP(level, intf.pseudonodeSeq[level-1], nodeIDToString(append(ourSystemID[:], getPseudonodeID(intf))))
+			intf.pseudonodeActive[level-1] = false
+			glog.Infof("Resigned as %s DIS on %s", levelToString(level), intf.name)
+			lsps = append(lsps, lsp)
+		}
+		intf.lock.Unlock()
+	}
+	return lsps
+}
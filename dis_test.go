@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestLanHelloAdjacency(t *testing.T) {
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Mac, r2Mac := []byte{0, 0, 0, 0, 0, 1}, []byte{0, 0, 0, 0, 0, 2}
+	r1Intf := &Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, circuitID: 5, priority: DEFAULT_PRIORITY, prefix: net.IP{172, 20, 0, 1}}
+	r2Intf := &Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, circuitID: 6, pseudonodeID: 6, priority: 100, prefix: net.IP{172, 20, 0, 2}}
+	// R1 hears R2 which hasn't heard anyone yet --> R1 initializing
+	if newNeighbor, _ := processLanHello(r1Intf, LEVEL_1, r1sid, buildLanHello(r2Intf, LEVEL_1, r2sid), r2Mac, r1Mac); !newNeighbor || r1Intf.adjacencies[0].state != "INIT" {
+		t.Fatalf("Expected a new INIT adjacency on R1")
+	}
+	// R2 hears R1 which lists R2 --> R2 up and elects itself since its priority is higher
+	if _, changed := processLanHello(r2Intf, LEVEL_1, r2sid, buildLanHello(r1Intf, LEVEL_1, r1sid), r1Mac, r2Mac); !changed || r2Intf.adjacencies[0].state != "UP" {
+		t.Fatalf("Expected an UP adjacency on R2")
+	}
+	if !isDIS(r2Intf, LEVEL_1, r2sid) || nodeIDToString(r2Intf.lanID[0][:]) != "1111.1111.1112.06" {
+		t.Fatalf("Expected R2 to be DIS, LAN ID %v", r2Intf.lanID[0])
+	}
+	// R1 hears R2 which lists R1 and announces itself as DIS
+	processLanHello(r1Intf, LEVEL_1, r1sid, buildLanHello(r2Intf, LEVEL_1, r2sid), r2Mac, r1Mac)
+	if r1Intf.adjacencies[0].state != "UP" || isDIS(r1Intf, LEVEL_1, r1sid) || r1Intf.lanID[0] != r2Intf.lanID[0] ||
+		!r1Intf.adjacencies[0].neighborIP.Equal(net.IP{172, 20, 0, 2}) {
+		t.Fail()
+	}
+}
+
+func TestDISElectionTieBreak(t *testing.T) {
+	// Equal priorities, the highest mac wins
+	sid := "1111.1111.1111"
+	intf := &Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, circuitID: 3, pseudonodeID: 3, priority: DEFAULT_PRIORITY}
+	intf.adjacencies = []*Adjacency{&Adjacency{state: "UP", level: LEVEL_1, priority: DEFAULT_PRIORITY,
+		neighborMac: []byte{0, 0, 0, 0, 0, 9}, neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}}}
+	electDIS(intf, LEVEL_1, sid, []byte{0, 0, 0, 0, 0, 1})
+	if intf.lanID[0] != [7]byte{} {
+		t.Fatalf("Expected to wait for the DIS to announce itself, got %v", intf.lanID[0])
+	}
+	electDIS(intf, LEVEL_1, sid, []byte{0, 0, 0, 0, 0, 0x0a})
+	if !isDIS(intf, LEVEL_1, sid) || intf.lanID[0][6] != 3 {
+		t.Fatalf("Expected to be DIS, got %v", intf.lanID[0])
+	}
+	// Nobody left on the LAN, no DIS
+	intf.adjacencies[0].state = "INIT"
+	if !electDIS(intf, LEVEL_1, sid, []byte{0, 0, 0, 0, 0, 0x0a}) || intf.lanID[0] != [7]byte{} {
+		t.Fail()
+	}
+}
+
+func TestPseudonodeIDs(t *testing.T) {
+	// Interface indexes which are the same in their low byte, or zero in it,
+	// still get unique pseudonode IDs which stay the same
+	seen := make(map[byte]bool)
+	for _, circuitID := range []uint32{1, 2, 256, 258} {
+		intf := &Intf{name: fmt.Sprintf("veth%d", circuitID), circuitType: BROADCAST_CIRCUIT, circuitID: circuitID}
+		psn := getPseudonodeID(intf)
+		if psn == 0 || seen[psn] || getPseudonodeID(intf) != psn {
+			t.Fatalf("Unexpected pseudonode ID %d for interface index %d", psn, circuitID)
+		}
+		seen[psn] = true
+	}
+}
+
+func TestPseudonodeSPF(t *testing.T) {
+	// TOPO: R1, R2 and R3 on one LAN with R2 as the DIS
+	// From R1 both R2 and R3 should be 10 away through the pseudonode
+	initConfig()
+	updateDBInit()
+	sids := []string{"1111.1111.1111", "1111.1111.1112", "1111.1111.1113"}
+	cfg.sid = sids[0]
+	lanID := [7]byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x02}
+	lanIntfs := make([]*Intf, len(sids))
+	for i, sid := range sids {
+		lanIntfs[i] = &Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, circuitID: 2, lanID: [2][7]byte{lanID}}
+		for j, neighborSid := range sids {
+			if i != j {
+				neighborSystemID := systemIDToBytes(neighborSid)
+				lanIntfs[i].adjacencies = append(lanIntfs[i].adjacencies,
+					&Adjacency{state: "UP", level: LEVEL_1, metric: 10, neighborSystemID: neighborSystemID[:], intfName: "eth0"})
+			}
+		}
+		lsp := buildEmptyLSP(LEVEL_1, 1, sid)
+		lsp.CoreLsp.FirstTLV = getNeighborTLV([]*Intf{lanIntfs[i]}, LEVEL_1)
+		UpdateDB.Root = AvlInsert(UpdateDB.Root, lsp.Key, lsp, false)
+	}
+	pseudonodeLsp := buildEmptyLSP(LEVEL_1, 1, nodeIDToString(lanID[:]))
+	pseudonodeLsp.CoreLsp.FirstTLV = getPseudonodeNeighborTLV(lanIntfs[1], LEVEL_1, sids[1], METRIC_STYLE_NARROW)
+	UpdateDB.Root = AvlInsert(UpdateDB.Root, pseudonodeLsp.Key, pseudonodeLsp, false)
+
+	topo := &IsisDB{}
+	computeSPF(UpdateDB, topo, sids[0], lanIntfs[:1], MT_STANDARD)
+	for _, sid := range sids[1:] {
+		tmp := AvlSearch(topo.Root, systemIDToKey(sid))
+		if tmp == nil {
+			t.Fatalf("No path to %s", sid)
+		}
+		path := tmp.(*Triple)
+		t.Logf("%v", path)
+		if path.distance != 10 || len(path.nextHops) != 1 || systemIDToString(path.nextHops[0].neighborSystemID) != sid {
+			t.Fail()
+		}
+	}
+}
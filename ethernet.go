@@ -13,12 +13,30 @@ import (
 )
 
 const (
-	PF_PACKET                  = 17
-	ETH_P_ALL                  = 0x0003
-	READ_BUF_SIZE              = 1000
-	ISIS_NEIGHBORS_TLV         = 2
-	ISIS_IP_INTERNAL_REACH_TLV = 128
-	ISIS_IP_INTF_ADDR_TLV      = 132
+	PF_PACKET                    = 17
+	ETH_P_ALL                    = 0x0003
+	READ_BUF_SIZE                = 1514 // Largest ethernet frame without the FCS
+	MAX_TLV_LENGTH               = 255
+	ISIS_AREA_ADDRESSES_TLV      = 1
+	ISIS_NEIGHBORS_TLV           = 2
+	ISIS_LAN_NEIGHBORS_TLV       = 6
+	ISIS_PADDING_TLV             = 8
+	ISIS_LSP_ENTRIES_TLV         = 9
+	ISIS_AUTH_TLV                = 10
+	ISIS_EXTENDED_IS_REACH_TLV   = 22
+	ISIS_MT_IS_REACH_TLV         = 222
+	ISIS_MT_TLV                  = 229
+	ISIS_IP_INTERNAL_REACH_TLV   = 128
+	ISIS_PROTOCOLS_SUPPORTED_TLV = 129
+	ISIS_IP_INTF_ADDR_TLV        = 132
+	ISIS_EXTENDED_IP_REACH_TLV   = 135
+	ISIS_HOSTNAME_TLV            = 137
+	ISIS_RESTART_TLV             = 211
+	ISIS_IPV6_INTF_ADDR_TLV      = 232
+	ISIS_IPV6_REACH_TLV          = 236
+	ISIS_MT_IPV6_REACH_TLV       = 237
+	ISIS_P2P_ADJ_STATE_TLV       = 240
+	ISIS_ROUTER_CAPABILITY_TLV   = 242
 )
 
 type RawSock struct {
@@ -55,6 +73,10 @@ func parseTLVs(rawBytes []byte, startIndex int) *IsisTLV {
 	first := true
 	remainingTLVBytes := len(rawBytes) - startIndex
 	for remainingTLVBytes > 0 {
+		if remainingTLVBytes < 2 || int(rawBytes[startIndex+1])+2 > remainingTLVBytes {
+			glog.Infof("Truncated TLV at offset %d, ignoring the rest of the PDU", startIndex)
+			break
+		}
 		var currentTLV IsisTLV
 		// Fill in tlv
 		currentTLV.typeTLV = rawBytes[startIndex]
@@ -80,6 +102,87 @@ func parseTLVs(rawBytes []byte, startIndex int) *IsisTLV {
 	return firstTLV
 }
 
+func getTLV(firstTLV *IsisTLV, typeTLV byte) *IsisTLV {
+	// Return the first TLV of the given type, nil if there isn't one
+	for tlv := firstTLV; tlv != nil; tlv = tlv.nextTLV {
+		if tlv.typeTLV == typeTLV {
+			return tlv
+		}
+	}
+	return nil
+}
+
+func lastTLV(tlv *IsisTLV) *IsisTLV {
+	for tlv.nextTLV != nil {
+		tlv = tlv.nextTLV
+	}
+	return tlv
+}
+
+func appendTLVValue(firstTLV *IsisTLV, value []byte, prefix []byte) {
+	// Add an entry to the last of a chain of TLVs of the same type. Once the
+	// length would no longer fit in a byte another TLV is started, with the
+	// prefix at the start of its value i.e. the virtual byte flag of TLV 2
+	tlv := lastTLV(firstTLV)
+	if len(tlv.valueTLV)+len(value) > MAX_TLV_LENGTH {
+		tlv.nextTLV = &IsisTLV{typeTLV: tlv.typeTLV, valueTLV: append([]byte{}, prefix...)}
+		tlv = tlv.nextTLV
+	}
+	tlv.valueTLV = append(tlv.valueTLV, value...)
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+}
+
+func linkTLVs(tlvs []*IsisTLV) *IsisTLV {
+	// Chain TLVs, each of which may already be a chain of its own, into one
+	// linked list and return its first TLV. Nil TLVs are skipped
+	var firstTLV, previous *IsisTLV
+	for _, tlv := range tlvs {
+		if tlv == nil {
+			continue
+		}
+		if firstTLV == nil {
+			firstTLV = tlv
+		} else {
+			lastTLV(previous).nextTLV = tlv
+		}
+		previous = tlv
+	}
+	return firstTLV
+}
+
+func getTLVsLength(tlv *IsisTLV) int {
+	// Bytes taken up by a linked list of TLVs once serialized
+	length := 0
+	for ; tlv != nil; tlv = tlv.nextTLV {
+		length += 2 + len(tlv.valueTLV)
+	}
+	return length
+}
+
+func getPDULength(pdu []byte) int {
+	// The PDU length field of a PDU without its ethernet header, which
+	// is after the circuit type, source ID and holding time on hellos
+	offset := 8
+	if pdu[4] == L1_LAN_IIH_PDU_TYPE || pdu[4] == L2_LAN_IIH_PDU_TYPE || pdu[4] == P2P_IIH_PDU_TYPE {
+		offset = 17
+	}
+	if len(pdu) < offset+2 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(pdu[offset : offset+2]))
+}
+
+func serializeTLVs(buf *bytes.Buffer, tlv *IsisTLV) {
+	// Walk the linked list of TLVs writing each one out
+	for tlv != nil {
+		glog.V(2).Info("Serializing tlv:", tlv.typeTLV)
+		binary.Write(buf, binary.BigEndian, tlv.typeTLV)
+		binary.Write(buf, binary.BigEndian, tlv.lengthTLV)
+		binary.Write(buf, binary.BigEndian, tlv.valueTLV)
+		tlv = tlv.nextTLV
+	}
+}
+
 func htons(host uint16) uint16 {
 	return (host&0xff)<<8 | (host >> 8)
 }
@@ -242,16 +345,23 @@ func recvFrame(ifname string) []byte {
 	}
 }
 
-func recvPdus(ifname string, hello chan []byte, update chan []byte) {
+func recvPdus(intf *Intf, hello chan []byte, update chan []byte) {
 	// Continuously read from the raw socks associated with the specified
 	// interface, putting the packets on the appropriate channels
 	// for the other goroutines to process
 	// pdu types:
 	//  0x0F --> l1 lan hello
-	//  0x12 --> l2 LSP
+	//  0x10 --> l2 lan hello
+	//  0x11 --> p2p hello
+	//  0x12 --> l1 LSP
+	//  0x14 --> l2 LSP
+	//  0x18 --> l1 CSNP
+	//  0x19 --> l2 CSNP
+	//  0x1A --> l1 PSNP
+	//  0x1B --> l2 PSNP
+	// LSPs and SNPs both belong to the update process so they share a channel
 	for {
-		buf := recvFrame(ifname)
-		// TODO: basic checks like length, checksum, auth
+		buf := recvFrame(intf.name)
 		// Check the common IS-IS header for the pdu type
 		// This receive frame will have everything including the ethernet frame
 		// 14 bytes ethernet header, then its the 5th byte after that in the common header
@@ -259,11 +369,24 @@ func recvPdus(ifname string, hello chan []byte, update chan []byte) {
 		if buf[14] != 0x83 {
 			continue
 		}
+		// Ethernet pads out short frames, only the PDU length is the PDU
+		length := getPDULength(buf[14:])
+		if length < int(buf[14+1]) || 14+length > len(buf) {
+			glog.Infof("Got a PDU on %s with length %d in a %d byte frame, dropping", intf.name, length, len(buf))
+			continue
+		}
+		buf = buf[:14+length]
+		if !authenticatePDU(intf, buf[14:]) {
+			continue
+		}
 		pduType := buf[14+4]
-		if pduType == 0x0F {
+		if pduType == L1_LAN_IIH_PDU_TYPE || pduType == L2_LAN_IIH_PDU_TYPE || pduType == P2P_IIH_PDU_TYPE {
 			hello <- buf
-		} else if pduType == 0x12 {
-			glog.Infof("Received an LSP %s", systemIDToString(buf[14+7+5:14+7+5+6]))
+		} else if pduType == L1_LSP_PDU_TYPE || pduType == L2_LSP_PDU_TYPE {
+			glog.Infof("Received an LSP %s", systemIDName(buf[14+8+4:14+8+4+6]))
+			update <- buf
+		} else if pduType == L1_CSNP_PDU_TYPE || pduType == L2_CSNP_PDU_TYPE ||
+			pduType == L1_PSNP_PDU_TYPE || pduType == L2_PSNP_PDU_TYPE {
 			update <- buf
 		}
 	}
@@ -22,3 +22,24 @@ func TestParseTLVs(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParseTruncatedTLV(t *testing.T) {
+	// The second TLV claims more bytes than there are
+	tlv := parseTLVs([]byte{0x01, 0x02, 0x01, 0x01, 0x02, 0x09, 0x01}, 0)
+	if tlv == nil || tlv.nextTLV != nil {
+		t.Fail()
+	}
+}
+
+func TestPDULength(t *testing.T) {
+	lsp := buildEmptyLSP(LEVEL_1, 1, "1111.1111.1111")
+	lsp.CoreLsp.FirstTLV = getAreaAddressesTLV([][]byte{DEFAULT_AREA})
+	pdu := serializeLsp(lsp.CoreLsp)
+	if pdu[1] != LSP_HEADER_SIZE || getPDULength(pdu) != len(pdu) {
+		t.Fail()
+	}
+	psnp := serializePsnp(&IsisPsnpPDU{Header: buildSnpHeader(L1_PSNP_PDU_TYPE)})
+	if psnp[1] != 17 || getPDULength(psnp) != 17 {
+		t.Fail()
+	}
+}
@@ -19,9 +19,15 @@ const (
 	PROTOCOL_ID                                  = 0x01
 	SYSTEM_ID_LENGTH                             = 0x06
 	L1_LAN_IIH_PDU_TYPE                          = 0x0F
+	L2_LAN_IIH_PDU_TYPE                          = 0x10
 	VERSION                                      = 0x01
 	MAX_AREA_ADDRESSES_DEFAULT                   = 0x00 // 0 means 3 addresses are supported
 	HELLO_INTERVAL                               = 4000 // Milliseconds in between hello udpates, TODO: Should be configurable
+	HELLO_MULTIPLIER                             = 3    // Hellos a neighbor can miss before the adjacency goes down
+	HOLDING_TIME                                 = HELLO_MULTIPLIER * HELLO_INTERVAL / 1000
+	HOLD_TIMER_INTERVAL                          = 1000 // Milliseconds in between checking for expired adjacencies
+	HELLO_PADDING_ENABLED                        = "enabled"
+	HELLO_PADDING_DISABLED                       = "disabled"
 )
 
 type IsisLanHelloHeader struct {
@@ -43,35 +49,42 @@ type IsisLanHelloPDU struct {
 type HelloResponse struct {
 	intf        *Intf
 	lanHelloPDU *IsisLanHelloPDU
+	p2pHelloPDU *IsisP2PHelloPDU // Only one of lanHelloPDU or p2pHelloPDU is set
 	sourceMac   []byte
+	length      int // PDU length, without the ethernet header
 }
 
-func buildL1HelloPDU(srcSystemID [6]byte) *IsisLanHelloPDU {
-	// Takes a destination mac and builds a IsisLanHelloPDU
-	// Also need a system ID for the node. Ignore the lan_dis field for now
+func buildLanHelloPDU(level byte, srcSystemID [6]byte) *IsisLanHelloPDU {
+	// Takes a destination mac and builds a IsisLanHelloPDU for the given level
+	// Also need a system ID for the node. The circuit type, priority and lan_dis
+	// fields are set per interface by sendHello
+	pduType := byte(L1_LAN_IIH_PDU_TYPE)
+	if level == LEVEL_2 {
+		pduType = L2_LAN_IIH_PDU_TYPE
+	}
 	isis_pdu_header := IsisPDUHeader{IntraDomainRouteingProtocolDiscriminator: 0x83,
 		LengthPDU:            0x00,
 		ProtocolID:           0x01,
 		SystemIDLength:       0x00, // 0 means default 6 bytes
-		TypePDU:              0x0F, //l1 lan hello pdu
+		TypePDU:              pduType,
 		Version:              0x01, //
 		Reserved:             0x00,
 		MaximumAreaAddresses: 0x00} // 0 means default 3 addresses
 
 	isis_lan_hello_header := IsisLanHelloHeader{
-		CircuitType:    0x01, // 01 L1, 10 L2, 11 L1/L2
+		CircuitType:    level, // 01 L1, 10 L2, 11 L1/L2
 		SourceSystemID: srcSystemID,
-		HoldingTime:    [2]byte{0x3c, 0x00},                               // period a neighbor router should wait for the next IIH before declaring the original router dead, set to 60 for now
+		HoldingTime:    [2]byte{0x00, HOLDING_TIME},                       // period a neighbor router should wait for the next IIH before declaring the original router dead
 		LengthPDU:      [2]byte{0x00, 0x00},                               // Whole pdu length
 		Priority:       [2]byte{0x00, 0x40},                               // Default priority is 64, used in the DIS election
 		LanDis:         [7]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // Should be SID of the DIS + pseudonode id
 	}
 
-	var isis_l1_lan_hello IsisLanHelloPDU
-	isis_l1_lan_hello.Header = isis_pdu_header
-	isis_l1_lan_hello.LanHelloHeader = isis_lan_hello_header
-	isis_l1_lan_hello.FirstTLV = nil
-	return &isis_l1_lan_hello // Golangs pointer analysis will allocate this on the heap
+	var isis_lan_hello IsisLanHelloPDU
+	isis_lan_hello.Header = isis_pdu_header
+	isis_lan_hello.LanHelloHeader = isis_lan_hello_header
+	isis_lan_hello.FirstTLV = nil
+	return &isis_lan_hello // Golangs pointer analysis will allocate this on the heap
 }
 
 func serializeIsisHelloPDU(pdu *IsisLanHelloPDU) []byte {
@@ -80,17 +93,11 @@ func serializeIsisHelloPDU(pdu *IsisLanHelloPDU) []byte {
 	// TLVs need to be handled specially because they can have null pointers
 	// So they can't serialized the rest of the pdu in one shot, however the
 	// common header can by serialized as is
+	pdu.Header.LengthPDU = byte(unsafe.Sizeof(pdu.Header) + unsafe.Sizeof(pdu.LanHelloHeader))
+	binary.BigEndian.PutUint16(pdu.LanHelloHeader.LengthPDU[:], uint16(int(pdu.Header.LengthPDU)+getTLVsLength(pdu.FirstTLV)))
 	binary.Write(&buf, binary.BigEndian, pdu.Header)
 	binary.Write(&buf, binary.BigEndian, pdu.LanHelloHeader)
-	tlv := pdu.FirstTLV
-	for tlv != nil {
-		// TODO: Will need to keep walking these tlvs until we hit the end somehow
-		glog.V(2).Info("Serializing tlv:", tlv.typeTLV)
-		binary.Write(&buf, binary.BigEndian, tlv.typeTLV)
-		binary.Write(&buf, binary.BigEndian, tlv.lengthTLV)
-		binary.Write(&buf, binary.BigEndian, tlv.valueTLV)
-		tlv = tlv.nextTLV
-	}
+	serializeTLVs(&buf, pdu.FirstTLV)
 	return buf.Bytes()
 }
 
@@ -107,7 +114,9 @@ func deserializeIsisHelloPDU(raw_bytes []byte) *IsisLanHelloPDU {
 	glog.V(2).Info("Binary decode common header:", commonHeader)
 	glog.V(2).Info("Binary decode hello header:", helloHeader)
 	var hello IsisLanHelloPDU
-	hello.LanHelloHeader.SourceSystemID = helloHeader.SourceSystemID
+	// Keep the whole header, the priority and LAN ID are needed for the DIS election
+	hello.Header = commonHeader
+	hello.LanHelloHeader = helloHeader
 	ethernetHeaderSize := 14
 	tlv_offset := ethernetHeaderSize + int(unsafe.Sizeof(commonHeader)) + int(unsafe.Sizeof(helloHeader))
 	glog.Infof("tlv offset %d raw bytes %d", tlv_offset, len(raw_bytes))
@@ -125,19 +134,164 @@ func getInterfaceTLV(intf *Intf) *IsisTLV {
 	return &interfaceTLV
 }
 
-func sendHello(intf *Intf, sid string, neighborsTLV *IsisTLV, sendChan chan []byte) {
-	// May need to send a hello with a neighbor tlv
+func getLanNeighborsTLV(intf *Intf, level byte) *IsisTLV {
+	// TLV 6 lists the macs of every neighbor we have heard on the LAN at this level,
+	// a neighbor which finds its own mac in here knows the adjacency is UP.
+	// Requires the interface lock to be held
+	var neighborsTLV IsisTLV
+	neighborsTLV.typeTLV = ISIS_LAN_NEIGHBORS_TLV
+	for _, adj := range intf.adjacencies {
+		if adj.level == level {
+			neighborsTLV.valueTLV = append(neighborsTLV.valueTLV, adj.neighborMac...)
+		}
+	}
+	if len(neighborsTLV.valueTLV) == 0 {
+		return nil
+	}
+	neighborsTLV.lengthTLV = byte(len(neighborsTLV.valueTLV))
+	return &neighborsTLV
+}
+
+func buildLanHello(intf *Intf, level byte, sid string) *IsisLanHelloPDU {
+	// Requires the interface lock to be held
 	// Convert the sid string to an array of 6 bytes
-	hello_l1_lan := buildL1HelloPDU(systemIDToBytes(sid))
-	if neighborsTLV != nil {
-		hello_l1_lan.FirstTLV = neighborsTLV
-		hello_l1_lan.FirstTLV.nextTLV = getInterfaceTLV(intf)
-		// Need to also add TLV 132 which has the outgoing ip address
-		glog.V(2).Infof("Sending hello with tlvs %v %v", hello_l1_lan.FirstTLV, hello_l1_lan.FirstTLV.nextTLV)
+	hello_lan := buildLanHelloPDU(level, systemIDToBytes(sid))
+	hello_lan.LanHelloHeader.CircuitType = intf.level
+	hello_lan.LanHelloHeader.Priority = [2]byte{0x00, intf.priority}
+	hello_lan.LanHelloHeader.LanDis = intf.lanID[level-1]
+	// Need to also add TLV 132 which has the outgoing ip address, TLV 1
+	// with our area addresses, the IPv6 TLVs 129 and 232, our topologies and the restart TLV
+	hello_lan.FirstTLV = linkTLVs([]*IsisTLV{getAreaAddressesTLV(getAreas()), getInterfaceTLV(intf), getIPv6HelloTLVs(intf), getHelloMTTLV(intf),
+		getRestartTLV(intf, level)})
+	if neighborsTLV := getLanNeighborsTLV(intf, level); neighborsTLV != nil {
+		neighborsTLV.nextTLV = hello_lan.FirstTLV
+		hello_lan.FirstTLV = neighborsTLV
+	}
+	return hello_lan
+}
+
+func getPaddingTLVs(length int) *IsisTLV {
+	// Padding TLVs full of zeros taking up length bytes, or one less
+	// than that since a TLV takes at least 2
+	var firstTLV, previousTLV *IsisTLV
+	for length >= 2 {
+		size := length - 2
+		if size > MAX_TLV_LENGTH {
+			size = MAX_TLV_LENGTH
+			if length-size-2 == 1 {
+				// Leave enough for another TLV
+				size--
+			}
+		}
+		tlv := &IsisTLV{typeTLV: ISIS_PADDING_TLV, lengthTLV: byte(size), valueTLV: make([]byte, size)}
+		if firstTLV == nil {
+			firstTLV = tlv
+		} else {
+			previousTLV.nextTLV = tlv
+		}
+		previousTLV = tlv
+		length -= size + 2
+	}
+	return firstTLV
+}
+
+func padHello(intf *Intf, firstTLV *IsisTLV, headerLength int) {
+	// Pad a hello out to the interface MTU unless padding is disabled.
+	// Requires the interface lock to be held
+	mtu := intf.mtu
+	if mtu > READ_BUF_SIZE-14 {
+		mtu = READ_BUF_SIZE - 14
+	}
+	if !intf.helloPadding || mtu == 0 {
+		return
+	}
+	lastTLV(firstTLV).nextTLV = getPaddingTLVs(mtu - headerLength - getTLVsLength(firstTLV))
+}
+
+func checkHelloLength(intf *Intf, length int) bool {
+	// Whether a hello fits in our MTU, counting it on the interface if not.
+	// A neighbor with a bigger MTU could send us LSPs we can't receive
+	// so the adjacency must not come up. Requires the interface lock to be held
+	if intf.mtu == 0 || length <= intf.mtu {
+		return true
 	}
-	sendChan <- buildEthernetFrame(l1_multicast,
+	intf.mtuMismatches++
+	glog.Infof("Got a %d byte hello on %s which has an MTU of %d, dropping", length, intf.name, intf.mtu)
+	return false
+}
+
+func sendHello(intf *Intf, level byte, sid string, sendChan chan []byte) {
+	// Requires the interface lock to be held
+	hello_lan := buildLanHello(intf, level, sid)
+	key := getSendKey(intf.helloKeychain)
+	hello_lan.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(key), hello_lan.FirstTLV})
+	padHello(intf, hello_lan.FirstTLV, int(unsafe.Sizeof(hello_lan.Header)+unsafe.Sizeof(hello_lan.LanHelloHeader)))
+	glog.V(2).Infof("Sending %s hello with tlvs %v %v", levelToString(level), hello_lan.FirstTLV, hello_lan.FirstTLV.nextTLV)
+	sendChan <- buildEthernetFrame(getMulticast(intf, level),
 		getMac(intf.name),
-		serializeIsisHelloPDU(hello_l1_lan))
+		signPDU(serializeIsisHelloPDU(hello_lan), key))
+}
+
+func processLanHello(intf *Intf, level byte, sid string, hello *IsisLanHelloPDU, sourceMac []byte, ourMac []byte) (bool, bool) {
+	// Update the adjacency at this level with the neighbor which sent this LAN
+	// hello and rerun the DIS election. Returns whether this is a neighbor we had
+	// not heard from before and whether our LSPs need to be regenerated
+	intf.lock.Lock()
+	defer intf.lock.Unlock()
+	var adj *Adjacency
+	for _, existing := range intf.adjacencies {
+		if existing.level == level && bytes.Equal(existing.neighborMac, sourceMac) {
+			adj = existing
+		}
+	}
+	newNeighbor := adj == nil
+	if newNeighbor {
+		adj = &Adjacency{state: "NEW", intfName: intf.name, level: level, neighborMac: make([]byte, 6)}
+		copy(adj.neighborMac, sourceMac)
+		intf.adjacencies = append(intf.adjacencies, adj)
+	}
+	previous := adj.state
+	setHoldTimer(adj, hello.LanHelloHeader.HoldingTime)
+	adj.neighborSystemID = make([]byte, 6)
+	copy(adj.neighborSystemID, hello.LanHelloHeader.SourceSystemID[:])
+	adj.priority = hello.LanHelloHeader.Priority[1] & MAX_PRIORITY
+	adj.lanID = hello.LanHelloHeader.LanDis
+	if ipTLV := getTLV(hello.FirstTLV, ISIS_IP_INTF_ADDR_TLV); ipTLV != nil && ipTLV.lengthTLV >= 4 {
+		adj.neighborIP = make(net.IP, 4)
+		copy(adj.neighborIP, ipTLV.valueTLV[:4])
+	}
+	adj.neighborIPv6 = getHelloIPv6Address(hello.FirstTLV)
+	topologies := getMTIDs(hello.FirstTLV)
+	topologiesChanged := previous == "UP" && !topologiesEqual(adj.topologies, topologies)
+	adj.topologies = topologies
+	// If our mac is in the neighbor's TLV 6 then it has heard us too and the
+	// adjacency is UP, otherwise it is still initializing
+	adj.state = "INIT"
+	for tlv := hello.FirstTLV; tlv != nil; tlv = tlv.nextTLV {
+		if tlv.typeTLV != ISIS_LAN_NEIGHBORS_TLV {
+			continue
+		}
+		for i := 0; i+6 <= int(tlv.lengthTLV); i += 6 {
+			if bytes.Equal(tlv.valueTLV[i:i+6], ourMac) {
+				adj.state = "UP"
+				adj.metric = getTopologyMetric(intf, MT_STANDARD)
+			}
+		}
+	}
+	// A restarting neighbor has forgotten about us, keep the adjacency UP while it resynchronizes
+	requested := hasRestartRequest(hello.FirstTLV)
+	if requested && previous == "UP" {
+		adj.state = "UP"
+	}
+	adj.restartRequested = requested && adj.state == "UP"
+	if hasRestartAck(hello.FirstTLV, sid) {
+		intf.restartAcked = true
+	}
+	if previous != adj.state {
+		glog.Infof("%s adjacency on %v with %v %s -> %s, neighbor IP %v", levelToString(level), intf.name, systemIDName(adj.neighborSystemID), previous, adj.state, adj.neighborIP)
+	}
+	disChanged := electDIS(intf, level, sid, ourMac)
+	return newNeighbor, (previous == "UP") != (adj.state == "UP") || disChanged || topologiesChanged
 }
 
 func recvHello(intf *Intf, helloChan chan []byte) *HelloResponse {
@@ -149,16 +303,20 @@ func recvHello(intf *Intf, helloChan chan []byte) *HelloResponse {
 
 	// Blocks on the hello channel
 	hello := <-helloChan
-	// Drop the frame unless it is the special multicast mac
-	if bytes.Equal(hello[0:6], l1_multicast) {
+	// Drop the frame unless it is one of the special multicast macs
+	if bytes.Equal(hello[0:6], l1_multicast) || bytes.Equal(hello[0:6], l2_multicast) {
 		glog.V(2).Infof("Got hello from %X:%X:%X:%X:%X:%X\n",
 			hello[6], hello[7], hello[8], hello[9], hello[10], hello[11])
 		glog.V(4).Infof(hex.Dump(hello[:]))
 		// Need to extract the system id from the packet
-		received_hello := deserializeIsisHelloPDU(hello[0:len(hello)])
 		var rsp HelloResponse
-		rsp.lanHelloPDU = received_hello
+		if hello[14+4] == P2P_IIH_PDU_TYPE {
+			rsp.p2pHelloPDU = deserializeP2PHelloPDU(hello[0:len(hello)])
+		} else {
+			rsp.lanHelloPDU = deserializeIsisHelloPDU(hello[0:len(hello)])
+		}
 		rsp.sourceMac = hello[6:12]
+		rsp.length = len(hello) - 14
 		return &rsp
 	}
 	return nil
@@ -172,9 +330,18 @@ func isisHelloSend(intf *Intf, sendChan chan []byte) {
 		intf.lock.Lock()
 		cfg.lock.Lock()
 		if cfg.sid != "" {
-			glog.Infof("Adjacency state on %v: %v goroutine ID %d", intf.name, intf.adj.state, getGID())
-			if intf.adj.state != "UP" {
-				sendHello(intf, cfg.sid, nil, sendChan)
+			glog.Infof("%d adjacencies on %v goroutine ID %d", len(intf.adjacencies), intf.name, getGID())
+			// Hellos keep flowing once adjacencies are UP, they carry
+			// the three-way state or the DIS election information
+			if intf.circuitType == P2P_CIRCUIT {
+				if intf.level != 0 {
+					sendP2PHello(intf, cfg.sid, sendChan)
+				}
+			} else {
+				// Separate hellos for each level
+				for _, level := range getLevels(intf.level) {
+					sendHello(intf, level, cfg.sid, sendChan)
+				}
 			}
 		}
 		glog.V(2).Infof("Unlocking interface and config %s", intf.name)
@@ -198,60 +365,126 @@ func isisHelloRecv(intf *Intf, helloChan chan []byte, sendChan chan []byte) {
 		}
 		intf.lock.Lock()
 		glog.Info("Receving on intf: ", intf.name, " goroutine ID ", getGID())
+		circuitType := intf.circuitType
+		intfLevel := intf.level
+		fits := checkHelloLength(intf, rsp.length)
 		intf.lock.Unlock()
-		// Depending on what type of hello it is, respond
+		if !fits {
+			continue
+		}
+		if rsp.p2pHelloPDU != nil {
+			if circuitType != P2P_CIRCUIT {
+				glog.Infof("Got a p2p hello on broadcast intf %s, dropping", intf.name)
+			} else if systemIDToString(rsp.p2pHelloPDU.P2PHelloHeader.SourceSystemID[:]) != cfg.sid {
+				isisP2PHelloRecv(intf, rsp.p2pHelloPDU, sendChan)
+			}
+			continue
+		} else if circuitType == P2P_CIRCUIT {
+			glog.Infof("Got a LAN hello on p2p intf %s, dropping", intf.name)
+			continue
+		}
 		// Respond to this hello packet with a IS-Neighbor TLV
-		// If we receive a hello with no neighbor tlv, we copy
-		// the mac of the sender into the neighbor tlv and send it back out
-		// then mark the adjacency on that interface as INITIALIZING
-		// If we receive a hello with our own mac in the neighbor tlv
-		// we mark the adjacency as UP
-		glog.Infof("Got hello from %v\n", systemIDToString(rsp.lanHelloPDU.LanHelloHeader.SourceSystemID[:]))
+		// If we receive a hello without our own mac in its neighbor tlv we
+		// mark the adjacency as INITIALIZING, once our mac shows up it is UP
+		glog.Infof("Got hello from %v\n", systemIDName(rsp.lanHelloPDU.LanHelloHeader.SourceSystemID[:]))
 		// This should not be our own system id, drop it if it is
 		if systemIDToString(rsp.lanHelloPDU.LanHelloHeader.SourceSystemID[:]) == cfg.sid {
 			glog.Infof("Got hello from our own system ID, dropping\n")
 			continue
 		}
-		// even if our adjacency is up, we need to respond to other folks
-		if rsp.lanHelloPDU.FirstTLV == nil {
-			// No TLVs yet in this hello packet so we need to add in the IS neighbors tlv
-			// TLV type 6
-			// After getting this --> adjacency is in the initializing state
-			var neighborsTLV IsisTLV
-			neighborsTLV.nextTLV = nil
-			neighborsTLV.typeTLV = 6
-			neighborsTLV.lengthTLV = 6            // Just one other mac for now
-			neighborsTLV.valueTLV = rsp.sourceMac // []byte of the senders mac address
+		level := byte(LEVEL_1)
+		if rsp.lanHelloPDU.Header.TypePDU == L2_LAN_IIH_PDU_TYPE {
+			level = LEVEL_2
+		}
+		if intfLevel&level == 0 {
+			glog.V(1).Infof("Got a %s hello on %s which does not run that level, dropping", levelToString(level), intf.name)
+			continue
+		}
+		if level == LEVEL_1 {
+			// Level 1 adjacencies are only formed within an area
 			intf.lock.Lock()
-			if intf.adj.state != "UP" {
-				glog.Infof("Initializing adjacency on intf %v", intf.name)
-				intf.adj.state = "INIT"
-			}
+			match := checkAreas(intf, rsp.lanHelloPDU.FirstTLV)
 			intf.lock.Unlock()
-			// Send a hello back out the interface we got the response on
-			// But with the neighbor tlv
-			sendHello(intf, cfg.sid, &neighborsTLV, sendChan)
-		} else {
-			// If we do have the neighbors tlv, check if it has our own mac in it
-			// if it does then we know the adjacency is established
-			intf.lock.Lock()
-			if bytes.Equal(rsp.lanHelloPDU.FirstTLV.valueTLV, getMac(intf.name)) {
-				intf.adj.state = "UP"
-				intf.adj.metric = 10
-				intf.adj.neighborSystemID = make([]byte, 6)
-				intf.adj.neighborIP = make(net.IP, 4)
-				copy(intf.adj.neighborSystemID, rsp.lanHelloPDU.LanHelloHeader.SourceSystemID[:])
-				copy(intf.adj.neighborIP, net.IP(rsp.lanHelloPDU.FirstTLV.nextTLV.valueTLV))
-				glog.Infof("Adjacency up between %v and %v on intf %v, neighbor IP %v", cfg.sid, systemIDToString(intf.adj.neighborSystemID), intf.name, intf.adj.neighborIP)
-				// Signal that an adjacency change has occurred, so we should regenerate our lsp
-				// and flood
-				// Optimization might be to use this adjacency information to only update that part of the
-				// LSP, rather than rebuilding the whole thing from the adjacency database
-				intf.lock.Unlock()
-				generateLocalLsp()
-			} else {
-				intf.lock.Unlock()
+			if !match {
+				continue
 			}
 		}
+		newNeighbor, changed := processLanHello(intf, level, cfg.sid, rsp.lanHelloPDU, rsp.sourceMac, getMac(intf.name))
+		if hasRestartRequest(rsp.lanHelloPDU.FirstTLV) {
+			helpRestart(intf, cfg.sid, sendChan)
+		}
+		if newNeighbor {
+			// Send a hello back out the interface we got it on straight away
+			// so the new neighbor finds its mac in our neighbor tlv
+			intf.lock.Lock()
+			sendHello(intf, level, cfg.sid, sendChan)
+			intf.lock.Unlock()
+		}
+		if changed {
+			// Signal that an adjacency or DIS change has occurred, so we should regenerate our lsps
+			// and flood
+			// Optimization might be to use this adjacency information to only update that part of the
+			// LSP, rather than rebuilding the whole thing from the adjacency database
+			scheduleLocalLsp()
+		}
+	}
+}
+
+func setHoldTimer(adj *Adjacency, holdingTime [2]byte) {
+	// Restart the hold timer with the holding time from the neighbor's hello
+	seconds := binary.BigEndian.Uint16(holdingTime[:])
+	if seconds == 0 {
+		seconds = HOLDING_TIME
+	}
+	adj.holdExpiry = time.Now().Add(time.Duration(seconds) * time.Second)
+}
+
+func expireAdjacencies(intf *Intf, sid string, ourMac []byte, now time.Time) bool {
+	// Bring down the adjacencies whose hold timer has expired. LAN adjacencies
+	// are removed and the DIS reelected, the point-to-point adjacency goes
+	// back to NEW. Returns whether our LSPs need to be regenerated.
+	// Requires the interface lock to be held
+	affected := false
+	adjacencies := make([]*Adjacency, 0, len(intf.adjacencies))
+	for _, adj := range intf.adjacencies {
+		if adj.state == "NEW" || adj.holdExpiry.IsZero() || now.Before(adj.holdExpiry) {
+			adjacencies = append(adjacencies, adj)
+			continue
+		}
+		glog.Infof("%s adjacency on %s with %s %s -> DOWN, hold timer expired", levelToString(adj.level), intf.name, systemIDName(adj.neighborSystemID), adj.state)
+		if adj.state == "UP" {
+			affected = true
+		}
+		if intf.circuitType == P2P_CIRCUIT {
+			adjacencies = append(adjacencies, &Adjacency{state: "NEW", intfName: intf.name})
+		}
+	}
+	intf.adjacencies = adjacencies
+	if affected && intf.circuitType == BROADCAST_CIRCUIT {
+		for _, level := range getLevels(intf.level) {
+			electDIS(intf, level, sid, ourMac)
+		}
+	}
+	return affected
+}
+
+func isisHoldTimers(intf *Intf, triggerSPF chan bool) {
+	// Check for expired adjacencies every HOLD_TIMER_INTERVAL, a dead
+	// neighbor is taken out of our LSPs and the routes through it
+	for {
+		time.Sleep(HOLD_TIMER_INTERVAL * time.Millisecond)
+		cfg.lock.Lock()
+		sid := cfg.sid
+		cfg.lock.Unlock()
+		if sid == "" {
+			continue
+		}
+		intf.lock.Lock()
+		affected := expireAdjacencies(intf, sid, getMac(intf.name), time.Now())
+		intf.lock.Unlock()
+		if affected {
+			scheduleLocalLsp()
+			scheduleSPF(triggerSPF)
+		}
 	}
 }
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"net"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 func TestInterfaceTLV(t *testing.T) {
@@ -15,3 +17,74 @@ func TestInterfaceTLV(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestL2LanHelloSerialize(t *testing.T) {
+	intf := Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1_2, priority: 70,
+		prefix: net.IP{0x01, 0x01, 0x01, 0x02}}
+	intf.lanID[1] = [7]byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x02}
+	hello := buildLanHello(&intf, LEVEL_2, "1111.1111.1111")
+	frame := buildEthernetFrame([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x15}, []byte{0, 0, 0, 0, 0, 1}, serializeIsisHelloPDU(hello))
+	received := deserializeIsisHelloPDU(frame)
+	if received.Header.TypePDU != L2_LAN_IIH_PDU_TYPE || received.LanHelloHeader.CircuitType != LEVEL_1_2 ||
+		received.LanHelloHeader.Priority[1] != 70 || received.LanHelloHeader.LanDis != intf.lanID[1] {
+		t.Fail()
+	}
+}
+
+func TestHelloPadding(t *testing.T) {
+	intf := Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, prefix: net.IP{0x01, 0x01, 0x01, 0x02}, mtu: 1500, helloPadding: true}
+	hello := buildLanHello(&intf, LEVEL_1, "1111.1111.1111")
+	headerLength := int(unsafe.Sizeof(hello.Header) + unsafe.Sizeof(hello.LanHelloHeader))
+	padHello(&intf, hello.FirstTLV, headerLength)
+	pdu := serializeIsisHelloPDU(hello)
+	if len(pdu) != 1500 || int(pdu[1]) != headerLength || getPDULength(pdu) != 1500 {
+		t.Fatalf("Expected a 1500 byte hello, got %d with length field %d", len(pdu), getPDULength(pdu))
+	}
+	received := deserializeIsisHelloPDU(buildEthernetFrame([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x14}, []byte{0, 0, 0, 0, 0, 1}, pdu))
+	if getTLV(received.FirstTLV, ISIS_PADDING_TLV) == nil || getTLV(received.FirstTLV, ISIS_IP_INTF_ADDR_TLV) == nil {
+		t.Fail()
+	}
+	// A neighbor with a smaller MTU drops it
+	small := Intf{name: "eth0", mtu: 1400}
+	if checkHelloLength(&small, len(pdu)) || small.mtuMismatches != 1 || !checkHelloLength(&small, 1400) {
+		t.Fail()
+	}
+}
+
+func TestPaddingTLVs(t *testing.T) {
+	// Never leaves a single byte which no TLV could fill
+	for _, length := range []int{2, 257, 258, 259, 1000} {
+		if got := getTLVsLength(getPaddingTLVs(length)); got != length {
+			t.Errorf("Padding %d bytes got %d", length, got)
+		}
+	}
+}
+
+func TestHoldTimerExpiry(t *testing.T) {
+	// R2 stops hearing R1 on the LAN, the adjacency goes away along with R1 as DIS
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Mac, r2Mac := []byte{0, 0, 0, 0, 0, 1}, []byte{0, 0, 0, 0, 0, 2}
+	r1Intf := &Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, circuitID: 5, pseudonodeID: 5, priority: 100, prefix: net.IP{172, 20, 0, 1}}
+	r2Intf := &Intf{name: "eth0", circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, circuitID: 6, priority: DEFAULT_PRIORITY, prefix: net.IP{172, 20, 0, 2}}
+	processLanHello(r1Intf, LEVEL_1, r1sid, buildLanHello(r2Intf, LEVEL_1, r2sid), r2Mac, r1Mac)
+	processLanHello(r2Intf, LEVEL_1, r2sid, buildLanHello(r1Intf, LEVEL_1, r1sid), r1Mac, r2Mac)
+	processLanHello(r1Intf, LEVEL_1, r1sid, buildLanHello(r2Intf, LEVEL_1, r2sid), r2Mac, r1Mac)
+	processLanHello(r2Intf, LEVEL_1, r2sid, buildLanHello(r1Intf, LEVEL_1, r1sid), r1Mac, r2Mac)
+	if r2Intf.adjacencies[0].state != "UP" || nodeIDToString(r2Intf.lanID[0][:]) != "1111.1111.1111.05" {
+		t.Fatalf("Expected an UP adjacency with R1 as DIS")
+	}
+	now := time.Now()
+	if expireAdjacencies(r2Intf, r2sid, r2Mac, now) || len(r2Intf.adjacencies) != 1 {
+		t.Fatalf("Expected the adjacency to still be UP")
+	}
+	if !expireAdjacencies(r2Intf, r2sid, r2Mac, now.Add((HOLDING_TIME+1)*time.Second)) || len(r2Intf.adjacencies) != 0 || r2Intf.lanID[0] != [7]byte{} {
+		t.Fatalf("Expected the adjacency to expire, adjacencies %v LAN ID %v", r2Intf.adjacencies, r2Intf.lanID[0])
+	}
+	// The point-to-point adjacency goes back to NEW
+	p2pIntf := &Intf{name: "eth1", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	p2pIntf.adjacencies[0].state = "INIT"
+	setHoldTimer(p2pIntf.adjacencies[0], [2]byte{0x00, 0x02})
+	if expireAdjacencies(p2pIntf, r2sid, r2Mac, now.Add(3*time.Second)) || p2pIntf.adjacencies[0].state != "NEW" {
+		t.Fail()
+	}
+}
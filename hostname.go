@@ -0,0 +1,73 @@
+// Dynamic hostnames in the IS-IS protocol (RFC 5301).
+// Hostnames learned from TLV 137 are shown next to system IDs.
+// +build linux
+
+package main
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"sync"
+)
+
+// Keyed by system ID i.e. 1111.1111.1111, learned from the LSPs of both levels
+var hostnames = make(map[string]string)
+var hostnamesLock sync.Mutex
+
+func getHostnameTLV(hostname string) *IsisTLV {
+	return &IsisTLV{typeTLV: ISIS_HOSTNAME_TLV, lengthTLV: byte(len(hostname)), valueTLV: []byte(hostname)}
+}
+
+func updateHostnames(lsp *IsisLsp) {
+	// Learn or forget the hostname of the system which originated an LSP,
+	// only fragment zero of its non-pseudonode LSP carries it
+	if lsp.LspID[6] != 0 || lsp.LspID[7] != 0 {
+		return
+	}
+	systemID := systemIDToString(lsp.LspID[:6])
+	hostnamesLock.Lock()
+	defer hostnamesLock.Unlock()
+	if tlv := getTLV(lsp.CoreLsp.FirstTLV, ISIS_HOSTNAME_TLV); tlv != nil && tlv.lengthTLV > 0 {
+		if hostnames[systemID] != string(tlv.valueTLV) {
+			glog.V(1).Infof("Hostname of %s is %s", systemID, string(tlv.valueTLV))
+		}
+		hostnames[systemID] = string(tlv.valueTLV)
+	} else {
+		delete(hostnames, systemID)
+	}
+}
+
+func removeHostname(lsp *IsisLsp) {
+	if lsp.LspID[6] != 0 || lsp.LspID[7] != 0 {
+		return
+	}
+	hostnamesLock.Lock()
+	defer hostnamesLock.Unlock()
+	delete(hostnames, systemIDToString(lsp.LspID[:6]))
+}
+
+func getHostname(systemID string) string {
+	hostnamesLock.Lock()
+	defer hostnamesLock.Unlock()
+	return hostnames[systemID]
+}
+
+func withHostname(nodeID string) string {
+	// A system ID or node ID followed by the hostname of the system, if known
+	// i.e. 1111.1111.1111.02 (r1)
+	if len(nodeID) < 14 {
+		return nodeID
+	}
+	if hostname := getHostname(nodeID[:14]); hostname != "" {
+		return fmt.Sprintf("%s (%s)", nodeID, hostname)
+	}
+	return nodeID
+}
+
+func systemIDName(systemID []byte) string {
+	return withHostname(systemIDToString(systemID))
+}
+
+func nodeIDName(nodeID []byte) string {
+	return withHostname(nodeIDToString(nodeID))
+}
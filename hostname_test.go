@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHostnameMap(t *testing.T) {
+	lsp := buildEmptyLSP(LEVEL_1, 1, "1111.1111.1111")
+	lsp.CoreLsp.FirstTLV = getHostnameTLV("r1")
+	updateHostnames(lsp)
+	if withHostname("1111.1111.1111") != "1111.1111.1111 (r1)" || nodeIDName([]byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x02}) != "1111.1111.1111.02 (r1)" {
+		t.Fatalf("Expected r1, got %s", withHostname("1111.1111.1111"))
+	}
+	// Only fragment zero carries the hostname
+	fragment := buildEmptyLSP(LEVEL_1, 1, "1111.1111.1111")
+	fragment.LspID[7] = 1
+	updateHostnames(fragment)
+	if getHostname("1111.1111.1111") != "r1" {
+		t.Fail()
+	}
+	// A newer LSP without the TLV forgets it
+	updateHostnames(buildEmptyLSP(LEVEL_1, 2, "1111.1111.1111"))
+	if withHostname("1111.1111.1111") != "1111.1111.1111" {
+		t.Fail()
+	}
+}
+
+func TestHostnameSerialize(t *testing.T) {
+	lsp := buildEmptyLSP(LEVEL_1, 1, "1111.1111.1112")
+	lsp.CoreLsp.FirstTLV = getHostnameTLV("router-2")
+	received := deserializeLsp(buildEthernetFrame([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x14}, []byte{0, 0, 0, 0, 0, 1}, serializeLsp(lsp.CoreLsp)))
+	updateHostnames(received)
+	if getHostname("1111.1111.1112") != "router-2" {
+		t.Fail()
+	}
+	removeHostname(received)
+	if getHostname("1111.1111.1112") != "" {
+		t.Fail()
+	}
+}
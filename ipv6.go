@@ -0,0 +1,184 @@
+// IPv6 routing in the IS-IS protocol (RFC 5308).
+// Our protocols go in TLV 129, our addresses in TLV 232 and our IPv6 prefixes in TLV 236.
+// +build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"github.com/golang/glog"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"net"
+)
+
+const (
+	NLPID_IPV4                = 0xcc
+	NLPID_IPV6                = 0x8e
+	IPV6_SUB_TLVS_PRESENT     = 0x20
+	MAX_IPV6_PREFIX_LENGTH    = 128
+	IPV6_ADDRESS_LENGTH       = 16
+	MAX_IPV6_ADDRESSES_IN_TLV = MAX_TLV_LENGTH / IPV6_ADDRESS_LENGTH
+)
+
+func initIPv6(intf *Intf, addrs []*net.IPNet) {
+	// Pick out the link-local address used as our next hop in hellos and the
+	// global addresses and prefixes of an interface we advertise in our LSPs
+	for _, addr := range addrs {
+		if addr.IP.IsLinkLocalUnicast() {
+			intf.ipv6LinkLocal = addr.IP
+		} else {
+			intf.ipv6Addresses = append(intf.ipv6Addresses, addr.IP)
+		}
+	}
+	intf.ipv6Routes = make([]*net.IPNet, 0)
+	link, err := netlink.LinkByName(intf.name)
+	if err != nil {
+		glog.Errorf("Unable to find link %s: %v", intf.name, err)
+		return
+	}
+	routes, _ := netlink.RouteList(link, unix.AF_INET6)
+	for _, prefix := range getConnectedPrefixes(routes) {
+		// The link-local and multicast prefixes are on every interface
+		if !prefix.IP.IsLinkLocalUnicast() && !prefix.IP.IsMulticast() {
+			intf.ipv6Routes = append(intf.ipv6Routes, prefix)
+		}
+	}
+}
+
+func getProtocolsSupportedTLV(ipv6 bool) *IsisTLV {
+	// TLV 129, the NLPID of each network layer protocol we route
+	tlv := &IsisTLV{typeTLV: ISIS_PROTOCOLS_SUPPORTED_TLV, valueTLV: []byte{NLPID_IPV4}}
+	if ipv6 {
+		tlv.valueTLV = append(tlv.valueTLV, NLPID_IPV6)
+	}
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+	return tlv
+}
+
+func getIPv6InterfaceTLV(addresses []net.IP) *IsisTLV {
+	// TLV 232, nil if there are no addresses to advertise
+	if len(addresses) == 0 {
+		return nil
+	}
+	if len(addresses) > MAX_IPV6_ADDRESSES_IN_TLV {
+		addresses = addresses[:MAX_IPV6_ADDRESSES_IN_TLV]
+	}
+	tlv := &IsisTLV{typeTLV: ISIS_IPV6_INTF_ADDR_TLV}
+	for _, address := range addresses {
+		tlv.valueTLV = append(tlv.valueTLV, address.To16()...)
+	}
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+	return tlv
+}
+
+func getIPv6InterfaceAddresses(tlv *IsisTLV) []net.IP {
+	addresses := make([]net.IP, 0)
+	for i := 0; i+IPV6_ADDRESS_LENGTH <= int(tlv.lengthTLV); i += IPV6_ADDRESS_LENGTH {
+		address := make(net.IP, IPV6_ADDRESS_LENGTH)
+		copy(address, tlv.valueTLV[i:i+IPV6_ADDRESS_LENGTH])
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+func getHelloIPv6Address(firstTLV *IsisTLV) net.IP {
+	// The link-local address a neighbor sent in its hello, nil if it has none
+	if tlv := getTLV(firstTLV, ISIS_IPV6_INTF_ADDR_TLV); tlv != nil {
+		if addresses := getIPv6InterfaceAddresses(tlv); len(addresses) > 0 {
+			return addresses[0]
+		}
+	}
+	return nil
+}
+
+func getIPv6HelloTLVs(intf *Intf) *IsisTLV {
+	// TLV 129 and, if the interface has a link-local address, TLV 232.
+	// Requires the interface lock to be held
+	var linkLocal []net.IP
+	if intf.ipv6LinkLocal != nil {
+		linkLocal = []net.IP{intf.ipv6LinkLocal}
+	}
+	return linkTLVs([]*IsisTLV{getProtocolsSupportedTLV(intf.ipv6LinkLocal != nil), getIPv6InterfaceTLV(linkLocal)})
+}
+
+func appendIPv6Prefix(reachTLV *IsisTLV, prefix *net.IPNet, metric uint32, upDown bool) {
+	// 4 byte metric, a flags byte with the up/down bit, the prefix length
+	// and then only as many bytes of the prefix as the length needs
+	length, _ := prefix.Mask.Size()
+	value := make([]byte, 6, 6+(length+7)/8)
+	binary.BigEndian.PutUint32(value[0:4], metric)
+	if upDown {
+		value[4] |= UP_DOWN_BIT
+	}
+	value[5] = byte(length)
+	value = append(value, prefix.IP.To16()[:(length+7)/8]...)
+	appendTLVValue(reachTLV, value, getTLVPrefix(reachTLV))
+}
+
+func getIPv6ReachTLV(interfaces []*Intf) *IsisTLV {
+	reachTLV := &IsisTLV{typeTLV: ISIS_IPV6_REACH_TLV}
+	for _, intf := range interfaces {
+		for _, route := range intf.ipv6Routes {
+			appendIPv6Prefix(reachTLV, route, DEFAULT_METRIC, false)
+		}
+	}
+	return reachTLV
+}
+
+func getIPv6PrefixesFromTLV(reachTLV *IsisTLV) []IPPrefix {
+	prefixes := make([]IPPrefix, 0)
+	for i := 0; i < int(reachTLV.lengthTLV); {
+		if i+6 > int(reachTLV.lengthTLV) {
+			glog.Infof("Malformed IPv6 reachability TLV %v", reachTLV.valueTLV)
+			break
+		}
+		flags := reachTLV.valueTLV[i+4]
+		length := int(reachTLV.valueTLV[i+5])
+		end := i + 6 + (length+7)/8
+		if length > MAX_IPV6_PREFIX_LENGTH || end > int(reachTLV.lengthTLV) {
+			glog.Infof("Malformed IPv6 reachability TLV %v", reachTLV.valueTLV)
+			break
+		}
+		var prefix IPPrefix
+		prefix.metric = binary.BigEndian.Uint32(reachTLV.valueTLV[i : i+4])
+		prefix.upDown = flags&UP_DOWN_BIT != 0
+		ip := make(net.IP, IPV6_ADDRESS_LENGTH)
+		copy(ip, reachTLV.valueTLV[i+6:end])
+		prefix.prefix = net.IPNet{IP: ip, Mask: net.CIDRMask(length, MAX_IPV6_PREFIX_LENGTH)}
+		if flags&IPV6_SUB_TLVS_PRESENT != 0 {
+			if end >= int(reachTLV.lengthTLV) {
+				glog.Infof("Malformed IPv6 reachability TLV %v", reachTLV.valueTLV)
+				break
+			}
+			end += 1 + int(reachTLV.valueTLV[end])
+		}
+		prefixes = append(prefixes, prefix)
+		i = end
+	}
+	return prefixes
+}
+
+func getIPv6Prefixes(db *IsisDB, systemID string) []IPPrefix {
+	// The IPv6 prefixes across all the fragments of a system's LSP,
+	// requires the update db lock to be held
+	var prefixes []IPPrefix
+	for _, lsp := range getLspFragments(db, systemID) {
+		for currentTLV := lsp.CoreLsp.FirstTLV; currentTLV != nil; currentTLV = currentTLV.nextTLV {
+			if currentTLV.typeTLV == ISIS_IPV6_REACH_TLV {
+				prefixes = append(prefixes, getIPv6PrefixesFromTLV(currentTLV)...)
+			}
+		}
+	}
+	return prefixes
+}
+
+func getLinkIndex(intfName string) int {
+	// IPv6 next hops are link-local so routes need the outgoing interface
+	for _, intf := range cfg.interfaces {
+		if intf.name == intfName {
+			return int(intf.circuitID)
+		}
+	}
+	return 0
+}
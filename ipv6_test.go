@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIPv6Prefixes(t *testing.T) {
+	tlv := &IsisTLV{typeTLV: ISIS_IPV6_REACH_TLV}
+	_, p64, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	_, p128, _ := net.ParseCIDR("2001:db8::1/128")
+	_, p0, _ := net.ParseCIDR("::/0")
+	appendIPv6Prefix(tlv, p64, 20, false)
+	appendIPv6Prefix(tlv, p128, 10, true)
+	appendIPv6Prefix(tlv, p0, 1, false)
+	// Only the significant bytes of each prefix are sent
+	if tlv.lengthTLV != 14+22+6 {
+		t.Fatalf("Expected 42 bytes, got %d", tlv.lengthTLV)
+	}
+	prefixes := getPrefixesFromTLV(tlv)
+	if len(prefixes) != 3 || prefixes[0].prefix.String() != "2001:db8:1:2::/64" || prefixes[0].metric != 20 || prefixes[0].upDown ||
+		prefixes[1].prefix.String() != "2001:db8::1/128" || !prefixes[1].upDown || prefixes[2].prefix.String() != "::/0" {
+		t.Fatalf("Unexpected prefixes %v", prefixes)
+	}
+}
+
+func TestIPv6HelloNextHop(t *testing.T) {
+	// The link-local address in a neighbor's hello becomes the IPv6 next hop
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, ipv6LinkLocal: net.ParseIP("fe80::1"),
+		adjacencies: []*Adjacency{&Adjacency{state: "INIT", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, neighborCircuitID: 2}}}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2, adjacencies: []*Adjacency{&Adjacency{state: "INIT"}}}
+	hello := buildTestP2PHello(r1Intf, r1sid)
+	lastTLV(hello.FirstTLV).nextTLV = getIPv6HelloTLVs(r1Intf)
+	frame := buildEthernetFrame([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x14}, []byte{0, 0, 0, 0, 0, 1}, serializeP2PHelloPDU(hello))
+	if _, state := processP2PHello(r2Intf, r2sid, deserializeP2PHelloPDU(frame)); state != "UP" {
+		t.Fatalf("Expected UP, got %s", state)
+	}
+	if !r2Intf.adjacencies[0].neighborIPv6.Equal(net.ParseIP("fe80::1")) {
+		t.Fatalf("Unexpected next hop %v", r2Intf.adjacencies[0].neighborIPv6)
+	}
+	if nlpids := getTLV(hello.FirstTLV, ISIS_PROTOCOLS_SUPPORTED_TLV); nlpids == nil || nlpids.lengthTLV != 2 || nlpids.valueTLV[1] != NLPID_IPV6 {
+		t.Fail()
+	}
+}
+
+func TestIPv6Lsp(t *testing.T) {
+	initConfig()
+	cfg.sid = "1111.1111.1112"
+	_, prefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	intf := &Intf{name: "eth0", level: LEVEL_1, ipv6Addresses: []net.IP{net.ParseIP("2001:db8:1::2")}, ipv6Routes: []*net.IPNet{prefix},
+		lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	cfg.interfaces = []*Intf{intf}
+	updateDBInit()
+	generateLocalLsp()
+	lsp := getLspFragments(UpdateDB, cfg.sid)[0]
+	addressTLV := getTLV(lsp.CoreLsp.FirstTLV, ISIS_IPV6_INTF_ADDR_TLV)
+	if addressTLV == nil || !getIPv6InterfaceAddresses(addressTLV)[0].Equal(net.ParseIP("2001:db8:1::2")) {
+		t.Fatalf("Missing the IPv6 interface address TLV")
+	}
+	prefixes := getIPv6Prefixes(UpdateDB, cfg.sid)
+	if len(prefixes) != 1 || prefixes[0].prefix.String() != "2001:db8:1::/64" || prefixes[0].metric != DEFAULT_METRIC {
+		t.Fatalf("Unexpected prefixes %v", prefixes)
+	}
+	// IPv6 prefixes stay out of the IPv4 reachability TLVs
+	if len(getDirectlyConnectedPrefixes(UpdateDB, cfg.sid)) != 0 {
+		t.Fail()
+	}
+	initConfig()
+}
@@ -0,0 +1,431 @@
+// Incremental SPF and partial route calculation.
+// Only the LSPs which changed since the last SPF are parsed and only the paths and routes they affect recomputed.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/golang/glog"
+	"sort"
+	"sync"
+)
+
+// What the last SPF of a topology database was computed from
+type spfState struct {
+	updateDB    *IsisDB
+	mtID        uint16
+	inputs      string
+	graph       *spfGraph
+	lsps        map[[7]byte][]*IsisLsp // The fragments of each node
+	paths       []*Triple
+	routes      map[string]*RibRoute
+	prefixNodes map[string]map[int]bool // The nodes with a share in each route
+}
+
+var spfStates = make(map[*IsisDB]*spfState) // Keyed by topology database
+var spfStatesLock sync.Mutex
+
+func getLocalSPFInputs(localSystemID string, localInterfaces []*Intf, level byte, mtID uint16) string {
+	// Everything SPF and the routes take from our own configuration and
+	// adjacencies rather than from the update database, a change to any of
+	// it runs a full SPF
+	inputs := fmt.Sprintf("%s %d %v", localSystemID, getMaxPaths(), cfg.segmentRouting)
+	if cfg.segmentRouting {
+		inputs += fmt.Sprintf(" %v", getSRGB())
+	}
+	for _, intf := range localInterfaces {
+		inputs += fmt.Sprintf("|%s %s %d %v %d %v", intf.name, intf.circuitType, intf.circuitID, intf.lanID[level-1], getTopologyMetric(intf, mtID),
+			hasTopologyAdjacency(intf, level, mtID))
+		for _, adj := range intf.adjacencies {
+			inputs += fmt.Sprintf(";%p %s %d %d %v %v %v %v %s %d", adj, adj.state, adj.level, adj.metric, adjInTopology(intf, adj, mtID),
+				adj.neighborSystemID, adj.neighborIP, adj.neighborIPv6, adj.intfName, adj.adjSID)
+		}
+	}
+	return inputs
+}
+
+func getSPFState(topoDB *IsisDB, updateDB *IsisDB, mtID uint16, inputs string) *spfState {
+	// The state of the last SPF, or an empty one if a full SPF is needed
+	spfStatesLock.Lock()
+	defer spfStatesLock.Unlock()
+	state := spfStates[topoDB]
+	if state != nil && state.updateDB == updateDB && state.mtID == mtID && state.inputs == inputs {
+		return state
+	}
+	if state != nil {
+		glog.V(1).Infof("SPF: Local interfaces changed, running a full SPF")
+	}
+	state = &spfState{updateDB: updateDB, mtID: mtID, inputs: inputs, graph: &spfGraph{index: make(map[[7]byte]int)},
+		lsps: make(map[[7]byte][]*IsisLsp), routes: make(map[string]*RibRoute), prefixNodes: make(map[string]map[int]bool)}
+	spfStates[topoDB] = state
+	topoDB.Root = nil
+	return state
+}
+
+func forgetSPFState(topoDB *IsisDB) {
+	spfStatesLock.Lock()
+	defer spfStatesLock.Unlock()
+	delete(spfStates, topoDB)
+}
+
+func isNeighborTLV(tlv *IsisTLV) bool {
+	return int(tlv.typeTLV) == ISIS_NEIGHBORS_TLV || tlv.typeTLV == ISIS_EXTENDED_IS_REACH_TLV || tlv.typeTLV == ISIS_MT_IS_REACH_TLV
+}
+
+func sameLinks(a []spfLink, b []spfLink) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameFragments(a []*IsisLsp, b []*IsisLsp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func removeLink(in []int, from int) []int {
+	for i := range in {
+		if in[i] == from {
+			return append(in[:i], in[i+1:]...)
+		}
+	}
+	return in
+}
+
+type spfNodeUpdate struct {
+	node       int
+	hasLsp     bool
+	overloaded bool
+	links      []spfLink
+}
+
+func (s *spfState) updateNode(nodeID [7]byte, fragments []*IsisLsp, local [7]byte, updates *[]*spfNodeUpdate, prefixes *[]int) {
+	// Parse the fragments of a node whose LSP changed, applying new prefixes
+	// and keeping a new topology to be applied once the affected nodes are known.
+	// New neighbors, metrics or overload bit are a topology change, a change to
+	// any other TLV a prefix change which only recalculates the node's routes
+	g := s.graph
+	i := g.getNode(nodeID[:])
+	update := &spfNodeUpdate{node: i}
+	var prefixTLVs []byte
+	for _, lsp := range fragments {
+		if lsp.LspID[7] == 0 {
+			update.hasLsp = true
+			// Only fragment zero decides whether a router is overloaded, pseudonodes never are
+			update.overloaded = nodeID[6] == 0 && lspOverloaded(lsp)
+		}
+		walkLspNeighbors(lsp, s.mtID, func(nodeID []byte, metric uint32, subTLVs []byte) {
+			update.links = append(update.links, spfLink{to: g.getNode(nodeID), metric: metric})
+		})
+		for tlv := lsp.CoreLsp.FirstTLV; tlv != nil; tlv = tlv.nextTLV {
+			if !isNeighborTLV(tlv) {
+				prefixTLVs = append(append(prefixTLVs, tlv.typeTLV, tlv.lengthTLV), tlv.valueTLV...)
+			}
+		}
+	}
+	node := &g.nodes[i]
+	if nodeID == local {
+		// Our own links are not used, SPF starts from our adjacencies
+		node.hasLsp = update.hasLsp
+		return
+	}
+	if update.hasLsp != node.hasLsp || update.overloaded != node.overloaded || !sameLinks(update.links, node.links) {
+		glog.V(2).Infof("SPF: Topology of %s changed", node.systemID)
+		*updates = append(*updates, update)
+	}
+	if !bytes.Equal(prefixTLVs, node.prefixes) {
+		glog.V(2).Infof("SPF: Prefixes of %s changed", node.systemID)
+		*prefixes = append(*prefixes, i)
+	}
+	node.prefixes = prefixTLVs
+}
+
+func (s *spfState) updateGraph(updateDB *IsisDB, local [7]byte) ([]int, []int, []int) {
+	// Bring the graph up to date with the update database, only parsing the
+	// nodes with an LSP fragment that was added, replaced or removed. Returns
+	// the nodes with topology changes, those with only prefix changes, and the
+	// nodes whose shortest paths went through the first ones. Requires the
+	// update db lock to be held
+	g := s.graph
+	g.local = g.getNode(local[:])
+	updates := make([]*spfNodeUpdate, 0)
+	prefixes := make([]int, 0)
+	lsps := make(map[[7]byte][]*IsisLsp, len(s.lsps))
+	all := AvlGetAll(updateDB.Root)
+	for start := 0; start < len(all); {
+		// The fragments of a node are next to each other
+		var nodeID [7]byte
+		copy(nodeID[:], all[start].data.(*IsisLsp).LspID[:7])
+		end := start
+		fragments := make([]*IsisLsp, 0, 1)
+		for ; end < len(all) && bytes.Equal(all[end].data.(*IsisLsp).LspID[:7], nodeID[:]); end++ {
+			fragments = append(fragments, all[end].data.(*IsisLsp))
+		}
+		lsps[nodeID] = fragments
+		if !sameFragments(fragments, s.lsps[nodeID]) {
+			s.updateNode(nodeID, fragments, local, &updates, &prefixes)
+		}
+		start = end
+	}
+	removed := make([][7]byte, 0)
+	for nodeID := range s.lsps {
+		if _, ok := lsps[nodeID]; !ok {
+			removed = append(removed, nodeID)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return bytes.Compare(removed[i][:], removed[j][:]) < 0 })
+	for _, nodeID := range removed {
+		s.updateNode(nodeID, nil, local, &updates, &prefixes)
+	}
+	s.lsps = lsps
+	// Found through the links as they were in the last run, then the new ones replace them
+	topology := make([]int, 0, len(updates))
+	for _, update := range updates {
+		topology = append(topology, update.node)
+	}
+	affected := g.getDescendants(topology)
+	for _, update := range updates {
+		node := &g.nodes[update.node]
+		for _, link := range node.links {
+			g.nodes[link.to].in = removeLink(g.nodes[link.to].in, update.node)
+		}
+		for _, link := range update.links {
+			g.nodes[link.to].in = append(g.nodes[link.to].in, update.node)
+		}
+		node.hasLsp = update.hasLsp
+		node.overloaded = update.overloaded
+		node.links = update.links
+	}
+	return topology, prefixes, affected
+}
+
+func (g *spfGraph) getDescendants(from []int) []int {
+	// The nodes with a shortest path through one of the given nodes
+	descendants := make([]int, 0)
+	found := make(map[int]bool)
+	queue := append([]int{}, from...)
+	for len(queue) > 0 {
+		node := &g.nodes[queue[0]]
+		queue = queue[1:]
+		if !node.done || node.overloaded {
+			continue
+		}
+		for _, link := range node.links {
+			neighbor := &g.nodes[link.to]
+			if link.to != g.local && neighbor.done && !found[link.to] && node.distance+link.metric == neighbor.distance {
+				found[link.to] = true
+				descendants = append(descendants, link.to)
+				queue = append(queue, link.to)
+			}
+		}
+	}
+	return descendants
+}
+
+func (s *spfState) resumeSPF(tent *spfHeap, topology []int, affected []int, localInterfaces []*Intf, level byte) {
+	// Take the affected nodes out of paths and into tent through their
+	// neighbors still in paths, and add the new links of the changed nodes
+	// still in paths. Our adjacencies are added after
+	g := s.graph
+	for _, i := range affected {
+		node := &g.nodes[i]
+		node.reached = false
+		node.done = false
+		node.distance = 0
+		node.nextHops = nil
+		g.touch(node, i)
+	}
+	for _, i := range affected {
+		for _, from := range g.nodes[i].in {
+			if neighbor := &g.nodes[from]; from != g.local && neighbor.done && !neighbor.overloaded {
+				for _, link := range neighbor.links {
+					if link.to == i {
+						g.relax(tent, from, link, localInterfaces, level)
+					}
+				}
+			}
+		}
+	}
+	for _, i := range topology {
+		if node := &g.nodes[i]; node.done && !node.overloaded {
+			for _, link := range node.links {
+				g.relax(tent, i, link, localInterfaces, level)
+			}
+		}
+	}
+}
+
+func sameNextHops(a []*Adjacency, b []*Adjacency) bool {
+	// Compared in full, not through mergeNextHops which stops at the maximum paths
+	if len(a) != len(b) {
+		return false
+	}
+	for _, adj := range a {
+		found := false
+		for _, nextHop := range b {
+			found = found || nextHop == adj
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *spfState) updatePaths(topoDB *IsisDB) []int {
+	// Replace the paths of the nodes SPF went through which changed, in the
+	// topology database too. Returns the nodes whose paths changed
+	g := s.graph
+	changed := make([]int, 0)
+	for _, i := range g.touched {
+		node := &g.nodes[i]
+		node.touched = false
+		if !node.reached {
+			if node.path != nil {
+				node.path = nil
+				changed = append(changed, i)
+				topoDB.Root = AvlDelete(topoDB.Root, systemIDToKey(node.systemID))
+			}
+			continue
+		}
+		if node.path != nil && node.path.distance == node.distance && sameNextHops(node.path.nextHops, node.nextHops) {
+			continue
+		}
+		node.path = &Triple{systemID: node.systemID, distance: node.distance, nextHops: node.nextHops}
+		changed = append(changed, i)
+		topoDB.Root = AvlInsert(topoDB.Root, systemIDToKey(node.systemID), node.path, true)
+	}
+	g.touched = g.touched[:0]
+	if len(changed) != 0 {
+		// Ourselves first, then in order of distance
+		reached := make([]int, 0, len(s.paths)+len(changed))
+		for i := range g.nodes {
+			if g.nodes[i].path != nil && i != g.local {
+				reached = append(reached, i)
+			}
+		}
+		sort.Slice(reached, func(a int, b int) bool {
+			if g.nodes[reached[a]].distance != g.nodes[reached[b]].distance {
+				return g.nodes[reached[a]].distance < g.nodes[reached[b]].distance
+			}
+			return reached[a] < reached[b]
+		})
+		s.paths = append(make([]*Triple, 0, len(reached)+1), g.nodes[g.local].path)
+		for _, i := range reached {
+			s.paths = append(s.paths, g.nodes[i].path)
+		}
+	}
+	return changed
+}
+
+func (s *spfState) getRouteNodes(changed []int, prefixes []int, segmentRouting bool) []int {
+	// The nodes whose routes need recalculating, those whose paths or prefixes
+	// changed. With segment routing the labels a prefix is sent with come from
+	// the next hop's SRGB, so a prefix change of a neighbor recalculates the
+	// routes of every node reached through it
+	g := s.graph
+	nodes := append(append(make([]int, 0, len(changed)+len(prefixes)), changed...), prefixes...)
+	if !segmentRouting || len(prefixes) == 0 {
+		return nodes
+	}
+	neighbors := make(map[string]bool)
+	for _, i := range prefixes {
+		if g.nodes[i].nodeID[6] == 0 {
+			neighbors[string(g.nodes[i].nodeID[:6])] = true
+		}
+	}
+	for i := range g.nodes {
+		if path := g.nodes[i].path; path != nil {
+			for _, adj := range path.nextHops {
+				if neighbors[string(adj.neighborSystemID)] {
+					nodes = append(nodes, i)
+					break
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+func (s *spfState) updateRoutes(updateDB *IsisDB, nodes []int, localInterfaces []*Intf, segmentRouting bool) int {
+	// Recalculate the routes of some nodes and combine them with the routes
+	// other nodes have to the same destinations. Returns how many destinations
+	// were recalculated. Requires the update db lock to be held
+	g := s.graph
+	keys := make(map[string]bool)
+	done := make(map[int]bool)
+	for _, i := range nodes {
+		if done[i] {
+			continue
+		}
+		done[i] = true
+		node := &g.nodes[i]
+		for key := range node.routes {
+			keys[key] = true
+			delete(s.prefixNodes[key], i)
+		}
+		node.routes = nil
+		if node.path == nil {
+			continue
+		}
+		routes := make(map[string]*RibRoute)
+		if i == g.local {
+			if segmentRouting {
+				installAdjSIDs(localInterfaces, routes)
+			}
+		} else if node.nodeID[6] == 0 {
+			// Pseudonodes have no prefixes
+			installRouteFromPath(updateDB, node.path, s.mtID, routes)
+			if segmentRouting {
+				installLabelsFromPath(updateDB, node.path, routes)
+			}
+		}
+		node.routes = routes
+		for key := range routes {
+			keys[key] = true
+			if s.prefixNodes[key] == nil {
+				s.prefixNodes[key] = make(map[int]bool)
+			}
+			s.prefixNodes[key][i] = true
+		}
+	}
+	for key := range keys {
+		if len(s.prefixNodes[key]) == 1 {
+			for i := range s.prefixNodes[key] {
+				s.routes[key] = g.nodes[i].routes[key]
+			}
+			continue
+		}
+		contributing := make([]int, 0, len(s.prefixNodes[key]))
+		for i := range s.prefixNodes[key] {
+			contributing = append(contributing, i)
+		}
+		sort.Ints(contributing)
+		merged := make(map[string]*RibRoute)
+		for _, i := range contributing {
+			addRibRoute(merged, g.nodes[i].routes[key])
+		}
+		if route, ok := merged[key]; ok {
+			s.routes[key] = route
+		} else {
+			delete(s.routes, key)
+			delete(s.prefixNodes, key)
+		}
+	}
+	return len(keys)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func gridSystemID(i int) string {
+	return fmt.Sprintf("2020.2020.%04x", i)
+}
+
+func changeGridLsp(updateDB *IsisDB, i int, metrics map[int]uint32, prefix string, overload bool) {
+	// Replace the LSP of a router from buildGridLSDB with new link metrics, an
+	// extra prefix or the overload bit. A metric of 0 drops the link
+	old := AvlSearch(updateDB.Root, systemIDToKey(gridSystemID(i))).(*IsisLsp)
+	lsp := buildEmptyLSP(LEVEL_1, 2, gridSystemID(i))
+	if overload {
+		lsp.CoreLsp.LspHeader.PAttOLType |= OVERLOAD_BIT
+	}
+	neighborTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IS_REACH_TLV}
+	for _, neighbor := range lookupNeighbors(old, MT_STANDARD) {
+		metric := neighbor.metric
+		for j, m := range metrics {
+			if gridSystemID(j) == neighbor.systemID {
+				metric = m
+			}
+		}
+		if metric == 0 {
+			continue
+		}
+		nodeID := systemIDToBytes(neighbor.systemID)
+		appendExtendedNeighbor(neighborTLV, metric, append(nodeID[:], 0), nil)
+	}
+	oldReachTLV := getTLV(old.CoreLsp.FirstTLV, ISIS_EXTENDED_IP_REACH_TLV)
+	reachTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IP_REACH_TLV, lengthTLV: oldReachTLV.lengthTLV, valueTLV: append([]byte{}, oldReachTLV.valueTLV...)}
+	if prefix != "" {
+		_, dst, _ := net.ParseCIDR(prefix)
+		appendExtendedPrefix(reachTLV, dst, 10, false, nil)
+	}
+	lsp.CoreLsp.FirstTLV = linkTLVs([]*IsisTLV{neighborTLV, reachTLV})
+	updateDB.Root = AvlInsert(updateDB.Root, lspIDToKey(lsp.LspID), lsp, true)
+}
+
+func checkIncrementalSPF(t *testing.T, step string, updateDB *IsisDB, topoDB *IsisDB, interfaces []*Intf) []*Triple {
+	// SPF picking up from the last run has to find the same paths and routes as a full SPF
+	paths := computeSPF(updateDB, topoDB, cfg.sid, interfaces, MT_STANDARD)
+	fullDB := &IsisDB{Level: LEVEL_1}
+	full := computeSPF(updateDB, fullDB, cfg.sid, interfaces, MT_STANDARD)
+	if len(paths) != len(full) || len(AvlGetAll(topoDB.Root)) != len(full) {
+		t.Fatalf("%s: expected %d paths, got %d", step, len(full), len(paths))
+	}
+	for i, path := range paths {
+		if path.distance != full[i].distance || !sameNextHops(path.nextHops, AvlSearch(fullDB.Root, systemIDToKey(path.systemID)).(*Triple).nextHops) {
+			t.Fatalf("%s: expected %v, got %v", step, full[i], path)
+		}
+	}
+	routes, fullRoutes := spfStates[topoDB].routes, spfStates[fullDB].routes
+	if len(routes) != len(fullRoutes) {
+		t.Fatalf("%s: expected %d routes, got %d", step, len(fullRoutes), len(routes))
+	}
+	for key, route := range fullRoutes {
+		if routes[key] == nil || routes[key].distance != route.distance || !sameRoute(routes[key], route) {
+			t.Fatalf("%s: expected %v, got %v", step, route, routes[key])
+		}
+	}
+	return paths
+}
+
+func TestIncrementalSPF(t *testing.T) {
+	// A 6x6 grid of routers changing one at a time, we are in the corner
+	initConfig()
+	cfg.sid = gridSystemID(0)
+	updateDB, interfaces := buildGridLSDB(6, 6)
+	topoDB := &IsisDB{Level: LEVEL_1}
+	paths := checkIncrementalSPF(t, "Initial", updateDB, topoDB, interfaces)
+	// A new prefix leaves every path alone
+	changeGridLsp(updateDB, 21, nil, "192.168.0.0/24", false)
+	if prefixPaths := checkIncrementalSPF(t, "Prefix", updateDB, topoDB, interfaces); &prefixPaths[0] != &paths[0] {
+		t.Fatalf("Expected no paths to change")
+	}
+	if route := spfStates[topoDB].routes["192.168.0.0/24"]; route == nil || route.distance != 70 {
+		t.Fatalf("Unexpected route %v", route)
+	}
+	removed := AvlSearch(updateDB.Root, systemIDToKey(gridSystemID(13))).(*IsisLsp)
+	for _, change := range []struct {
+		step  string
+		apply func()
+		check func() bool
+	}{
+		{"Higher metric", func() { changeGridLsp(updateDB, 1, map[int]uint32{2: 100}, "", false) }, nil},
+		{"Lower metric", func() { changeGridLsp(updateDB, 6, map[int]uint32{7: 1}, "", false) }, nil},
+		{"Link down", func() { changeGridLsp(updateDB, 8, map[int]uint32{14: 0}, "", false) }, func() bool {
+			g := spfStates[topoDB].graph
+			for _, link := range g.nodes[g.index[[7]byte{0x20, 0x20, 0x20, 0x20, 0, 8, 0}]].links {
+				if g.nodes[link.to].systemID == gridSystemID(14) {
+					return false
+				}
+			}
+			return true
+		}},
+		{"Overload", func() { changeGridLsp(updateDB, 7, nil, "", true) }, nil},
+		{"LSP removed", func() { updateDB.Root = AvlDelete(updateDB.Root, systemIDToKey(gridSystemID(13))) }, nil},
+		{"Overload cleared", func() { changeGridLsp(updateDB, 7, nil, "", false) }, nil},
+		{"LSP back", func() { updateDB.Root = AvlInsert(updateDB.Root, removed.Key, removed, false) }, nil},
+		{"Unreachable", func() {
+			changeGridLsp(updateDB, 29, map[int]uint32{35: 0}, "", false)
+			changeGridLsp(updateDB, 34, map[int]uint32{35: 0}, "", false)
+		}, func() bool {
+			return AvlSearch(topoDB.Root, systemIDToKey(gridSystemID(35))) == nil && spfStates[topoDB].routes["10.0.35.0/24"] == nil
+		}},
+	} {
+		change.apply()
+		checkIncrementalSPF(t, change.step, updateDB, topoDB, interfaces)
+		if change.check != nil && !change.check() {
+			t.Fatalf("%s: unexpected paths or routes", change.step)
+		}
+	}
+	// A change to our own adjacencies starts over
+	state := spfStates[topoDB]
+	interfaces[0].adjacencies[0].metric = 20
+	checkIncrementalSPF(t, "Adjacency metric", updateDB, topoDB, interfaces)
+	if spfStates[topoDB] == state {
+		t.Fatalf("Expected a full SPF")
+	}
+}
+
+func TestIncrementalSPFMaxPaths(t *testing.T) {
+	// With one path kept, the next hop to the far corner of a square has to
+	// move over when the link it goes through gets worse, at the same distance
+	initConfig()
+	cfg.sid = gridSystemID(0)
+	cfg.maxPaths = 1
+	updateDB, interfaces := buildGridLSDB(2, 2)
+	topoDB := &IsisDB{Level: LEVEL_1}
+	checkIncrementalSPF(t, "Initial", updateDB, topoDB, interfaces)
+	far := AvlSearch(topoDB.Root, systemIDToKey(gridSystemID(3))).(*Triple)
+	through, other := 1, 2
+	if systemIDToString(far.nextHops[0].neighborSystemID) == gridSystemID(2) {
+		through, other = 2, 1
+	}
+	changeGridLsp(updateDB, through, map[int]uint32{3: 100}, "", false)
+	checkIncrementalSPF(t, "Higher metric", updateDB, topoDB, interfaces)
+	far = AvlSearch(topoDB.Root, systemIDToKey(gridSystemID(3))).(*Triple)
+	if far.distance != 20 || systemIDToString(far.nextHops[0].neighborSystemID) != gridSystemID(other) {
+		t.Fatalf("Expected %s through %s, got %v", gridSystemID(3), gridSystemID(other), far)
+	}
+}
+
+func BenchmarkIncrementalSPF(b *testing.B) {
+	// SPF after a change to one LSP of a 5000 router LSDB
+	initConfig()
+	cfg.sid = gridSystemID(0)
+	updateDB, interfaces := buildGridLSDB(50, 100)
+	topoDB := &IsisDB{Level: LEVEL_1}
+	computeSPF(updateDB, topoDB, cfg.sid, interfaces, MT_STANDARD)
+	for _, change := range []struct {
+		name  string
+		apply func(i int)
+	}{
+		{"Prefix", func(i int) { changeGridLsp(updateDB, 2550, nil, fmt.Sprintf("192.168.%d.0/24", i%2), false) }},
+		{"Metric", func(i int) { changeGridLsp(updateDB, 2550, map[int]uint32{2551: uint32(10 + i%2)}, "", false) }},
+	} {
+		b.Run(change.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				change.apply(i)
+				b.StartTimer()
+				computeSPF(updateDB, topoDB, cfg.sid, interfaces, MT_STANDARD)
+			}
+		})
+	}
+}
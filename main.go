@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"flag"
+	"fmt"
 	pb "github.com/connorwstein/go-is-is/config"
 	"github.com/golang/glog"
 	"github.com/vishvananda/netlink"
@@ -22,10 +23,12 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 var wg sync.WaitGroup
 var l1_multicast []byte
+var l2_multicast []byte
 var cfg *Config
 
 const (
@@ -33,11 +36,34 @@ const (
 	RECV_LOG_PREFIX      = "RECV:"
 	SEND_LOG_PREFIX      = "SEND:"
 	CHAN_BUF_SIZE        = 1000
+	// Levels are a bitmask, the same encoding as the circuit type in the PDUs
+	LEVEL_1   = 0x01
+	LEVEL_2   = 0x02
+	LEVEL_1_2 = 0x03
 )
 
 type Config struct {
-	lock sync.Mutex
-	sid  string // Format is 6 bytes in a hex encoded string, with a '.' between bytes 2-3 and 4-5
+	lock  sync.Mutex
+	sid   string // Format is 6 bytes in a hex encoded string, with a '.' between bytes 2-3 and 4-5
+	level byte   // Levels this instance runs
+	// Advertised in TLV 137, defaults to the hostname of the machine
+	hostname string
+	// Whether we originate narrow metrics (TLV 2 and 128), wide metrics
+	// (TLV 22 and 135) or both while transitioning
+	metricStyle string
+	// Whether IPv6 runs in a topology of its own (RFC 5120)
+	multiTopology bool
+	// Whether we set the overload bit, configured or for a while after starting
+	overload        bool
+	startupOverload bool
+	// Segment routing with our SRGB and the prefixes we configured a SID for
+	segmentRouting bool
+	srgbStart      uint32
+	srgbRange      uint32
+	prefixSIDs     []*PrefixSID
+	maxPaths       uint32 // Equal cost next hops SPF keeps for each destination
+	spfThrottle    ThrottleTimers
+	lspThrottle    ThrottleTimers
 	// Keep adjacencies and interfaces separate in case we want to do multiple
 	// IS-IS levels, in which case there would be a level-1 and level-2 adjacency
 	// each pointing to the same interface
@@ -45,15 +71,59 @@ type Config struct {
 }
 
 type Intf struct {
-	adj    *Adjacency
-	name   string
-	prefix net.IP
-	mask   net.IPMask
-	routes []*net.IPNet
+	adjacencies []*Adjacency // Point-to-point circuits have at most one, LAN circuits one per neighbor per level
+	name        string
+	prefix      net.IP
+	mask        net.IPMask
+	routes      []*net.IPNet
+	circuitType string // Either BROADCAST_CIRCUIT or P2P_CIRCUIT
+	circuitID   uint32 // Extended local circuit ID, the interface index
+	// Levels configured on the interface and the levels actually running
+	// on it, which are also limited by the instance level
+	circuitLevel byte
+	level        byte
+	priority     byte // DIS election priority, only used on LAN circuits
+	pseudonodeID byte // Ours on the LAN once we have been DIS, see getPseudonodeID
+	// Level 1 hellos refused because the neighbor shares no area with us
+	areaMismatches uint32
+	// LSPs dropped because their checksum was wrong
+	lspChecksumErrors uint32
+	// Hellos are padded out to the MTU so a neighbor can only hear them if
+	// its MTU is at least as big, hellos too big for ours are counted
+	mtu           int
+	helloPadding  bool
+	mtuMismatches uint32
+	// IPv6 link-local address sent in our hellos, global addresses and
+	// prefixes advertised in our LSPs
+	ipv6LinkLocal net.IP
+	ipv6Addresses []net.IP
+	ipv6Routes    []*net.IPNet
+	metric        uint32 // Link metric in the standard topology
+	ipv6Metric    uint32 // Link metric in the IPv6 topology with multi-topology
+	// Keychain our hellos are authenticated with, PDUs failing authentication are counted
+	helloKeychain string
+	authFailures  uint32
+	bfd           bool // Whether UP adjacencies run a BFD session
+	// Traffic engineering attributes advertised with our links, bandwidths
+	// in bits per second
+	teMetric            uint32
+	maxBandwidth        uint64
+	reservableBandwidth uint64
+	adminGroup          uint32
+	// While gracefully restarting, whether a neighbor has acknowledged our
+	// restart and whether a complete set of CSNPs arrived at each level
+	restartAcked bool
+	restartCsnp  [2]bool
+	// The remaining per level state is indexed by level - 1
+	lanID [2][7]byte
+	// Sequence number of the pseudonode LSP we originate while DIS and
+	// whether it currently lists the routers on the LAN
+	pseudonodeSeq    [2]uint32
+	pseudonodeActive [2]bool
 	// Each interface has an SRM and SSN flag per LSP
 	// Map where the keys are the LspIDs
 	lock           sync.Mutex
-	lspFloodStates map[uint64]*LspFloodState
+	lspFloodStates [2]map[uint64]*LspFloodState
 }
 
 type LspFloodState struct {
@@ -64,22 +134,97 @@ type LspFloodState struct {
 }
 
 type Adjacency struct {
-	state            string // Can be NEW, INITIALIZING or UP
-	neighborSystemID []byte
-	metric           uint32
-	intfName         string
-	neighborIP       net.IP
-}
-
-func getAdjacency(neighborSystemID string) *Adjacency {
-	for i, _ := range cfg.interfaces {
-		if cfg.interfaces[i].adj.state == "UP" {
-			if systemIDToString(cfg.interfaces[i].adj.neighborSystemID) == neighborSystemID {
-				return cfg.interfaces[i].adj
-			}
+	state             string // Can be NEW, INITIALIZING or UP
+	neighborSystemID  []byte
+	neighborCircuitID uint32 // Only used on point-to-point circuits
+	neighborMac       []byte // LAN adjacencies are identified by the neighbor's mac
+	priority          byte
+	lanID             [7]byte // DIS the neighbor reported in its last hello
+	level             byte    // A single level on LAN circuits, possibly both on point-to-point circuits
+	metric            uint32
+	intfName          string
+	neighborIP        net.IP
+	neighborIPv6      net.IP    // Link-local address from the neighbor's hellos, the IPv6 next hop
+	topologies        []uint16  // Topologies the neighbor listed in its hellos
+	holdExpiry        time.Time // The adjacency goes down unless we hear another hello by then
+	restartRequested  bool      // The neighbor is restarting and we are helping it
+	adjSID            uint32    // Label allocated for the adjacency with segment routing
+}
+
+func hasUpAdjacency(intf *Intf, level byte) bool {
+	// Requires the interface lock to be held
+	for _, adj := range intf.adjacencies {
+		if adj.state == "UP" && adj.level&level != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func getLevels(levels byte) []byte {
+	// Split a level bitmask into the individual levels
+	result := make([]byte, 0)
+	for _, level := range []byte{LEVEL_1, LEVEL_2} {
+		if levels&level != 0 {
+			result = append(result, level)
 		}
 	}
-	return nil
+	return result
+}
+
+func levelToString(level byte) string {
+	switch level {
+	case LEVEL_1:
+		return "level-1"
+	case LEVEL_2:
+		return "level-2"
+	case LEVEL_1_2:
+		return "level-1-2"
+	}
+	return "none"
+}
+
+func stringToLevel(level string) (byte, error) {
+	switch level {
+	case "level-1":
+		return LEVEL_1, nil
+	case "level-2":
+		return LEVEL_2, nil
+	case "level-1-2":
+		return LEVEL_1_2, nil
+	}
+	return 0, fmt.Errorf("unsupported level %s", level)
+}
+
+func getMulticast(intf *Intf, level byte) []byte {
+	// LAN PDUs go to AllL1ISs or AllL2ISs depending on their level,
+	// point-to-point circuits carry both levels on the same address
+	if level == LEVEL_2 && intf.circuitType == BROADCAST_CIRCUIT {
+		return l2_multicast
+	}
+	return l1_multicast
+}
+
+func setIntfLevel(intf *Intf, instanceLevel byte) bool {
+	// Recompute the levels running on an interface, restarting the
+	// adjacencies if they changed. Returns whether the adjacencies were reset.
+	// Requires the interface lock to be held
+	level := intf.circuitLevel & instanceLevel
+	if level == intf.level {
+		return false
+	}
+	glog.Infof("Running %s on %s", levelToString(level), intf.name)
+	intf.level = level
+	return resetAdjacencies(intf)
+}
+
+func resetAdjacencies(intf *Intf) bool {
+	// Drop all the adjacencies on an interface. Returns whether that
+	// affects our LSPs. Requires the interface lock to be held
+	affected := hasUpAdjacency(intf, LEVEL_1_2) || intf.pseudonodeActive[0] || intf.pseudonodeActive[1]
+	intf.adjacencies = make([]*Adjacency, 0)
+	intf.lanID = [2][7]byte{}
+	return affected
 }
 
 func systemIDToString(system_id []byte) string {
@@ -97,6 +242,22 @@ func systemIDToString(system_id []byte) string {
 	return result
 }
 
+func nodeIDToString(nodeID []byte) string {
+	// Node IDs are a 6 byte system ID and a 1 byte pseudonode ID, which
+	// is only shown when non-zero i.e. 1111.1111.1111.03
+	if len(nodeID) != 7 {
+		return ""
+	}
+	if nodeID[6] == 0 {
+		return systemIDToString(nodeID[:6])
+	}
+	return systemIDToString(nodeID[:6]) + "." + hex.EncodeToString(nodeID[6:])
+}
+
+func isPseudonode(nodeID string) bool {
+	return len(strings.Replace(nodeID, ".", "", 6)) == 14
+}
+
 func systemIDToBytes(sid string) [6]byte {
 	sid = strings.Replace(sid, ".", "", 6)
 	var sidBytes []byte = make([]byte, 6, 6)
@@ -117,9 +278,14 @@ func getGID() uint64 {
 
 func cleanup() {
 	glog.Infof("Cleanup")
+	if !*retainRoutes {
+		flushRib()
+	}
 }
 
-type server struct{}
+type server struct {
+	triggerSPF chan bool // For config which changes the routes without changing our LSPs
+}
 
 func (s *server) ConfigureSystemID(ctx context.Context, in *pb.SystemIDCfgRequest) (*pb.SystemIDCfgReply, error) {
 	cfg.lock.Lock()
@@ -133,10 +299,484 @@ func (s *server) ConfigureSystemID(ctx context.Context, in *pb.SystemIDCfgReques
 	return &pb.SystemIDCfgReply{Ack: "SID " + in.Sid + " successfully configured"}, nil
 }
 
+func (s *server) ConfigureNET(ctx context.Context, in *pb.NETCfgRequest) (*pb.NETCfgReply, error) {
+	area, sid, err := parseNET(in.Net)
+	if err != nil {
+		return nil, err
+	}
+	cfg.lock.Lock()
+	sameSystemID := cfg.sid == sid
+	newAreas := [][]byte{area}
+	if sameSystemID {
+		// Another area for this router, areas are only ever added
+		newAreas = getAreas()
+		if areasMatch(newAreas, [][]byte{area}) {
+			cfg.lock.Unlock()
+			return &pb.NETCfgReply{Ack: "NET " + in.Net + " already configured"}, nil
+		}
+		if len(newAreas) >= MAX_AREA_ADDRESSES {
+			cfg.lock.Unlock()
+			return nil, fmt.Errorf("at most %d area addresses are supported", MAX_AREA_ADDRESSES)
+		}
+		newAreas = append(append([][]byte{}, newAreas...), area)
+	}
+	setAreas(newAreas)
+	cfg.sid = sid
+	glog.Infof("Got NET request, setting SID to %s and areas to %v", sid, areasToStrings(newAreas))
+	cfg.lock.Unlock()
+	if sameSystemID {
+		// Already running with this system ID, advertise the new area
+		scheduleLocalLsp()
+	}
+	return &pb.NETCfgReply{Ack: "NET " + in.Net + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureHostname(ctx context.Context, in *pb.HostnameCfgRequest) (*pb.HostnameCfgReply, error) {
+	if len(in.Hostname) == 0 || len(in.Hostname) > MAX_TLV_LENGTH {
+		return nil, fmt.Errorf("hostname must be 1-%d characters", MAX_TLV_LENGTH)
+	}
+	cfg.lock.Lock()
+	cfg.hostname = in.Hostname
+	sid := cfg.sid
+	glog.Info("Got hostname request, setting hostname to " + in.Hostname)
+	cfg.lock.Unlock()
+	if sid != "" {
+		scheduleLocalLsp()
+	}
+	return &pb.HostnameCfgReply{Ack: "Hostname " + in.Hostname + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureMetricStyle(ctx context.Context, in *pb.MetricStyleCfgRequest) (*pb.MetricStyleCfgReply, error) {
+	style, err := stringToMetricStyle(in.Style)
+	if err != nil {
+		return nil, err
+	}
+	cfg.lock.Lock()
+	cfg.metricStyle = style
+	sid := cfg.sid
+	interfaces := cfg.interfaces
+	glog.Info("Got metric style request, setting metric style to " + style)
+	cfg.lock.Unlock()
+	// Narrow metrics cap the link metrics
+	for _, intf := range interfaces {
+		intf.lock.Lock()
+		setAdjMetrics(intf)
+		intf.lock.Unlock()
+	}
+	if sid != "" {
+		scheduleLocalLsp()
+	}
+	return &pb.MetricStyleCfgReply{Ack: "Metric style " + style + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureMultiTopology(ctx context.Context, in *pb.MultiTopologyCfgRequest) (*pb.MultiTopologyCfgReply, error) {
+	if in.State != MT_ENABLED && in.State != MT_DISABLED {
+		return nil, fmt.Errorf("unsupported multi-topology state %s, must be %s or %s", in.State, MT_ENABLED, MT_DISABLED)
+	}
+	cfg.lock.Lock()
+	cfg.multiTopology = in.State == MT_ENABLED
+	enabled := cfg.multiTopology
+	sid := cfg.sid
+	glog.Info("Got multi-topology request, setting multi-topology to " + in.State)
+	cfg.lock.Unlock()
+	if !enabled {
+		clearTopoDB(MT_IPV6_UNICAST)
+	}
+	if sid != "" {
+		scheduleLocalLsp()
+	}
+	// Installs or withdraws the routes of the IPv6 topology
+	scheduleSPF(s.triggerSPF)
+	return &pb.MultiTopologyCfgReply{Ack: "Multi-topology " + in.State + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureOverload(ctx context.Context, in *pb.OverloadCfgRequest) (*pb.OverloadCfgReply, error) {
+	if in.State != OVERLOAD_ENABLED && in.State != OVERLOAD_DISABLED {
+		return nil, fmt.Errorf("unsupported overload state %s, must be %s or %s", in.State, OVERLOAD_ENABLED, OVERLOAD_DISABLED)
+	}
+	cfg.lock.Lock()
+	cfg.overload = in.State == OVERLOAD_ENABLED
+	sid := cfg.sid
+	glog.Info("Got overload request, setting overload to " + in.State)
+	cfg.lock.Unlock()
+	if sid != "" {
+		scheduleLocalLsp()
+	}
+	return &pb.OverloadCfgReply{Ack: "Overload " + in.State + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureSegmentRouting(ctx context.Context, in *pb.SegmentRoutingCfgRequest) (*pb.SegmentRoutingCfgReply, error) {
+	if in.State != "" && in.State != SR_ENABLED && in.State != SR_DISABLED {
+		return nil, fmt.Errorf("unsupported segment routing state %s, must be %s or %s", in.State, SR_ENABLED, SR_DISABLED)
+	}
+	cfg.lock.Lock()
+	start, size := cfg.srgbStart, cfg.srgbRange
+	if in.SrgbStart != 0 {
+		start = in.SrgbStart
+	}
+	if in.SrgbRange != 0 {
+		size = in.SrgbRange
+	}
+	if err := checkSRGB(start, size); err != nil {
+		cfg.lock.Unlock()
+		return nil, err
+	}
+	cfg.srgbStart, cfg.srgbRange = start, size
+	if in.State != "" {
+		cfg.segmentRouting = in.State == SR_ENABLED
+	}
+	segmentRouting := cfg.segmentRouting
+	interfaces := cfg.interfaces
+	sid := cfg.sid
+	glog.Infof("Got segment routing request, segment routing %v with SRGB %d-%d", segmentRouting, start, start+size-1)
+	cfg.lock.Unlock()
+	if segmentRouting {
+		enableMpls(interfaces)
+	}
+	if sid != "" {
+		scheduleLocalLsp()
+	}
+	// Programs or withdraws the label routes
+	scheduleSPF(s.triggerSPF)
+	return &pb.SegmentRoutingCfgReply{Ack: "Segment routing successfully configured"}, nil
+}
+
+func (s *server) ConfigurePrefixSID(ctx context.Context, in *pb.PrefixSIDCfgRequest) (*pb.PrefixSIDCfgReply, error) {
+	_, prefix, err := net.ParseCIDR(in.Prefix)
+	if err != nil || prefix.IP.To4() == nil {
+		return nil, fmt.Errorf("invalid IPv4 prefix %s", in.Prefix)
+	}
+	cfg.lock.Lock()
+	prefixSIDs := make([]*PrefixSID, 0, len(cfg.prefixSIDs)+1)
+	for _, sid := range cfg.prefixSIDs {
+		if sid.prefix.String() != prefix.String() {
+			prefixSIDs = append(prefixSIDs, sid)
+		}
+	}
+	ack := "Prefix SID for " + prefix.String()
+	if in.Remove {
+		if len(prefixSIDs) == len(cfg.prefixSIDs) {
+			cfg.lock.Unlock()
+			return nil, fmt.Errorf("no prefix SID for %s", prefix.String())
+		}
+		glog.Infof("Removing the prefix SID for %v", prefix)
+		ack += " successfully removed"
+	} else {
+		if in.Index >= cfg.srgbRange {
+			cfg.lock.Unlock()
+			return nil, fmt.Errorf("SID index %d out of range, must be less than the SRGB size %d", in.Index, cfg.srgbRange)
+		}
+		sid := &PrefixSID{prefix: *prefix, index: in.Index}
+		if ones, bits := prefix.Mask.Size(); ones == bits {
+			sid.flags |= PREFIX_SID_FLAG_NODE
+		}
+		if in.NoPhp {
+			sid.flags |= PREFIX_SID_FLAG_NO_PHP
+		}
+		glog.Infof("Setting the prefix SID for %v to index %d", prefix, in.Index)
+		prefixSIDs = append(prefixSIDs, sid)
+		ack += " successfully configured"
+	}
+	cfg.prefixSIDs = prefixSIDs
+	sid := cfg.sid
+	segmentRouting := cfg.segmentRouting
+	cfg.lock.Unlock()
+	if sid != "" && segmentRouting {
+		scheduleLocalLsp()
+	}
+	if segmentRouting {
+		scheduleSPF(s.triggerSPF)
+	}
+	return &pb.PrefixSIDCfgReply{Ack: ack}, nil
+}
+
+func (s *server) ConfigureSPF(ctx context.Context, in *pb.SPFCfgRequest) (*pb.SPFCfgReply, error) {
+	if in.MaxPaths > MAX_PATHS {
+		return nil, fmt.Errorf("maximum paths %d out of range, must be at most %d", in.MaxPaths, MAX_PATHS)
+	}
+	cfg.lock.Lock()
+	if in.MaxPaths != 0 {
+		glog.Infof("Setting maximum paths to %d", in.MaxPaths)
+		cfg.maxPaths = in.MaxPaths
+	}
+	cfg.lock.Unlock()
+	if in.MaxPaths != 0 {
+		scheduleSPF(s.triggerSPF)
+	}
+	return &pb.SPFCfgReply{Ack: "SPF successfully configured"}, nil
+}
+
+func (s *server) ConfigureThrottle(ctx context.Context, in *pb.ThrottleCfgRequest) (*pb.ThrottleCfgReply, error) {
+	if in.Throttle != THROTTLE_SPF && in.Throttle != THROTTLE_LSP {
+		return nil, fmt.Errorf("invalid throttle %s, must be %s or %s", in.Throttle, THROTTLE_SPF, THROTTLE_LSP)
+	}
+	for _, wait := range []uint32{in.InitialWait, in.SecondaryWait, in.MaxWait} {
+		if wait > MAX_THROTTLE_WAIT {
+			return nil, fmt.Errorf("wait %dms out of range, must be at most %dms", wait, MAX_THROTTLE_WAIT)
+		}
+	}
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+	timers := &cfg.spfThrottle
+	if in.Throttle == THROTTLE_LSP {
+		timers = &cfg.lspThrottle
+	}
+	updated := *timers
+	if in.InitialWait != 0 {
+		updated.initialWait = in.InitialWait
+	}
+	if in.SecondaryWait != 0 {
+		updated.secondaryWait = in.SecondaryWait
+	}
+	if in.MaxWait != 0 {
+		updated.maxWait = in.MaxWait
+	}
+	if updated.initialWait > updated.maxWait || updated.secondaryWait > updated.maxWait {
+		return nil, fmt.Errorf("%s waits must be at most the maximum wait, got %v", in.Throttle, updated)
+	}
+	*timers = updated
+	glog.Infof("Setting %s throttle to %v", in.Throttle, updated)
+	return &pb.ThrottleCfgReply{Ack: fmt.Sprintf("Throttle %s successfully configured", in.Throttle)}, nil
+}
+
+func (s *server) ConfigureKeychain(ctx context.Context, in *pb.KeychainCfgRequest) (*pb.KeychainCfgReply, error) {
+	if in.Name == "" || in.Name == AUTH_NONE {
+		return nil, fmt.Errorf("invalid keychain name %s", in.Name)
+	}
+	if in.KeyID > MAX_AUTH_KEY_ID {
+		return nil, fmt.Errorf("key ID %d out of range, must be at most %d", in.KeyID, MAX_AUTH_KEY_ID)
+	}
+	ack := fmt.Sprintf("Key %d of keychain %s", in.KeyID, in.Name)
+	if in.Secret == "" {
+		if !removeKey(in.Name, uint16(in.KeyID)) {
+			return nil, fmt.Errorf("no key %d in keychain %s", in.KeyID, in.Name)
+		}
+		glog.Infof("Removed key %d from keychain %s", in.KeyID, in.Name)
+		ack += " successfully removed"
+	} else {
+		algorithm, err := stringToAuthAlgorithm(in.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if algorithm == AUTH_CLEARTEXT && len(in.Secret) > MAX_CLEARTEXT_PASSWORD {
+			return nil, fmt.Errorf("cleartext passwords must be at most %d characters", MAX_CLEARTEXT_PASSWORD)
+		}
+		key := &AuthKey{id: uint16(in.KeyID), algorithm: algorithm, secret: []byte(in.Secret)}
+		for _, lifetime := range []struct {
+			value string
+			time  *time.Time
+		}{{in.SendStart, &key.sendStart}, {in.SendEnd, &key.sendEnd}, {in.AcceptStart, &key.acceptStart}, {in.AcceptEnd, &key.acceptEnd}} {
+			if *lifetime.time, err = parseLifetime(lifetime.value); err != nil {
+				return nil, fmt.Errorf("invalid lifetime %s: %v", lifetime.value, err)
+			}
+		}
+		setKey(in.Name, key)
+		glog.Infof("Set %s key %d in keychain %s", algorithm, in.KeyID, in.Name)
+		ack += " successfully configured"
+	}
+	cfg.lock.Lock()
+	sid := cfg.sid
+	cfg.lock.Unlock()
+	if sid != "" && usesKeychain(in.Name) {
+		// Re-sign our LSPs with what is now the send key
+		scheduleLocalLsp()
+	}
+	return &pb.KeychainCfgReply{Ack: ack}, nil
+}
+
+func (s *server) ConfigureLevelAuth(ctx context.Context, in *pb.LevelAuthCfgRequest) (*pb.LevelAuthCfgReply, error) {
+	level, err := stringToLevel(in.Level)
+	if err != nil {
+		return nil, err
+	}
+	if in.Keychain == "" {
+		return nil, fmt.Errorf("a keychain or %s is required", AUTH_NONE)
+	}
+	keychain := in.Keychain
+	if keychain == AUTH_NONE {
+		keychain = ""
+	}
+	setLevelKeychain(level, keychain)
+	cfg.lock.Lock()
+	sid := cfg.sid
+	glog.Infof("Got level authentication request, setting the %s keychain to %s", in.Level, in.Keychain)
+	cfg.lock.Unlock()
+	if sid != "" {
+		scheduleLocalLsp()
+	}
+	return &pb.LevelAuthCfgReply{Ack: "Level " + in.Level + " keychain " + in.Keychain + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureLevel(ctx context.Context, in *pb.LevelCfgRequest) (*pb.LevelCfgReply, error) {
+	level, err := stringToLevel(in.Level)
+	if err != nil {
+		return nil, err
+	}
+	cfg.lock.Lock()
+	cfg.level = level
+	glog.Info("Got level request, setting level to " + in.Level)
+	interfaces := cfg.interfaces
+	cfg.lock.Unlock()
+	regenerate := false
+	for _, intf := range interfaces {
+		intf.lock.Lock()
+		if setIntfLevel(intf, level) {
+			regenerate = true
+		}
+		intf.lock.Unlock()
+	}
+	if regenerate {
+		scheduleLocalLsp()
+	}
+	return &pb.LevelCfgReply{Ack: "Level " + in.Level + " successfully configured"}, nil
+}
+
+func (s *server) ConfigureIntf(ctx context.Context, in *pb.IntfCfgRequest) (*pb.IntfCfgReply, error) {
+	// An empty circuit type, level, hello padding, hello keychain, BFD setting or admin
+	// group or a zero priority, metric, IPv6 metric, TE metric or bandwidth leaves that setting unchanged
+	if in.CircuitType != "" && in.CircuitType != BROADCAST_CIRCUIT && in.CircuitType != P2P_CIRCUIT {
+		return nil, fmt.Errorf("unsupported circuit type %s", in.CircuitType)
+	}
+	if in.Metric > MAX_WIDE_LINK_METRIC {
+		return nil, fmt.Errorf("metric %d out of range, must be at most %d", in.Metric, MAX_WIDE_LINK_METRIC)
+	}
+	if in.Ipv6Metric > MAX_WIDE_LINK_METRIC {
+		return nil, fmt.Errorf("IPv6 metric %d out of range, must be at most %d", in.Ipv6Metric, MAX_WIDE_LINK_METRIC)
+	}
+	if in.Priority > MAX_PRIORITY {
+		return nil, fmt.Errorf("priority %d out of range, must be at most %d", in.Priority, MAX_PRIORITY)
+	}
+	if in.TeMetric > MAX_WIDE_LINK_METRIC {
+		return nil, fmt.Errorf("TE metric %d out of range, must be at most %d", in.TeMetric, MAX_WIDE_LINK_METRIC)
+	}
+	var adminGroup uint64
+	if in.AdminGroup != "" {
+		var err error
+		if adminGroup, err = strconv.ParseUint(in.AdminGroup, 0, 32); err != nil {
+			return nil, fmt.Errorf("invalid admin group %s", in.AdminGroup)
+		}
+	}
+	if in.HelloPadding != "" && in.HelloPadding != HELLO_PADDING_ENABLED && in.HelloPadding != HELLO_PADDING_DISABLED {
+		return nil, fmt.Errorf("unsupported hello padding %s", in.HelloPadding)
+	}
+	if in.Bfd != "" && in.Bfd != BFD_ENABLED && in.Bfd != BFD_DISABLED {
+		return nil, fmt.Errorf("unsupported BFD setting %s", in.Bfd)
+	}
+	var circuitLevel byte
+	if in.Level != "" {
+		var err error
+		if circuitLevel, err = stringToLevel(in.Level); err != nil {
+			return nil, err
+		}
+	}
+	cfg.lock.Lock()
+	sid := cfg.sid
+	instanceLevel := cfg.level
+	var intf *Intf
+	for _, i := range cfg.interfaces {
+		if i.name == in.Name {
+			intf = i
+		}
+	}
+	cfg.lock.Unlock()
+	if intf == nil {
+		return nil, fmt.Errorf("unknown interface %s", in.Name)
+	}
+	intf.lock.Lock()
+	regenerate := false
+	if in.CircuitType != "" && intf.circuitType != in.CircuitType {
+		// Changing the circuit type restarts the adjacencies
+		glog.Infof("Setting circuit type on %s to %s", intf.name, in.CircuitType)
+		intf.circuitType = in.CircuitType
+		regenerate = resetAdjacencies(intf)
+	}
+	if circuitLevel != 0 {
+		intf.circuitLevel = circuitLevel
+		if setIntfLevel(intf, instanceLevel) {
+			regenerate = true
+		}
+	}
+	if in.HelloPadding != "" {
+		glog.Infof("Setting hello padding on %s to %s", intf.name, in.HelloPadding)
+		intf.helloPadding = in.HelloPadding == HELLO_PADDING_ENABLED
+	}
+	if in.Bfd != "" {
+		// Sessions are started and stopped by the BFD timers
+		glog.Infof("Setting BFD on %s to %s", intf.name, in.Bfd)
+		intf.bfd = in.Bfd == BFD_ENABLED
+	}
+	if in.HelloKeychain == AUTH_NONE {
+		glog.Infof("Removing hello authentication on %s", intf.name)
+		intf.helloKeychain = ""
+	} else if in.HelloKeychain != "" {
+		glog.Infof("Setting hello keychain on %s to %s", intf.name, in.HelloKeychain)
+		intf.helloKeychain = in.HelloKeychain
+	}
+	if in.Metric != 0 && intf.metric != in.Metric {
+		glog.Infof("Setting metric on %s to %d", intf.name, in.Metric)
+		intf.metric = in.Metric
+		setAdjMetrics(intf)
+		regenerate = true
+	}
+	if in.Ipv6Metric != 0 && intf.ipv6Metric != in.Ipv6Metric {
+		glog.Infof("Setting IPv6 metric on %s to %d", intf.name, in.Ipv6Metric)
+		intf.ipv6Metric = in.Ipv6Metric
+		regenerate = true
+	}
+	// The TE database is built from the LSPs, ours included, by the SPF run
+	// which follows the regeneration of our LSPs
+	if in.TeMetric != 0 && intf.teMetric != in.TeMetric {
+		glog.Infof("Setting TE metric on %s to %d", intf.name, in.TeMetric)
+		intf.teMetric = in.TeMetric
+		regenerate = true
+	}
+	if in.MaxBandwidth != 0 && intf.maxBandwidth != in.MaxBandwidth {
+		glog.Infof("Setting maximum bandwidth on %s to %d", intf.name, in.MaxBandwidth)
+		intf.maxBandwidth = in.MaxBandwidth
+		regenerate = true
+	}
+	if in.ReservableBandwidth != 0 && intf.reservableBandwidth != in.ReservableBandwidth {
+		glog.Infof("Setting reservable bandwidth on %s to %d", intf.name, in.ReservableBandwidth)
+		intf.reservableBandwidth = in.ReservableBandwidth
+		regenerate = true
+	}
+	if in.AdminGroup != "" && intf.adminGroup != uint32(adminGroup) {
+		glog.Infof("Setting admin group on %s to %#x", intf.name, adminGroup)
+		intf.adminGroup = uint32(adminGroup)
+		regenerate = true
+	}
+	if in.Priority != 0 && intf.priority != byte(in.Priority) {
+		glog.Infof("Setting priority on %s to %d", intf.name, in.Priority)
+		intf.priority = byte(in.Priority)
+		if intf.circuitType == BROADCAST_CIRCUIT && sid != "" {
+			for _, level := range getLevels(intf.level) {
+				if electDIS(intf, level, sid, getMac(intf.name)) {
+					regenerate = true
+				}
+			}
+		}
+	}
+	intf.lock.Unlock()
+	if regenerate {
+		scheduleLocalLsp()
+	}
+	return &pb.IntfCfgReply{Ack: "Interface " + in.Name + " successfully configured"}, nil
+}
+
 func (s *server) GetSystemID(ctx context.Context, in *pb.SystemIDRequest) (*pb.SystemIDReply, error) {
 	cfg.lock.Lock()
 	var reply pb.SystemIDReply
 	reply.Sid = cfg.sid
+	reply.Area = areasToStrings(getAreas())
+	reply.Hostname = cfg.hostname
+	reply.MetricStyle = cfg.metricStyle
+	reply.Overload = isOverloaded()
+	if cfg.segmentRouting {
+		reply.Srgb = fmt.Sprintf("%d-%d", cfg.srgbStart, cfg.srgbStart+cfg.srgbRange-1)
+	}
+	reply.MaxPaths = cfg.maxPaths
+	reply.SpfThrottle = cfg.spfThrottle.String()
+	reply.LspThrottle = cfg.lspThrottle.String()
 	cfg.lock.Unlock()
 	return &reply, nil
 }
@@ -147,11 +787,56 @@ func (s *server) GetIntf(ctx context.Context, in *pb.IntfRequest) (*pb.IntfReply
 	reply.Intf = make([]string, len(cfg.interfaces))
 	for i, intf := range cfg.interfaces {
 		intf.lock.Lock()
-		interfaces_string := ""
-		if intf.adj.state != "UP" {
-			interfaces_string += intf.prefix.String() + " " + intf.mask.String() + ", adjacency " + intf.adj.state
-		} else {
-			interfaces_string += intf.prefix.String() + " " + intf.mask.String() + ", adjacency " + intf.adj.state + " with " + systemIDToString(intf.adj.neighborSystemID)
+		interfaces_string := intf.prefix.String() + " " + intf.mask.String() + " " + intf.circuitType + " " + levelToString(intf.level)
+		interfaces_string += fmt.Sprintf(" metric %d", getTopologyMetric(intf, MT_STANDARD))
+		if intf.ipv6LinkLocal != nil {
+			interfaces_string += " " + intf.ipv6LinkLocal.String()
+		}
+		if cfg.multiTopology {
+			interfaces_string += fmt.Sprintf(" topologies %s IPv6 metric %d", mtIDsToString(getIntfTopologies(intf)), getTopologyMetric(intf, MT_IPV6_UNICAST))
+		}
+		if len(intf.adjacencies) == 0 {
+			interfaces_string += ", adjacency NEW"
+		}
+		for _, adj := range intf.adjacencies {
+			if adj.state != "UP" {
+				interfaces_string += ", " + levelToString(adj.level) + " adjacency " + adj.state
+			} else {
+				interfaces_string += ", " + levelToString(adj.level) + " adjacency " + adj.state + " with " + systemIDName(adj.neighborSystemID)
+				if adj.adjSID != 0 {
+					interfaces_string += fmt.Sprintf(" adjacency SID %d", adj.adjSID)
+				}
+			}
+		}
+		if intf.areaMismatches != 0 {
+			interfaces_string += fmt.Sprintf(", %d area mismatches", intf.areaMismatches)
+		}
+		if !intf.helloPadding {
+			interfaces_string += ", hello padding disabled"
+		}
+		if intf.mtuMismatches != 0 {
+			interfaces_string += fmt.Sprintf(", %d hellos over the MTU of %d", intf.mtuMismatches, intf.mtu)
+		}
+		if intf.lspChecksumErrors != 0 {
+			interfaces_string += fmt.Sprintf(", %d LSP checksum errors", intf.lspChecksumErrors)
+		}
+		if intf.helloKeychain != "" {
+			interfaces_string += ", hello keychain " + intf.helloKeychain
+		}
+		if intf.authFailures != 0 {
+			interfaces_string += fmt.Sprintf(", %d authentication failures", intf.authFailures)
+		}
+		if intf.bfd {
+			interfaces_string += ", BFD enabled" + getBfdSessionsString(intf.name)
+		}
+		if hasTEAttributes(intf) {
+			interfaces_string += fmt.Sprintf(", TE metric %d max bandwidth %d reservable bandwidth %d admin group %#x",
+				intf.teMetric, intf.maxBandwidth, intf.reservableBandwidth, intf.adminGroup)
+		}
+		for _, level := range getLevels(intf.level) {
+			if intf.circuitType == BROADCAST_CIRCUIT && intf.lanID[level-1] != [7]byte{} {
+				interfaces_string += ", " + levelToString(level) + " DIS " + nodeIDName(intf.lanID[level-1][:])
+			}
 		}
 		reply.Intf[i] = interfaces_string
 		intf.lock.Unlock()
@@ -164,9 +849,11 @@ func (s *server) GetLsp(ctx context.Context, in *pb.LspRequest) (*pb.LspReply, e
 	cfg.lock.Lock()
 	var reply pb.LspReply
 	reply.Lsp = make([]string, 0)
-	nodes := AvlGetAll(UpdateDB.Root)
-	for _, node := range nodes {
-		reply.Lsp = append(reply.Lsp, node.data.(*IsisLsp).String())
+	for _, level := range getLevels(cfg.level) {
+		nodes := AvlGetAll(getUpdateDB(level).Root)
+		for _, node := range nodes {
+			reply.Lsp = append(reply.Lsp, "L"+strconv.Itoa(int(level))+" "+node.data.(*IsisLsp).String())
+		}
 	}
 	cfg.lock.Unlock()
 	return &reply, nil
@@ -176,23 +863,71 @@ func (s *server) GetTopo(ctx context.Context, in *pb.TopoRequest) (*pb.TopoReply
 	cfg.lock.Lock()
 	var reply pb.TopoReply
 	reply.Topo = make([]string, 0)
-	reply.Topo = append(reply.Topo, cfg.sid)
-	nodes := AvlGetAll(TopoDB.Root)
-	for _, node := range nodes {
-		reply.Topo = append(reply.Topo, node.data.(*Triple).String())
+	if !hasTopology(getTopologies(), uint16(in.MtID)) {
+		cfg.lock.Unlock()
+		return nil, fmt.Errorf("topology %d is not running", in.MtID)
+	}
+	reply.Topo = append(reply.Topo, withHostname(cfg.sid)+" "+topologyToString(uint16(in.MtID)))
+	for _, level := range getLevels(cfg.level) {
+		nodes := AvlGetAll(getTopoDB(level, uint16(in.MtID)).Root)
+		for _, node := range nodes {
+			reply.Topo = append(reply.Topo, "L"+strconv.Itoa(int(level))+" "+node.data.(*Triple).String())
+		}
 	}
 	cfg.lock.Unlock()
 	return &reply, nil
 }
 
-func start_grpc() {
+func (s *server) ComputePath(ctx context.Context, in *pb.PathRequest) (*pb.PathReply, error) {
+	if in.Metric != "" && in.Metric != PATH_METRIC_TE && in.Metric != PATH_METRIC_IGP {
+		return nil, fmt.Errorf("unsupported metric %s", in.Metric)
+	}
+	cfg.lock.Lock()
+	source := cfg.sid
+	instanceLevel := cfg.level
+	cfg.lock.Unlock()
+	level := getLevels(instanceLevel)[0]
+	var err error
+	if in.Level != "" {
+		if level, err = stringToLevel(in.Level); err != nil || level == LEVEL_1_2 {
+			return nil, fmt.Errorf("unsupported level %s", in.Level)
+		}
+		if instanceLevel&level == 0 {
+			return nil, fmt.Errorf("not running %s", in.Level)
+		}
+	}
+	if in.Source != "" {
+		if source, err = resolveSystemID(in.Source); err != nil {
+			return nil, err
+		}
+	}
+	destination, err := resolveSystemID(in.Destination)
+	if err != nil {
+		return nil, err
+	}
+	constraints := &PathConstraints{bandwidth: float32(in.Bandwidth) / 8, includeAny: in.IncludeAny, includeAll: in.IncludeAll,
+		excludeAny: in.ExcludeAny, teMetric: in.Metric != PATH_METRIC_IGP}
+	hops, err := computePath(getTEDB(level), source, destination, constraints)
+	if err != nil {
+		return nil, err
+	}
+	var reply pb.PathReply
+	reply.Hops = make([]string, len(hops))
+	for i, hop := range hops {
+		reply.Hops[i] = hop.String()
+	}
+	reply.Cost = hops[len(hops)-1].cost
+	return &reply, nil
+}
+
+func start_grpc(triggerSPF chan bool) {
 	lis, err := net.Listen("tcp", strings.Join([]string{":", GRPC_CFG_SERVER_PORT}, ""))
 	if err != nil {
 		glog.Fatalf("gRPC server failed to start listening: %v", err)
 	}
 	s := grpc.NewServer()
-	pb.RegisterConfigureServer(s, &server{})
-	pb.RegisterStateServer(s, &server{})
+	pb.RegisterConfigureServer(s, &server{triggerSPF: triggerSPF})
+	pb.RegisterStateServer(s, &server{triggerSPF: triggerSPF})
 	// Register reflection service on gRPC server.
 	reflection.Register(s)
 	if err := s.Serve(lis); err != nil {
@@ -202,11 +937,12 @@ func start_grpc() {
 
 func initInterfaces() {
 	// Initialize the configuration of this IS-IS node
-	// with the interface information and a NEW adjacency per
-	// interface.
+	// with the interface information. Adjacencies are added
+	// as hellos are received.
 	ifaces, err := net.Interfaces()
 	cfg.interfaces = make([]*Intf, len(ifaces)-1)
 	index := 0
+	ipv6Addrs := make(map[string][]*net.IPNet)
 	if err != nil {
 		glog.Errorf("initInterfaces: %+v\n", err.Error())
 		return
@@ -233,50 +969,61 @@ func initInterfaces() {
 					new_intf.prefix = v.IP
 					new_intf.mask = v.Mask
 					new_intf.lock = sync.Mutex{}
-					var adj Adjacency
-					adj.state = "NEW"
-					adj.intfName = i.Name
-					new_intf.adj = &adj
+					new_intf.circuitType = BROADCAST_CIRCUIT
+					new_intf.circuitID = uint32(i.Index)
+					new_intf.priority = DEFAULT_PRIORITY
+					new_intf.mtu = i.MTU
+					new_intf.helloPadding = true
+					new_intf.metric = DEFAULT_METRIC
+					new_intf.ipv6Metric = DEFAULT_METRIC
+					new_intf.circuitLevel = LEVEL_1_2
+					new_intf.level = LEVEL_1_2 & cfg.level
+					// Adjacencies are created as neighbors are heard from
+					new_intf.adjacencies = make([]*Adjacency, 0)
 
 					cfg.interfaces[index] = &new_intf
 
 					// Initialize the flood states slice on that interface
 					// Initially an empty slice, will grow as lsps are learned/created
-					cfg.interfaces[index].lspFloodStates = make(map[uint64]*LspFloodState)
+					for l := range cfg.interfaces[index].lspFloodStates {
+						cfg.interfaces[index].lspFloodStates[l] = make(map[uint64]*LspFloodState)
+					}
 
-					cfg.interfaces[index].routes = make([]*net.IPNet, 0)
 					// Obtain the routes for that interface
 					link, _ := netlink.LinkByName(i.Name)
 					// Just v4 routes for now, filter by AF_INET
 					routes, _ := netlink.RouteList(link, unix.AF_INET)
-					for _, route := range routes {
-						// IP prefix type: route.Dst
-						if route.Dst != nil {
-							cfg.interfaces[index].routes = append(cfg.interfaces[index].routes, route.Dst)
-						}
-					}
+					cfg.interfaces[index].routes = getConnectedPrefixes(routes)
 					index++
 				} else {
-					// TODO: ipv6 support
-					glog.V(1).Info("IPV6 interface ", i.Name, " not supported")
+					// Added to the interface once we have its IPv4 address
+					ipv6Addrs[i.Name] = append(ipv6Addrs[i.Name], v)
 				}
 			default:
 				glog.Errorf("Not an ip address %+v\n", v)
 			}
 		}
 	}
+	cfg.interfaces = cfg.interfaces[:index]
+	for _, intf := range cfg.interfaces {
+		initIPv6(intf, ipv6Addrs[intf.name])
+	}
 }
 
 func initConfig() {
-	cfg = &Config{lock: sync.Mutex{}, sid: ""}
+	cfg = &Config{lock: sync.Mutex{}, sid: "", level: LEVEL_1, metricStyle: METRIC_STYLE_NARROW,
+		srgbStart: DEFAULT_SRGB_START, srgbRange: DEFAULT_SRGB_RANGE, maxPaths: DEFAULT_MAX_PATHS,
+		spfThrottle: ThrottleTimers{DEFAULT_SPF_INITIAL_WAIT, DEFAULT_SPF_SECONDARY_WAIT, DEFAULT_SPF_MAX_WAIT},
+		lspThrottle: ThrottleTimers{DEFAULT_LSP_INITIAL_WAIT, DEFAULT_LSP_SECONDARY_WAIT, DEFAULT_LSP_MAX_WAIT}}
 }
 
 func main() {
 	flag.Parse()
 	glog.Info("Booting IS-IS node...")
 
-	// This is a special multicast mac address
+	// These are the special AllL1ISs and AllL2ISs multicast mac addresses
 	l1_multicast = []byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x14}
+	l2_multicast = []byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x15}
 
 	// Exit go routine
 	c := make(chan os.Signal, 2)
@@ -290,10 +1037,18 @@ func main() {
 	// Determine the interfaces available on the container
 	// and add that to the configuration
 	initConfig()
+	cfg.hostname, _ = os.Hostname()
+	startupOverload()
+	if *gracefulRestart {
+		startRestart()
+	}
 	initInterfaces()
 	ethernetInit()
 	updateDBInit()
 	topoDBInit()
+	teDBInit()
+	// Flush the routes of a previous instance, unless restarting gracefully
+	ribInit()
 
 	for _, intf := range cfg.interfaces {
 		ethernetIntfInit(intf.name) // Creates send/recv raw sockets
@@ -313,30 +1068,42 @@ func main() {
 		updateChans = append(updateChans, make(chan []byte))
 		sendChans = append(sendChans, make(chan []byte))
 	}
-	triggerSPF := make(chan bool)
+	// SPF and our LSPs are each generated by one goroutine, throttled
+	triggerSPF := make(chan bool, 1)
+	go isisDecision(triggerSPF)
+	go isisLocalLsp(triggerSPF)
+	// Age out the LSPs in both databases and refresh our own
+	go isisAging(triggerSPF)
+	// Sign our LSPs again as keys roll over
+	go isisKeyRollover()
+	// Hold off on our LSPs and SPF until our database is back in sync after a graceful restart
+	go isisRestartTimer(triggerSPF)
+	// Fast failure detection for the adjacencies on interfaces with BFD enabled
+	bfdInit(triggerSPF)
 	for i, intf := range cfg.interfaces {
-		// Waiting to compute topology based on update db
-		go isisDecision(triggerSPF)
-
 		// The updateInput goroutine is responsible for setting the SRM flag if required to trigger
 		// the flooding
 		go isisUpdateInput(intf, updateChans[i], triggerSPF)
 		// Periodically check for SRMs on each interface
 		go isisUpdate(intf, sendChans[i])
+		// Periodically send CSNPs on each interface to keep the databases synchronized
+		go isisCsnpSend(intf, sendChans[i])
 
 		// Periodically send hellos on each interface
 		// 3-way handshake occurs in parallel on each interface
 		go isisHelloSend(intf, sendChans[i])
 		go isisHelloRecv(intf, helloChans[i], sendChans[i])
+		// Bring adjacencies down when we stop hearing hellos
+		go isisHoldTimers(intf, triggerSPF)
 
 		// Each interface has a goroutine for sending and receiving PDUs
 		// the recv PDU goroutine will forward the PDU to either the hello or update
 		// chan for that interface
-		go recvPdus(intf.name, helloChans[i], updateChans[i])
+		go recvPdus(intf, helloChans[i], updateChans[i])
 		go sendPdus(intf.name, sendChans[i])
 
 	}
 	// Start the gRPC server for accepting configuration (CLI commands)
-	go start_grpc()
+	go start_grpc(triggerSPF)
 	wg.Wait()
 }
@@ -1,14 +1,59 @@
 package main
 
 import (
+	pb "github.com/connorwstein/go-is-is/config"
 	"github.com/golang/glog"
+	"golang.org/x/net/context"
 	"sync"
 	"testing"
 )
 
 func TestInitInterfaces(t *testing.T) {
-	cfg = &Config{lock: sync.Mutex{}, sid: ""}
+	cfg = &Config{lock: sync.Mutex{}, sid: "", level: LEVEL_1}
 	initInterfaces()
 	glog.V(2).Infof("%v", cfg.interfaces[0].routes)
 	// TODO: more testing here
 }
+
+func TestConfigureSchedulesSPF(t *testing.T) {
+	// Config which changes our routes without going through our LSPs runs SPF
+	initConfig()
+	topoDBInit()
+	s := &server{triggerSPF: make(chan bool, 1)}
+	for _, test := range []struct {
+		name      string
+		configure func() error
+	}{
+		{"maximum paths", func() error {
+			_, err := s.ConfigureSPF(context.Background(), &pb.SPFCfgRequest{MaxPaths: 2})
+			return err
+		}},
+		{"multi-topology", func() error {
+			_, err := s.ConfigureMultiTopology(context.Background(), &pb.MultiTopologyCfgRequest{State: MT_ENABLED})
+			return err
+		}},
+		{"multi-topology off", func() error {
+			_, err := s.ConfigureMultiTopology(context.Background(), &pb.MultiTopologyCfgRequest{State: MT_DISABLED})
+			return err
+		}},
+		{"the SRGB", func() error {
+			_, err := s.ConfigureSegmentRouting(context.Background(), &pb.SegmentRoutingCfgRequest{SrgbStart: 15000})
+			return err
+		}},
+		{"a prefix SID", func() error {
+			cfg.segmentRouting = true
+			_, err := s.ConfigurePrefixSID(context.Background(), &pb.PrefixSIDCfgRequest{Prefix: "10.0.0.1/32", Index: 1})
+			return err
+		}},
+	} {
+		if err := test.configure(); err != nil {
+			t.Fatalf("Configuring %s: %v", test.name, err)
+		}
+		select {
+		case <-s.triggerSPF:
+		default:
+			t.Fatalf("Expected configuring %s to run SPF", test.name)
+		}
+	}
+	initConfig()
+}
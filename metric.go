@@ -0,0 +1,167 @@
+// Wide metrics in the IS-IS protocol (RFC 5305).
+// Neighbors in TLV 22 and prefixes in TLV 135, originated according to the metric style.
+// +build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/glog"
+	"net"
+)
+
+const (
+	METRIC_STYLE_NARROW     = "narrow"
+	METRIC_STYLE_WIDE       = "wide"
+	METRIC_STYLE_TRANSITION = "transition"
+	MAX_WIDE_LINK_METRIC    = 0xfffffe // 0xffffff means the link must not be used by SPF
+	MAX_NARROW_LINK_METRIC  = 63       // What fits in the 6 bits of a TLV 2 metric
+	UP_DOWN_BIT             = 0x80     // Set on prefixes leaked from level 2 into level 1
+	SUB_TLVS_PRESENT_BIT    = 0x40
+	PREFIX_LENGTH_MASK      = 0x3f
+)
+
+type IPPrefix struct {
+	prefix net.IPNet
+	metric uint32
+	upDown bool
+	sid    *PrefixSID // From the prefix SID sub-TLV of TLV 135 with segment routing
+}
+
+func stringToMetricStyle(style string) (string, error) {
+	switch style {
+	case METRIC_STYLE_NARROW, METRIC_STYLE_WIDE, METRIC_STYLE_TRANSITION:
+		return style, nil
+	}
+	return "", fmt.Errorf("invalid metric style %s, must be %s, %s or %s", style, METRIC_STYLE_NARROW, METRIC_STYLE_WIDE, METRIC_STYLE_TRANSITION)
+}
+
+func originatesNarrow(style string) bool {
+	// Transition originates both the old and the wide TLVs, so routers which
+	// only understand the old ones keep working while a network is migrated
+	return style != METRIC_STYLE_WIDE
+}
+
+func originatesWide(style string) bool {
+	return style != METRIC_STYLE_NARROW
+}
+
+func appendExtendedNeighbor(neighborsTLV *IsisTLV, metric uint32, nodeID []byte, subTLVs []byte) {
+	// 7 byte node ID, 3 byte metric and the sub-TLV length followed by the sub-TLVs
+	if metric > MAX_WIDE_LINK_METRIC {
+		metric = MAX_WIDE_LINK_METRIC
+	}
+	value := make([]byte, 11, 11+len(subTLVs))
+	copy(value[0:7], nodeID[:7])
+	value[7] = byte(metric >> 16)
+	value[8] = byte(metric >> 8)
+	value[9] = byte(metric)
+	value[10] = byte(len(subTLVs))
+	value = append(value, subTLVs...)
+	glog.V(2).Infof("adding extended neighbor node id %s", nodeIDName(nodeID[:7]))
+	appendTLVValue(neighborsTLV, value, getTLVPrefix(neighborsTLV))
+}
+
+func getExtendedNeighborTLV(interfaces []*Intf, level byte) *IsisTLV {
+	neighborsTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IS_REACH_TLV}
+	for _, neighbor := range getLocalNeighbors(interfaces, level, MT_STANDARD) {
+		nodeID := systemIDToLspID(neighbor.systemID)
+		appendExtendedNeighbor(neighborsTLV, neighbor.metric, nodeID[:7], neighbor.subTLVs)
+	}
+	return neighborsTLV
+}
+
+func getExtendedNeighbors(neighborTLV *IsisTLV) []*Neighbor {
+	neighbors := make([]*Neighbor, 0)
+	walkExtendedNeighbors(neighborTLV, func(nodeID []byte, metric uint32, subTLVs []byte) {
+		neighbors = append(neighbors, &Neighbor{systemID: nodeIDToString(nodeID), metric: metric, subTLVs: subTLVs})
+	})
+	return neighbors
+}
+
+func walkExtendedNeighbors(neighborTLV *IsisTLV, visit func(nodeID []byte, metric uint32, subTLVs []byte)) {
+	// Visit each neighbor of a TLV 22 with its node ID, metric and sub-TLVs
+	for i := 0; i < int(neighborTLV.lengthTLV); {
+		if i+11 > int(neighborTLV.lengthTLV) || i+11+int(neighborTLV.valueTLV[i+10]) > int(neighborTLV.lengthTLV) {
+			glog.Infof("Malformed extended IS reachability TLV %v", neighborTLV.valueTLV)
+			break
+		}
+		value := neighborTLV.valueTLV[i:]
+		metric := uint32(value[7])<<16 | uint32(value[8])<<8 | uint32(value[9])
+		// Links at the maximum metric are advertised but not used
+		if metric <= MAX_WIDE_LINK_METRIC {
+			visit(value[:7], metric, value[11:11+int(value[10])])
+		}
+		i += 11 + int(value[10])
+	}
+}
+
+func appendExtendedPrefix(reachTLV *IsisTLV, prefix *net.IPNet, metric uint32, upDown bool, subTLVs []byte) {
+	// 4 byte metric, a control byte with the up/down bit and prefix length,
+	// then only as many bytes of the prefix as the length needs and any sub-TLVs
+	length, _ := prefix.Mask.Size()
+	value := make([]byte, 5, 6+(length+7)/8+len(subTLVs))
+	binary.BigEndian.PutUint32(value[0:4], metric)
+	value[4] = byte(length)
+	if upDown {
+		value[4] |= UP_DOWN_BIT
+	}
+	value = append(value, prefix.IP.To4()[:(length+7)/8]...)
+	if len(subTLVs) != 0 {
+		value[4] |= SUB_TLVS_PRESENT_BIT
+		value = append(append(value, byte(len(subTLVs))), subTLVs...)
+	}
+	appendTLVValue(reachTLV, value, nil)
+}
+
+func getExtendedIPReachTLV(interfaces []*Intf) *IsisTLV {
+	reachTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IP_REACH_TLV}
+	for _, intf := range interfaces {
+		for _, route := range intf.routes {
+			if route != nil {
+				appendExtendedPrefix(reachTLV, route, DEFAULT_METRIC, false, nil)
+			}
+		}
+	}
+	return reachTLV
+}
+
+func getExtendedPrefixes(reachTLV *IsisTLV) []IPPrefix {
+	prefixes := make([]IPPrefix, 0)
+	for i := 0; i < int(reachTLV.lengthTLV); {
+		if i+5 > int(reachTLV.lengthTLV) {
+			glog.Infof("Malformed extended IP reachability TLV %v", reachTLV.valueTLV)
+			break
+		}
+		control := reachTLV.valueTLV[i+4]
+		length := int(control & PREFIX_LENGTH_MASK)
+		end := i + 5 + (length+7)/8
+		if length > 32 || end > int(reachTLV.lengthTLV) {
+			glog.Infof("Malformed extended IP reachability TLV %v", reachTLV.valueTLV)
+			break
+		}
+		var prefix IPPrefix
+		prefix.metric = binary.BigEndian.Uint32(reachTLV.valueTLV[i : i+4])
+		prefix.upDown = control&UP_DOWN_BIT != 0
+		ip := make(net.IP, 4)
+		copy(ip, reachTLV.valueTLV[i+5:end])
+		prefix.prefix = net.IPNet{IP: ip, Mask: net.CIDRMask(length, 32)}
+		if control&SUB_TLVS_PRESENT_BIT != 0 {
+			if end >= int(reachTLV.lengthTLV) {
+				glog.Infof("Malformed extended IP reachability TLV %v", reachTLV.valueTLV)
+				break
+			}
+			subTLVs := end + 1
+			end = subTLVs + int(reachTLV.valueTLV[end])
+			if end > int(reachTLV.lengthTLV) {
+				glog.Infof("Malformed extended IP reachability TLV %v", reachTLV.valueTLV)
+				break
+			}
+			prefix.sid = getPrefixSID(reachTLV.valueTLV[subTLVs:end])
+		}
+		prefixes = append(prefixes, prefix)
+		i = end
+	}
+	return prefixes
+}
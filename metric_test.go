@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestExtendedNeighborTLV(t *testing.T) {
+	initConfig()
+	systemID := []byte{0x01, 0x01, 0x01, 0x01, 0x01, 0x01}
+	interfaces := []*Intf{&Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: systemID}}}}
+	tlv := getExtendedNeighborTLV(interfaces, LEVEL_1)
+	if tlv.typeTLV != ISIS_EXTENDED_IS_REACH_TLV || tlv.lengthTLV != 11 {
+		t.Fatalf("Unexpected TLV %v", tlv)
+	}
+	// A neighbor with sub-TLVs and one at the maximum metric, which SPF must not use
+	appendTLVValue(tlv, []byte{0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x00, 0x01, 0x00, 0x00, 0x02, 0xff, 0x00}, nil)
+	appendTLVValue(tlv, []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x00, 0xff, 0xff, 0xff, 0x00}, nil)
+	neighbors := getExtendedNeighbors(tlv)
+	if len(neighbors) != 2 || neighbors[0].systemID != "0101.0101.0101" || neighbors[0].metric != DEFAULT_METRIC ||
+		neighbors[1].systemID != "0202.0202.0202" || neighbors[1].metric != 0x10000 {
+		t.Fatalf("Unexpected neighbors %v", neighbors)
+	}
+}
+
+func TestExtendedPrefixes(t *testing.T) {
+	tlv := &IsisTLV{typeTLV: ISIS_EXTENDED_IP_REACH_TLV}
+	appendExtendedPrefix(tlv, &net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.CIDRMask(16, 32)}, 100000, false, nil)
+	appendExtendedPrefix(tlv, &net.IPNet{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(32, 32)}, 10, true, nil)
+	appendExtendedPrefix(tlv, &net.IPNet{IP: net.IP{0, 0, 0, 0}, Mask: net.CIDRMask(0, 32)}, 1, false, nil)
+	// Only the significant bytes of each prefix are sent
+	if tlv.lengthTLV != 7+9+5 {
+		t.Fatalf("Expected 21 bytes, got %d", tlv.lengthTLV)
+	}
+	prefixes := getPrefixesFromTLV(tlv)
+	if len(prefixes) != 3 || prefixes[0].prefix.String() != "172.19.0.0/16" || prefixes[0].metric != 100000 || prefixes[0].upDown ||
+		prefixes[1].prefix.String() != "10.0.0.1/32" || !prefixes[1].upDown || prefixes[2].prefix.String() != "0.0.0.0/0" {
+		t.Fatalf("Unexpected prefixes %v", prefixes)
+	}
+}
+
+func TestTransitionMetrics(t *testing.T) {
+	// In transition mode both TLV types are originated and the wide ones are used
+	initConfig()
+	cfg.sid = "1111.1111.1112"
+	cfg.metricStyle = METRIC_STYLE_TRANSITION
+	intf := &Intf{name: "eth0", level: LEVEL_1, circuitType: P2P_CIRCUIT, lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	intf.routes = []*net.IPNet{&net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.IPMask{0xff, 0xff, 0, 0}}}
+	intf.adjacencies = []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11}}}
+	cfg.interfaces = []*Intf{intf}
+	updateDBInit()
+	generateLocalLsp()
+	lsp := getLspFragments(UpdateDB, cfg.sid)[0]
+	for _, typeTLV := range []byte{ISIS_NEIGHBORS_TLV, ISIS_IP_INTERNAL_REACH_TLV, ISIS_EXTENDED_IS_REACH_TLV, ISIS_EXTENDED_IP_REACH_TLV} {
+		if getTLV(lsp.CoreLsp.FirstTLV, typeTLV) == nil {
+			t.Fatalf("Missing TLV %d", typeTLV)
+		}
+	}
+	// Change the wide metric to tell which TLV the neighbor came from
+	getTLV(lsp.CoreLsp.FirstTLV, ISIS_EXTENDED_IS_REACH_TLV).valueTLV[9] = 20
+	neighbors := lookupNeighbors(lsp, MT_STANDARD)
+	if len(neighbors) != 1 || neighbors[0].systemID != "1111.1111.1111" || neighbors[0].metric != 20 {
+		t.Fatalf("Unexpected neighbors %v", neighbors)
+	}
+	if prefixes := getIPPrefixes(UpdateDB, cfg.sid); len(prefixes) != 1 || prefixes[0].prefix.String() != "172.19.0.0/16" {
+		t.Fatalf("Unexpected prefixes %v", prefixes)
+	}
+	// Wide only drops the narrow TLVs
+	cfg.metricStyle = METRIC_STYLE_WIDE
+	generateLocalLsp()
+	lsp = getLspFragments(UpdateDB, cfg.sid)[0]
+	if getTLV(lsp.CoreLsp.FirstTLV, ISIS_NEIGHBORS_TLV) != nil || getTLV(lsp.CoreLsp.FirstTLV, ISIS_IP_INTERNAL_REACH_TLV) != nil {
+		t.Fail()
+	}
+	initConfig()
+}
@@ -0,0 +1,238 @@
+// Multi-topology IS-IS (RFC 5120).
+// IPv6 gets a topology of its own in TLVs 229, 222 and 237, with its own SPF and routes.
+// +build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/glog"
+)
+
+const (
+	MT_STANDARD     = 0 // IPv4 unicast, also IPv6 without multi-topology
+	MT_IPV6_UNICAST = 2
+	MT_ID_MASK      = 0x0fff
+	MT_ENABLED      = "enabled"
+	MT_DISABLED     = "disabled"
+	MT_ID_LENGTH    = 2
+)
+
+func getTopologies() []uint16 {
+	if cfg.multiTopology {
+		return []uint16{MT_STANDARD, MT_IPV6_UNICAST}
+	}
+	return []uint16{MT_STANDARD}
+}
+
+func topologyToString(mtID uint16) string {
+	switch mtID {
+	case MT_STANDARD:
+		return "standard"
+	case MT_IPV6_UNICAST:
+		return "ipv6-unicast"
+	}
+	return fmt.Sprintf("mt-%d", mtID)
+}
+
+func getMTTLV(mtIDs []uint16) *IsisTLV {
+	// TLV 229, 2 bytes per topology with the MT ID in the low 12 bits
+	tlv := &IsisTLV{typeTLV: ISIS_MT_TLV}
+	for _, mtID := range mtIDs {
+		var value [MT_ID_LENGTH]byte
+		binary.BigEndian.PutUint16(value[:], mtID&MT_ID_MASK)
+		tlv.valueTLV = append(tlv.valueTLV, value[:]...)
+	}
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+	return tlv
+}
+
+func getMTIDs(firstTLV *IsisTLV) []uint16 {
+	// The topologies listed in the TLV 229s of a PDU, only the standard
+	// topology if there are none
+	var mtIDs []uint16
+	for tlv := firstTLV; tlv != nil; tlv = tlv.nextTLV {
+		if tlv.typeTLV != ISIS_MT_TLV {
+			continue
+		}
+		for i := 0; i+MT_ID_LENGTH <= int(tlv.lengthTLV); i += MT_ID_LENGTH {
+			mtIDs = append(mtIDs, binary.BigEndian.Uint16(tlv.valueTLV[i:i+MT_ID_LENGTH])&MT_ID_MASK)
+		}
+	}
+	if mtIDs == nil {
+		return []uint16{MT_STANDARD}
+	}
+	return mtIDs
+}
+
+func hasTopology(mtIDs []uint16, mtID uint16) bool {
+	for _, id := range mtIDs {
+		if id == mtID {
+			return true
+		}
+	}
+	return false
+}
+
+func topologiesEqual(mtIDs []uint16, other []uint16) bool {
+	if len(mtIDs) != len(other) {
+		return false
+	}
+	for i := range mtIDs {
+		if mtIDs[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getIntfTopologies(intf *Intf) []uint16 {
+	// IPv6 is only run on interfaces with a link-local address to use as the next hop
+	if intf.ipv6LinkLocal != nil {
+		return []uint16{MT_STANDARD, MT_IPV6_UNICAST}
+	}
+	return []uint16{MT_STANDARD}
+}
+
+func getHelloMTTLV(intf *Intf) *IsisTLV {
+	// TLV 229 for our hellos, nil without multi-topology. Requires the interface lock to be held
+	if !cfg.multiTopology {
+		return nil
+	}
+	return getMTTLV(getIntfTopologies(intf))
+}
+
+func adjInTopology(intf *Intf, adj *Adjacency, mtID uint16) bool {
+	// Whether both ends of an adjacency take part in a topology
+	if mtID == MT_STANDARD {
+		return true
+	}
+	return hasTopology(getIntfTopologies(intf), mtID) && hasTopology(adj.topologies, mtID)
+}
+
+func hasTopologyAdjacency(intf *Intf, level byte, mtID uint16) bool {
+	// Requires the interface lock to be held
+	for _, adj := range intf.adjacencies {
+		if adj.state == "UP" && adj.level&level != 0 && adjInTopology(intf, adj, mtID) {
+			return true
+		}
+	}
+	return false
+}
+
+func getTopologyMetric(intf *Intf, mtID uint16) uint32 {
+	if mtID == MT_IPV6_UNICAST && intf.ipv6Metric != 0 {
+		return intf.ipv6Metric
+	}
+	if mtID == MT_STANDARD && intf.metric != 0 {
+		if originatesNarrow(cfg.metricStyle) && intf.metric > MAX_NARROW_LINK_METRIC {
+			return MAX_NARROW_LINK_METRIC
+		}
+		return intf.metric
+	}
+	return DEFAULT_METRIC
+}
+
+func setAdjMetrics(intf *Intf) {
+	// Bring the metric of the adjacencies on an interface in line with its
+	// link metric once that or the metric style changes.
+	// Requires the interface lock to be held
+	for _, adj := range intf.adjacencies {
+		adj.metric = getTopologyMetric(intf, MT_STANDARD)
+	}
+}
+
+func getMTID(tlv *IsisTLV) uint16 {
+	if tlv.lengthTLV < MT_ID_LENGTH {
+		return MT_STANDARD
+	}
+	return binary.BigEndian.Uint16(tlv.valueTLV[:MT_ID_LENGTH]) & MT_ID_MASK
+}
+
+func stripMTID(tlv *IsisTLV) *IsisTLV {
+	// The entries of a TLV 222 or 237 without the MT ID in front, these are
+	// the same as the entries of a TLV 22 or 236
+	if tlv.lengthTLV < MT_ID_LENGTH {
+		return &IsisTLV{typeTLV: tlv.typeTLV}
+	}
+	return &IsisTLV{typeTLV: tlv.typeTLV, lengthTLV: tlv.lengthTLV - MT_ID_LENGTH, valueTLV: tlv.valueTLV[MT_ID_LENGTH:]}
+}
+
+func newMTTLV(typeTLV byte, mtID uint16) *IsisTLV {
+	tlv := &IsisTLV{typeTLV: typeTLV, lengthTLV: MT_ID_LENGTH, valueTLV: make([]byte, MT_ID_LENGTH)}
+	binary.BigEndian.PutUint16(tlv.valueTLV, mtID&MT_ID_MASK)
+	return tlv
+}
+
+func getMTNeighborTLV(interfaces []*Intf, level byte, mtID uint16) *IsisTLV {
+	neighborsTLV := newMTTLV(ISIS_MT_IS_REACH_TLV, mtID)
+	for _, neighbor := range getLocalNeighbors(interfaces, level, mtID) {
+		nodeID := systemIDToLspID(neighbor.systemID)
+		appendExtendedNeighbor(neighborsTLV, neighbor.metric, nodeID[:7], nil)
+	}
+	return neighborsTLV
+}
+
+func getMTIPv6ReachTLV(interfaces []*Intf, mtID uint16) *IsisTLV {
+	reachTLV := newMTTLV(ISIS_MT_IPV6_REACH_TLV, mtID)
+	for _, intf := range interfaces {
+		for _, route := range intf.ipv6Routes {
+			appendIPv6Prefix(reachTLV, route, DEFAULT_METRIC, false)
+		}
+	}
+	return reachTLV
+}
+
+func getMTIPv6Prefixes(db *IsisDB, systemID string, mtID uint16) []IPPrefix {
+	// The prefixes a system advertises in a topology's TLV 237s,
+	// requires the update db lock to be held
+	var prefixes []IPPrefix
+	for _, lsp := range getLspFragments(db, systemID) {
+		for tlv := lsp.CoreLsp.FirstTLV; tlv != nil; tlv = tlv.nextTLV {
+			if tlv.typeTLV == ISIS_MT_IPV6_REACH_TLV && getMTID(tlv) == mtID {
+				prefixes = append(prefixes, getIPv6PrefixesFromTLV(stripMTID(tlv))...)
+			}
+		}
+	}
+	return prefixes
+}
+
+func walkMTNeighbors(lsp *IsisLsp, mtID uint16, visit func(nodeID []byte, metric uint32, subTLVs []byte)) bool {
+	// Visit the neighbors in a topology's TLV 222s
+	found := false
+	for tlv := lsp.CoreLsp.FirstTLV; tlv != nil; tlv = tlv.nextTLV {
+		if tlv.typeTLV == ISIS_MT_IS_REACH_TLV && getMTID(tlv) == mtID {
+			walkExtendedNeighbors(stripMTID(tlv), visit)
+			found = true
+		}
+	}
+	if !found {
+		glog.V(2).Infof("No %s neighbor tlv found in LSP %s", topologyToString(mtID), nodeIDName(lsp.LspID[:7]))
+	}
+	return found
+}
+
+func getTLVPrefix(tlv *IsisTLV) []byte {
+	// What another TLV of the same type has to start with when its entries
+	// overflow into it, the MT ID for the multi-topology TLVs
+	if (tlv.typeTLV == ISIS_MT_IS_REACH_TLV || tlv.typeTLV == ISIS_MT_IPV6_REACH_TLV) && len(tlv.valueTLV) >= MT_ID_LENGTH {
+		return append([]byte{}, tlv.valueTLV[:MT_ID_LENGTH]...)
+	}
+	return nil
+}
+
+func isIPv6ReachTLV(tlv *IsisTLV) bool {
+	return tlv.typeTLV == ISIS_IPV6_REACH_TLV || tlv.typeTLV == ISIS_MT_IPV6_REACH_TLV
+}
+
+func mtIDsToString(mtIDs []uint16) string {
+	result := ""
+	for i, mtID := range mtIDs {
+		if i > 0 {
+			result += " "
+		}
+		result += topologyToString(mtID)
+	}
+	return result
+}
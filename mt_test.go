@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func buildTestMTLsp(sid string, interfaces []*Intf) *IsisLsp {
+	lsp := buildEmptyLSP(LEVEL_1, 1, sid)
+	lsp.CoreLsp.FirstTLV = linkTLVs([]*IsisTLV{getMTTLV([]uint16{MT_STANDARD, MT_IPV6_UNICAST}), getExtendedNeighborTLV(interfaces, LEVEL_1),
+		getMTNeighborTLV(interfaces, LEVEL_1, MT_IPV6_UNICAST)})
+	return lsp
+}
+
+func TestMTSPF(t *testing.T) {
+	// TOPO: R1 -- R2 -- R3 dual stack with the R1 -- R3 link IPv4 only
+	// The IPv6 topology has to go around the IPv4 only link
+	initConfig()
+	updateDBInit()
+	r1sid, r2sid, r3sid := "1111.1111.1111", "1111.1111.1112", "1111.1111.1113"
+	linkLocal := net.ParseIP("fe80::1")
+	dualStack := []uint16{MT_STANDARD, MT_IPV6_UNICAST}
+	adj := func(neighbor byte, intfName string, topologies []uint16) []*Adjacency {
+		return []*Adjacency{&Adjacency{level: LEVEL_1, metric: 10, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, neighbor},
+			intfName: intfName, topologies: topologies}}
+	}
+	r1Interfaces := []*Intf{&Intf{name: "eth0", circuitType: P2P_CIRCUIT, ipv6LinkLocal: linkLocal, ipv6Metric: 15, adjacencies: adj(0x12, "eth0", dualStack)},
+		&Intf{name: "eth1", circuitType: P2P_CIRCUIT, adjacencies: adj(0x13, "eth1", []uint16{MT_STANDARD})}}
+	r2Interfaces := []*Intf{&Intf{name: "eth0", circuitType: P2P_CIRCUIT, ipv6LinkLocal: linkLocal, ipv6Metric: 15, adjacencies: adj(0x11, "eth0", dualStack)},
+		&Intf{name: "eth1", circuitType: P2P_CIRCUIT, ipv6LinkLocal: linkLocal, adjacencies: adj(0x13, "eth1", dualStack)}}
+	r3Interfaces := []*Intf{&Intf{name: "eth0", circuitType: P2P_CIRCUIT, ipv6LinkLocal: linkLocal, adjacencies: adj(0x12, "eth0", dualStack)},
+		&Intf{name: "eth1", circuitType: P2P_CIRCUIT, adjacencies: adj(0x11, "eth1", []uint16{MT_STANDARD})}}
+	for sid, interfaces := range map[string][]*Intf{r1sid: r1Interfaces, r2sid: r2Interfaces, r3sid: r3Interfaces} {
+		UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(sid), buildTestMTLsp(sid, interfaces), false)
+	}
+	// The IPv4 only link is left out of the IPv6 neighbors
+	if neighbors := getLocalNeighbors(r1Interfaces, LEVEL_1, MT_IPV6_UNICAST); len(neighbors) != 1 || neighbors[0].systemID != r2sid || neighbors[0].metric != 15 {
+		t.Fatalf("Unexpected IPv6 neighbors %v", neighbors)
+	}
+	standard, ipv6 := &IsisDB{}, &IsisDB{}
+	computeSPF(UpdateDB, standard, r1sid, r1Interfaces, MT_STANDARD)
+	computeSPF(UpdateDB, ipv6, r1sid, r1Interfaces, MT_IPV6_UNICAST)
+	r3 := AvlSearch(standard.Root, systemIDToKey(r3sid))
+	if r3 == nil || r3.(*Triple).distance != 10 || r3.(*Triple).nextHops[0].intfName != "eth1" {
+		t.Fatalf("Unexpected standard path to R3 %v", r3)
+	}
+	r3 = AvlSearch(ipv6.Root, systemIDToKey(r3sid))
+	if r3 == nil || r3.(*Triple).distance != 25 || r3.(*Triple).nextHops[0].intfName != "eth0" {
+		t.Fatalf("Unexpected IPv6 path to R3 %v", r3)
+	}
+}
+
+func TestMTHelloTopologies(t *testing.T) {
+	// A change in the topologies of an UP adjacency restarts it
+	initConfig()
+	cfg.multiTopology = true
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, ipv6LinkLocal: net.ParseIP("fe80::1"),
+		adjacencies: []*Adjacency{&Adjacency{state: "INIT", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, neighborCircuitID: 2}}}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2, adjacencies: []*Adjacency{&Adjacency{state: "INIT"}}}
+	hello := buildTestP2PHello(r1Intf, r1sid)
+	lastTLV(hello.FirstTLV).nextTLV = getHelloMTTLV(r1Intf)
+	if _, state := processP2PHello(r2Intf, r2sid, hello); state != "UP" || !hasTopology(r2Intf.adjacencies[0].topologies, MT_IPV6_UNICAST) {
+		t.Fatalf("Expected an UP IPv6 adjacency, got %s %v", state, r2Intf.adjacencies[0].topologies)
+	}
+	// R2 has no link-local address so the adjacency stays out of the IPv6 topology
+	if adjInTopology(r2Intf, r2Intf.adjacencies[0], MT_IPV6_UNICAST) || !adjInTopology(r2Intf, r2Intf.adjacencies[0], MT_STANDARD) {
+		t.Fail()
+	}
+	r1Intf.ipv6LinkLocal = nil
+	r1Intf.adjacencies[0].state = "UP"
+	hello = buildTestP2PHello(r1Intf, r1sid)
+	lastTLV(hello.FirstTLV).nextTLV = getHelloMTTLV(r1Intf)
+	if _, state := processP2PHello(r2Intf, r2sid, hello); state == "UP" {
+		t.Fatalf("Expected the adjacency to restart")
+	}
+	initConfig()
+}
+
+func TestMTReachTLVSplit(t *testing.T) {
+	// Every TLV 237 starts with the MT ID, including the ones entries overflow into
+	intf := &Intf{}
+	for i := 0; i < 30; i++ {
+		intf.ipv6Routes = append(intf.ipv6Routes, &net.IPNet{IP: net.IP{0x20, 0x01, 0x0d, 0xb8, 0, byte(i), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, Mask: net.CIDRMask(64, 128)})
+	}
+	tlv := getMTIPv6ReachTLV([]*Intf{intf}, MT_IPV6_UNICAST)
+	if tlv.nextTLV == nil || getMTID(tlv.nextTLV) != MT_IPV6_UNICAST {
+		t.Fatalf("Expected a second TLV 237")
+	}
+	count := 0
+	for current := tlv; current != nil; current = current.nextTLV {
+		count += len(getIPv6PrefixesFromTLV(stripMTID(current)))
+	}
+	if count != 30 {
+		t.Fatalf("Expected 30 prefixes, got %d", count)
+	}
+}
+
+func TestTopologyMetric(t *testing.T) {
+	// The link metric goes in the standard topology, capped while narrow metrics are originated
+	initConfig()
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, metric: 1000, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: DEFAULT_METRIC,
+		state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, intfName: "eth0"}}}
+	for _, test := range []struct {
+		style  string
+		metric uint32
+	}{{METRIC_STYLE_WIDE, 1000}, {METRIC_STYLE_TRANSITION, MAX_NARROW_LINK_METRIC}, {METRIC_STYLE_NARROW, MAX_NARROW_LINK_METRIC}} {
+		cfg.metricStyle = test.style
+		setAdjMetrics(intf)
+		neighbors := getExtendedNeighbors(getExtendedNeighborTLV([]*Intf{intf}, LEVEL_1))
+		if len(neighbors) != 1 || neighbors[0].metric != test.metric || intf.adjacencies[0].metric != test.metric {
+			t.Fatalf("Expected a %s metric of %d, got %v", test.style, test.metric, neighbors)
+		}
+	}
+	// Without a link metric it is the default, in the IPv6 topology too
+	intf.metric = 0
+	if getTopologyMetric(intf, MT_STANDARD) != DEFAULT_METRIC || getTopologyMetric(intf, MT_IPV6_UNICAST) != DEFAULT_METRIC {
+		t.Fail()
+	}
+	initConfig()
+}
@@ -0,0 +1,52 @@
+// The overload bit in the LSP header.
+// An overloaded router is still reached for its own prefixes but never used for transit.
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"github.com/golang/glog"
+	"time"
+)
+
+const (
+	OVERLOAD_BIT      = 0x04 // Between the attached bits and the IS type in PAttOLType
+	OVERLOAD_ENABLED  = "enabled"
+	OVERLOAD_DISABLED = "disabled"
+)
+
+var overloadOnStartup = flag.Int("overload_on_startup", 0, "Seconds to set the overload bit for after starting, 0 to not set it")
+
+func isOverloaded() bool {
+	// Whether we advertise the overload bit, either configured or still starting up
+	return cfg.overload || cfg.startupOverload
+}
+
+func lspOverloaded(lsp *IsisLsp) bool {
+	// Only fragment zero decides whether a router is overloaded
+	return lsp.CoreLsp.LspHeader.PAttOLType&OVERLOAD_BIT != 0
+}
+
+func startupOverload() {
+	// Set the overload bit for the configured time after starting,
+	// our LSPs are regenerated without it once it expires
+	if *overloadOnStartup <= 0 {
+		return
+	}
+	glog.Infof("Setting the overload bit for %d seconds on startup", *overloadOnStartup)
+	cfg.lock.Lock()
+	cfg.startupOverload = true
+	cfg.lock.Unlock()
+	go func() {
+		time.Sleep(time.Duration(*overloadOnStartup) * time.Second)
+		cfg.lock.Lock()
+		cfg.startupOverload = false
+		sid := cfg.sid
+		cfg.lock.Unlock()
+		glog.Infof("Startup overload expired")
+		if sid != "" {
+			scheduleLocalLsp()
+		}
+	}()
+}
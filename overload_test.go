@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestOverloadSPF(t *testing.T) {
+	// TOPO: R1 -- 10 -- R2 -- 10 -- R3 with a direct R1 -- 30 -- R3 link
+	// With R2 overloaded R3 is reached over the direct link, R2 is still reached
+	initConfig()
+	updateDBInit()
+	r1sid, r2sid, r3sid := "1111.1111.1111", "1111.1111.1112", "1111.1111.1113"
+	adj := func(neighbor byte, intfName string, metric uint32) *Intf {
+		return &Intf{name: intfName, circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: metric, state: "UP",
+			neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, neighbor}, intfName: intfName}}}
+	}
+	r1Interfaces := []*Intf{adj(0x12, "eth0", 10), adj(0x13, "eth1", 30)}
+	lsps := make(map[string]*IsisLsp)
+	for sid, interfaces := range map[string][]*Intf{r1sid: r1Interfaces, r2sid: []*Intf{adj(0x11, "eth0", 10), adj(0x13, "eth1", 10)},
+		r3sid: []*Intf{adj(0x12, "eth0", 10), adj(0x11, "eth1", 30)}} {
+		lsps[sid] = buildEmptyLSP(LEVEL_1, 1, sid)
+		lsps[sid].CoreLsp.FirstTLV = getNeighborTLV(interfaces, LEVEL_1)
+		UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(sid), lsps[sid], false)
+	}
+	topoDB := &IsisDB{}
+	computeSPF(UpdateDB, topoDB, r1sid, r1Interfaces, MT_STANDARD)
+	if r3 := AvlSearch(topoDB.Root, systemIDToKey(r3sid)); r3 == nil || r3.(*Triple).distance != 20 {
+		t.Fatalf("Expected R3 through R2, got %v", r3)
+	}
+	lsps[r2sid].CoreLsp.LspHeader.PAttOLType |= OVERLOAD_BIT
+	topoDB = &IsisDB{}
+	computeSPF(UpdateDB, topoDB, r1sid, r1Interfaces, MT_STANDARD)
+	if r3 := AvlSearch(topoDB.Root, systemIDToKey(r3sid)); r3 == nil || r3.(*Triple).distance != 30 || r3.(*Triple).nextHops[0].intfName != "eth1" {
+		t.Fatalf("Expected R3 over the direct link, got %v", r3)
+	}
+	if r2 := AvlSearch(topoDB.Root, systemIDToKey(r2sid)); r2 == nil || r2.(*Triple).distance != 10 {
+		t.Fatalf("Expected R2 to still be reachable, got %v", r2)
+	}
+}
+
+func TestOverloadLsp(t *testing.T) {
+	initConfig()
+	cfg.sid = "1111.1111.1112"
+	cfg.interfaces = []*Intf{}
+	updateDBInit()
+	cfg.overload = true
+	generateLocalLsp()
+	if lsp := getLspFragments(UpdateDB, cfg.sid)[0]; !lspOverloaded(lsp) || lsp.CoreLsp.LspHeader.PAttOLType&0x03 != 0x01 {
+		t.Fatalf("Expected the overload bit, got 0x%x", lsp.CoreLsp.LspHeader.PAttOLType)
+	}
+	cfg.overload = false
+	generateLocalLsp()
+	if lspOverloaded(getLspFragments(UpdateDB, cfg.sid)[0]) {
+		t.Fail()
+	}
+	initConfig()
+}
@@ -0,0 +1,269 @@
+// Point-to-point circuits in the IS-IS protocol.
+// Adjacencies come UP through the three-way handshake (RFC 5303).
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/golang/glog"
+	"net"
+	"unsafe"
+)
+
+const (
+	P2P_IIH_PDU_TYPE  = 0x11
+	BROADCAST_CIRCUIT = "broadcast"
+	P2P_CIRCUIT       = "p2p"
+	// Three-way adjacency states as encoded in TLV 240
+	P2P_ADJ_STATE_UP   = 0x00
+	P2P_ADJ_STATE_INIT = 0x01
+	P2P_ADJ_STATE_DOWN = 0x02
+)
+
+type IsisP2PHelloHeader struct {
+	// Fields need to be exported for the binary encoding
+	CircuitType    byte
+	SourceSystemID [6]byte
+	HoldingTime    [2]byte
+	LengthPDU      [2]byte
+	LocalCircuitID byte
+}
+
+type IsisP2PHelloPDU struct {
+	Header         IsisPDUHeader
+	P2PHelloHeader IsisP2PHelloHeader
+	FirstTLV       *IsisTLV // Linked list of TLVs
+}
+
+func buildP2PHelloPDU(srcSystemID [6]byte, localCircuitID byte) *IsisP2PHelloPDU {
+	isis_pdu_header := IsisPDUHeader{IntraDomainRouteingProtocolDiscriminator: 0x83,
+		LengthPDU:            0x00,
+		ProtocolID:           0x01,
+		SystemIDLength:       0x00, // 0 means default 6 bytes
+		TypePDU:              P2P_IIH_PDU_TYPE,
+		Version:              0x01,
+		Reserved:             0x00,
+		MaximumAreaAddresses: 0x00} // 0 means default 3 addresses
+
+	isis_p2p_hello_header := IsisP2PHelloHeader{
+		CircuitType:    0x01, // 01 L1, 10 L2, 11 L1/L2
+		SourceSystemID: srcSystemID,
+		HoldingTime:    [2]byte{0x00, HOLDING_TIME}, // Same as the LAN hellos
+		LengthPDU:      [2]byte{0x00, 0x00},
+		LocalCircuitID: localCircuitID,
+	}
+	return &IsisP2PHelloPDU{Header: isis_pdu_header, P2PHelloHeader: isis_p2p_hello_header, FirstTLV: nil}
+}
+
+func serializeP2PHelloPDU(pdu *IsisP2PHelloPDU) []byte {
+	var buf bytes.Buffer
+	pdu.Header.LengthPDU = byte(unsafe.Sizeof(pdu.Header) + unsafe.Sizeof(pdu.P2PHelloHeader))
+	binary.BigEndian.PutUint16(pdu.P2PHelloHeader.LengthPDU[:], uint16(int(pdu.Header.LengthPDU)+getTLVsLength(pdu.FirstTLV)))
+	binary.Write(&buf, binary.BigEndian, pdu.Header)
+	binary.Write(&buf, binary.BigEndian, pdu.P2PHelloHeader)
+	serializeTLVs(&buf, pdu.FirstTLV)
+	return buf.Bytes()
+}
+
+func deserializeP2PHelloPDU(raw_bytes []byte) *IsisP2PHelloPDU {
+	// Skip the ethernet header, same as the LAN hellos
+	buf := bytes.NewBuffer(raw_bytes[14:])
+	var hello IsisP2PHelloPDU
+	binary.Read(buf, binary.BigEndian, &hello.Header)
+	binary.Read(buf, binary.BigEndian, &hello.P2PHelloHeader)
+	ethernetHeaderSize := 14
+	tlv_offset := ethernetHeaderSize + int(unsafe.Sizeof(hello.Header)) + int(unsafe.Sizeof(hello.P2PHelloHeader))
+	hello.FirstTLV = parseTLVs(raw_bytes, tlv_offset)
+	return &hello
+}
+
+func adjStateToP2PState(state string) byte {
+	if state == "UP" {
+		return P2P_ADJ_STATE_UP
+	} else if state == "INIT" {
+		return P2P_ADJ_STATE_INIT
+	}
+	return P2P_ADJ_STATE_DOWN
+}
+
+func getP2PAdjacency(intf *Intf) *Adjacency {
+	// Point-to-point circuits always have exactly one adjacency, which starts
+	// out as NEW. Requires the interface lock to be held
+	if len(intf.adjacencies) == 0 {
+		intf.adjacencies = append(intf.adjacencies, &Adjacency{state: "NEW", intfName: intf.name})
+	}
+	return intf.adjacencies[0]
+}
+
+func getP2PAdjTLV(intf *Intf) *IsisTLV {
+	// Three-way adjacency TLV 240, requires the interface lock to be held.
+	// 1 byte state, 4 byte extended local circuit ID and once we have heard from
+	// our neighbor, its 6 byte system ID and 4 byte extended local circuit ID
+	adj := getP2PAdjacency(intf)
+	var adjTLV IsisTLV
+	adjTLV.typeTLV = ISIS_P2P_ADJ_STATE_TLV
+	adjTLV.valueTLV = append(adjTLV.valueTLV, adjStateToP2PState(adj.state))
+	var circuitID [4]byte
+	binary.BigEndian.PutUint32(circuitID[:], intf.circuitID)
+	adjTLV.valueTLV = append(adjTLV.valueTLV, circuitID[:]...)
+	if adj.state != "NEW" {
+		binary.BigEndian.PutUint32(circuitID[:], adj.neighborCircuitID)
+		adjTLV.valueTLV = append(adjTLV.valueTLV, adj.neighborSystemID...)
+		adjTLV.valueTLV = append(adjTLV.valueTLV, circuitID[:]...)
+	}
+	adjTLV.lengthTLV = byte(len(adjTLV.valueTLV))
+	return &adjTLV
+}
+
+func sendP2PHello(intf *Intf, sid string, sendChan chan []byte) {
+	// Point-to-point hellos always carry the three-way TLV and our interface address.
+	// Requires the interface lock to be held
+	hello := buildP2PHelloPDU(systemIDToBytes(sid), byte(intf.circuitID))
+	hello.P2PHelloHeader.CircuitType = intf.level
+	key := getSendKey(intf.helloKeychain)
+	hello.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(key), getP2PAdjTLV(intf), getInterfaceTLV(intf), getAreaAddressesTLV(getAreas()), getIPv6HelloTLVs(intf), getHelloMTTLV(intf),
+		getRestartTLV(intf, LEVEL_1_2)})
+	padHello(intf, hello.FirstTLV, int(unsafe.Sizeof(hello.Header)+unsafe.Sizeof(hello.P2PHelloHeader)))
+	glog.V(2).Infof("Sending p2p hello on %s with three-way state %v", intf.name, getTLV(hello.FirstTLV, ISIS_P2P_ADJ_STATE_TLV).valueTLV)
+	sendChan <- buildEthernetFrame(l1_multicast,
+		getMac(intf.name),
+		signPDU(serializeP2PHelloPDU(hello), key))
+}
+
+func nextP2PAdjState(current string, neighborState byte) string {
+	// RFC 5303 section 3.2 state transition table
+	switch neighborState {
+	case P2P_ADJ_STATE_DOWN:
+		return "INIT"
+	case P2P_ADJ_STATE_INIT:
+		return "UP"
+	case P2P_ADJ_STATE_UP:
+		if current == "NEW" {
+			// Our neighbor thinks it's up but we haven't heard from it yet
+			return "NEW"
+		}
+		return "UP"
+	}
+	return current
+}
+
+func processP2PHello(intf *Intf, sid string, hello *IsisP2PHelloPDU) (string, string) {
+	// Run the three-way handshake for a received point-to-point hello
+	// Returns the adjacency state before and after
+	intf.lock.Lock()
+	defer intf.lock.Unlock()
+	adj := getP2PAdjacency(intf)
+	previous := adj.state
+	adjTLV := getTLV(hello.FirstTLV, ISIS_P2P_ADJ_STATE_TLV)
+	if adjTLV == nil || adjTLV.lengthTLV < 5 {
+		glog.Infof("P2P hello on %s without a three-way adjacency TLV, ignoring", intf.name)
+		return previous, previous
+	}
+	neighborSystemID := hello.P2PHelloHeader.SourceSystemID[:]
+	// Both levels share the one adjacency, which runs whatever levels we have in common
+	level := hello.P2PHelloHeader.CircuitType & intf.level
+	if level&LEVEL_1 != 0 && !checkAreas(intf, hello.FirstTLV) {
+		// Level 1 only runs within an area, level 2 can still come up
+		level &^= LEVEL_1
+	}
+	if level == 0 {
+		glog.Infof("P2P neighbor on %s has no level in common with us, adjacency down", intf.name)
+		adj.state = "NEW"
+		return previous, adj.state
+	}
+	if adj.state == "UP" && adj.level != level {
+		// Different set of levels, our LSPs need to change
+		glog.Infof("P2P neighbor on %s levels changed to %s", intf.name, levelToString(level))
+		adj.state = "NEW"
+	}
+	adj.level = level
+	topologies := getMTIDs(hello.FirstTLV)
+	if adj.state == "UP" && !topologiesEqual(adj.topologies, topologies) {
+		// Different set of topologies, our LSPs need to change
+		glog.Infof("P2P neighbor on %s topologies changed to %s", intf.name, mtIDsToString(topologies))
+		adj.state = "NEW"
+	}
+	adj.topologies = topologies
+	neighborState := adjTLV.valueTLV[0]
+	neighborCircuitID := binary.BigEndian.Uint32(adjTLV.valueTLV[1:5])
+	if adj.state != "NEW" && (!bytes.Equal(adj.neighborSystemID, neighborSystemID) ||
+		adj.neighborCircuitID != neighborCircuitID) {
+		// Someone else is on the other end now, start over
+		glog.Infof("P2P neighbor on %s changed to %s", intf.name, systemIDName(neighborSystemID))
+		adj.state = "NEW"
+	}
+	if hasRestartAck(hello.FirstTLV, sid) {
+		intf.restartAcked = true
+	}
+	adj.restartRequested = hasRestartRequest(hello.FirstTLV) && adj.state == "UP"
+	if adj.restartRequested {
+		// Our neighbor is restarting and no longer knows about the adjacency,
+		// keep it UP while the neighbor resynchronizes
+		setHoldTimer(adj, hello.P2PHelloHeader.HoldingTime)
+		return previous, adj.state
+	}
+	if adjTLV.lengthTLV >= 15 {
+		// Our neighbor has told us who it thinks it is talking to, it had better be us
+		ourSystemID := systemIDToBytes(sid)
+		if !bytes.Equal(adjTLV.valueTLV[5:11], ourSystemID[:]) || binary.BigEndian.Uint32(adjTLV.valueTLV[11:15]) != intf.circuitID {
+			glog.Infof("P2P neighbor on %s is adjacent to someone else, adjacency down", intf.name)
+			adj.state = "NEW"
+			return previous, adj.state
+		}
+	}
+	adj.neighborSystemID = make([]byte, 6)
+	copy(adj.neighborSystemID, neighborSystemID)
+	adj.neighborCircuitID = neighborCircuitID
+	setHoldTimer(adj, hello.P2PHelloHeader.HoldingTime)
+	adj.state = nextP2PAdjState(adj.state, neighborState)
+	if adj.state == "NEW" && neighborState == P2P_ADJ_STATE_UP && hasRestartAck(hello.FirstTLV, sid) {
+		// We are restarting and our neighbor kept the adjacency UP
+		adj.state = "UP"
+	}
+	if adj.state == "UP" {
+		adj.metric = getTopologyMetric(intf, MT_STANDARD)
+		if ipTLV := getTLV(hello.FirstTLV, ISIS_IP_INTF_ADDR_TLV); ipTLV != nil && ipTLV.lengthTLV >= 4 {
+			adj.neighborIP = make(net.IP, 4)
+			copy(adj.neighborIP, ipTLV.valueTLV[:4])
+		}
+		adj.neighborIPv6 = getHelloIPv6Address(hello.FirstTLV)
+	}
+	if previous != adj.state {
+		glog.Infof("P2P adjacency on %s with %s %s -> %s", intf.name, systemIDName(neighborSystemID), previous, adj.state)
+	}
+	return previous, adj.state
+}
+
+func isisP2PHelloRecv(intf *Intf, hello *IsisP2PHelloPDU, sendChan chan []byte) {
+	cfg.lock.Lock()
+	sid := cfg.sid
+	cfg.lock.Unlock()
+	previous, current := processP2PHello(intf, sid, hello)
+	if hasRestartRequest(hello.FirstTLV) {
+		helpRestart(intf, sid, sendChan)
+	}
+	if previous == current {
+		return
+	}
+	// Let our neighbor know about the state change straight away rather
+	// than waiting for the next hello interval
+	intf.lock.Lock()
+	sendP2PHello(intf, sid, sendChan)
+	intf.lock.Unlock()
+	if previous == "UP" || current == "UP" {
+		// Our neighbors have changed, regenerate and flood our lsp
+		scheduleLocalLsp()
+	}
+	if current == "UP" {
+		// Point-to-point links synchronize their databases with
+		// CSNPs when the adjacency comes up rather than periodically
+		intf.lock.Lock()
+		levels := getLevels(getP2PAdjacency(intf).level)
+		intf.lock.Unlock()
+		for _, level := range levels {
+			sendCsnps(intf, getUpdateDB(level), sid, sendChan)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+)
+
+func buildTestP2PHello(intf *Intf, sid string) *IsisP2PHelloPDU {
+	hello := buildP2PHelloPDU(systemIDToBytes(sid), byte(intf.circuitID))
+	hello.FirstTLV = getP2PAdjTLV(intf)
+	hello.FirstTLV.nextTLV = getAreaAddressesTLV(getAreas())
+	return hello
+}
+
+func TestP2PThreeWayHandshake(t *testing.T) {
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	// R2 hears R1 which reports down --> R2 initializing
+	if _, state := processP2PHello(r2Intf, r2sid, buildTestP2PHello(r1Intf, r1sid)); state != "INIT" {
+		t.Fatalf("Expected R2 INIT, got %s", state)
+	}
+	// R1 hears R2 which is initializing with R1 --> R1 up
+	if _, state := processP2PHello(r1Intf, r1sid, buildTestP2PHello(r2Intf, r2sid)); state != "UP" {
+		t.Fatalf("Expected R1 UP, got %s", state)
+	}
+	// R2 hears R1 which is up --> R2 up
+	if _, state := processP2PHello(r2Intf, r2sid, buildTestP2PHello(r1Intf, r1sid)); state != "UP" {
+		t.Fatalf("Expected R2 UP, got %s", state)
+	}
+	if systemIDToString(r2Intf.adjacencies[0].neighborSystemID) != r1sid || r2Intf.adjacencies[0].neighborCircuitID != 1 {
+		t.Fail()
+	}
+}
+
+func TestP2PNeighborAdjacentToOther(t *testing.T) {
+	// R1 claims to be adjacent with R3, so R2 must not bring the adjacency up
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1,
+		adjacencies: []*Adjacency{&Adjacency{state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x13}, neighborCircuitID: 2}}}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2, adjacencies: []*Adjacency{&Adjacency{state: "INIT"}}}
+	if _, state := processP2PHello(r2Intf, r2sid, buildTestP2PHello(r1Intf, r1sid)); state != "NEW" {
+		t.Fatalf("Expected R2 NEW, got %s", state)
+	}
+}
+
+func TestP2PHelloSerialize(t *testing.T) {
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 7, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	hello := buildTestP2PHello(intf, "1111.1111.1111")
+	frame := buildEthernetFrame([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x14}, []byte{0, 0, 0, 0, 0, 1}, serializeP2PHelloPDU(hello))
+	received := deserializeP2PHelloPDU(frame)
+	adjTLV := getTLV(received.FirstTLV, ISIS_P2P_ADJ_STATE_TLV)
+	if received.Header.TypePDU != P2P_IIH_PDU_TYPE || adjTLV == nil || adjTLV.lengthTLV != 5 ||
+		adjTLV.valueTLV[0] != P2P_ADJ_STATE_DOWN || received.P2PHelloHeader.LocalCircuitID != 7 {
+		t.Fail()
+	}
+}
+
+func TestP2PNoCommonLevel(t *testing.T) {
+	// R1 only runs level 1 and R2 only level 2, the adjacency must not come up
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_2, circuitID: 2}
+	if _, state := processP2PHello(r2Intf, "1111.1111.1112", buildTestP2PHello(r1Intf, "1111.1111.1111")); state != "NEW" {
+		t.Fatalf("Expected R2 NEW, got %s", state)
+	}
+	// With both levels on R2 the adjacency runs level 1 only
+	r2Intf.level = LEVEL_1_2
+	processP2PHello(r2Intf, "1111.1111.1112", buildTestP2PHello(r1Intf, "1111.1111.1111"))
+	if r2Intf.adjacencies[0].state != "INIT" || r2Intf.adjacencies[0].level != LEVEL_1 {
+		t.Fail()
+	}
+}
+
+func TestP2PAreaMismatch(t *testing.T) {
+	// A level 1 neighbor in another area is refused and counted, at level 1/2
+	// the adjacency still comes up for level 2
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1_2, circuitID: 1}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2}
+	hello := buildP2PHelloPDU(systemIDToBytes("1111.1111.1111"), 1)
+	hello.P2PHelloHeader.CircuitType = LEVEL_1_2
+	hello.FirstTLV = getP2PAdjTLV(r1Intf)
+	hello.FirstTLV.nextTLV = getAreaAddressesTLV([][]byte{[]byte{0x49, 0x00, 0x02}})
+	if _, state := processP2PHello(r2Intf, "1111.1111.1112", hello); state != "NEW" || r2Intf.areaMismatches != 1 {
+		t.Fatalf("Expected R2 NEW with 1 area mismatch, got %s with %d", state, r2Intf.areaMismatches)
+	}
+	r2Intf.level = LEVEL_1_2
+	if _, state := processP2PHello(r2Intf, "1111.1111.1112", hello); state != "INIT" || r2Intf.adjacencies[0].level != LEVEL_2 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,253 @@
+// Graceful restart in the IS-IS protocol (RFC 5306).
+// Keeps forwarding on the routes installed before a restart until our database is back in sync.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"github.com/golang/glog"
+	"sync"
+	"time"
+)
+
+const (
+	RESTART_REQUEST      = 0x01
+	RESTART_ACK          = 0x02
+	RESTART_T2           = 60   // Seconds we wait for our database to synchronize
+	RESTART_TIMER_PERIOD = 1000 // Milliseconds in between checking whether we are synchronized
+)
+
+var gracefulRestart = flag.Bool("graceful_restart", false, "Restart gracefully, keeping the routes of the previous instance while the LSP database resynchronizes")
+
+// The LSPs the CSNPs of each level described while restarting and their sequence numbers
+var restarting bool
+var restartWanted [2]map[uint64]uint32
+var restartLock sync.Mutex
+
+func startRestart() {
+	// Ask our neighbors to keep their adjacencies UP and send us their database.
+	// We neither originate LSPs nor run SPF until a CSNP has been received and
+	// every LSP it described has arrived on every interface, or RESTART_T2 runs out
+	restartLock.Lock()
+	defer restartLock.Unlock()
+	restarting = true
+	restartWanted = [2]map[uint64]uint32{make(map[uint64]uint32), make(map[uint64]uint32)}
+}
+
+func isRestarting() bool {
+	restartLock.Lock()
+	defer restartLock.Unlock()
+	return restarting
+}
+
+func getRestartTLV(intf *Intf, level byte) *IsisTLV {
+	// TLV 211 for our hellos at a level, nil if we are neither restarting nor
+	// helping a neighbor restart. Acknowledgements carry the remaining holding
+	// time of the neighbor and its system ID. Requires the interface lock to be held
+	var flags byte
+	if isRestarting() && !intf.restartAcked {
+		flags |= RESTART_REQUEST
+	}
+	var helped *Adjacency
+	for _, adj := range intf.adjacencies {
+		if adj.restartRequested && adj.level&level != 0 {
+			helped = adj
+			break
+		}
+	}
+	if flags == 0 && helped == nil {
+		return nil
+	}
+	tlv := &IsisTLV{typeTLV: ISIS_RESTART_TLV, valueTLV: []byte{flags}}
+	if helped != nil {
+		tlv.valueTLV[0] |= RESTART_ACK
+		var remaining [2]byte
+		if seconds := time.Until(helped.holdExpiry) / time.Second; seconds > 0 {
+			binary.BigEndian.PutUint16(remaining[:], uint16(seconds))
+		}
+		tlv.valueTLV = append(append(tlv.valueTLV, remaining[:]...), helped.neighborSystemID...)
+	}
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+	return tlv
+}
+
+func hasRestartRequest(firstTLV *IsisTLV) bool {
+	tlv := getTLV(firstTLV, ISIS_RESTART_TLV)
+	return tlv != nil && tlv.lengthTLV >= 1 && tlv.valueTLV[0]&RESTART_REQUEST != 0
+}
+
+func hasRestartAck(firstTLV *IsisTLV, sid string) bool {
+	// Whether a hello acknowledges our restart request, the
+	// system ID is optional but has to be ours if present
+	tlv := getTLV(firstTLV, ISIS_RESTART_TLV)
+	if tlv == nil || tlv.lengthTLV < 1 || tlv.valueTLV[0]&RESTART_ACK == 0 {
+		return false
+	}
+	ourSystemID := systemIDToBytes(sid)
+	return tlv.lengthTLV < 9 || bytes.Equal(tlv.valueTLV[3:9], ourSystemID[:])
+}
+
+func helpRestart(intf *Intf, sid string, sendChan chan []byte) {
+	// A neighbor with an UP adjacency asked to restart. Acknowledge it straight
+	// away and describe our whole database to it, on point-to-point circuits
+	// we flood every LSP to it too. On LANs the DIS sends the CSNPs
+	intf.lock.Lock()
+	var levels byte
+	for _, adj := range intf.adjacencies {
+		if adj.restartRequested {
+			levels |= adj.level
+		}
+	}
+	if levels == 0 {
+		intf.lock.Unlock()
+		return
+	}
+	glog.Infof("Helping our %s neighbor on %s restart", levelToString(levels), intf.name)
+	p2p := intf.circuitType == P2P_CIRCUIT
+	csnpLevels := make([]byte, 0)
+	for _, level := range getLevels(levels) {
+		if p2p {
+			sendP2PHello(intf, sid, sendChan)
+			csnpLevels = append(csnpLevels, level)
+		} else {
+			sendHello(intf, level, sid, sendChan)
+			if isDIS(intf, level, sid) {
+				csnpLevels = append(csnpLevels, level)
+			}
+		}
+	}
+	intf.lock.Unlock()
+	for _, level := range csnpLevels {
+		db := getUpdateDB(level)
+		sendCsnps(intf, db, sid, sendChan)
+		if p2p {
+			db.DBLock.Lock()
+			intf.lock.Lock()
+			for _, node := range AvlGetAll(db.Root) {
+				setFloodFlags(intf, level, node.data.(*IsisLsp).LspID, true, false)
+			}
+			intf.lock.Unlock()
+			db.DBLock.Unlock()
+		}
+	}
+}
+
+func noteRestartCsnp(intf *Intf, level byte, csnp *IsisCsnpPDU) {
+	// Remember what a CSNP received while restarting described, the last
+	// CSNP of a set reaches the end of the LSP ID space
+	restartLock.Lock()
+	if !restarting {
+		restartLock.Unlock()
+		return
+	}
+	for _, entry := range getLspEntries(csnp.FirstTLV) {
+		key := lspIDToKey(entry.LspID)
+		if seq := binary.BigEndian.Uint32(entry.SequenceNumber[:]); seq > restartWanted[level-1][key] {
+			restartWanted[level-1][key] = seq
+		}
+	}
+	restartLock.Unlock()
+	if csnp.CsnpHeader.EndLspID == [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff} {
+		intf.lock.Lock()
+		if !intf.restartCsnp[level-1] {
+			glog.Infof("Got a complete %s CSNP set on %s while restarting", levelToString(level), intf.name)
+		}
+		intf.restartCsnp[level-1] = true
+		intf.lock.Unlock()
+	}
+}
+
+func noteOwnLsp(lsp *IsisLsp) {
+	// One of our LSPs from before the restart, the LSPs we generate once
+	// synchronized have to be newer and replace all of its fragments
+	level := byte(LEVEL_1)
+	if lsp.CoreLsp.Header.TypePDU == L2_LSP_PDU_TYPE {
+		level = LEVEL_2
+	}
+	if seq := binary.BigEndian.Uint32(lsp.CoreLsp.LspHeader.SequenceNumber[:]); seq > sequenceNumber[level-1] {
+		sequenceNumber[level-1] = seq
+	}
+	if int(lsp.LspID[7]) >= fragmentCount[level-1] {
+		fragmentCount[level-1] = int(lsp.LspID[7]) + 1
+	}
+}
+
+func isRestartSynchronized(interfaces []*Intf, levels byte) bool {
+	// Whether every interface with an UP adjacency has acknowledged our restart
+	// and sent a complete set of CSNPs, and we have every LSP they described
+	upAdjacencies := false
+	for _, intf := range interfaces {
+		intf.lock.Lock()
+		for _, level := range getLevels(levels & intf.level) {
+			if hasUpAdjacency(intf, level) {
+				upAdjacencies = true
+				if !intf.restartAcked || !intf.restartCsnp[level-1] {
+					intf.lock.Unlock()
+					return false
+				}
+			}
+		}
+		intf.lock.Unlock()
+	}
+	if !upAdjacencies {
+		return false
+	}
+	for _, level := range getLevels(levels) {
+		restartLock.Lock()
+		wanted := make(map[uint64]uint32)
+		for key, seq := range restartWanted[level-1] {
+			wanted[key] = seq
+		}
+		restartLock.Unlock()
+		db := getUpdateDB(level)
+		db.DBLock.Lock()
+		for key, seq := range wanted {
+			tmp := AvlSearch(db.Root, key)
+			if tmp == nil || binary.BigEndian.Uint32(tmp.(*IsisLsp).CoreLsp.LspHeader.SequenceNumber[:]) < seq {
+				db.DBLock.Unlock()
+				return false
+			}
+		}
+		db.DBLock.Unlock()
+	}
+	return true
+}
+
+func finishRestart(triggerSPF chan bool) {
+	restartLock.Lock()
+	restarting = false
+	restartWanted = [2]map[uint64]uint32{}
+	restartLock.Unlock()
+	scheduleLocalLsp()
+	scheduleSPF(triggerSPF)
+}
+
+func isisRestartTimer(triggerSPF chan bool) {
+	// Once a system ID is configured, wait for our database to synchronize
+	// or RESTART_T2 to run out before originating LSPs and running SPF
+	var deadline time.Time
+	for isRestarting() {
+		time.Sleep(RESTART_TIMER_PERIOD * time.Millisecond)
+		cfg.lock.Lock()
+		sid := cfg.sid
+		level := cfg.level
+		interfaces := cfg.interfaces
+		cfg.lock.Unlock()
+		if sid == "" {
+			continue
+		}
+		if deadline.IsZero() {
+			deadline = time.Now().Add(RESTART_T2 * time.Second)
+		}
+		if isRestartSynchronized(interfaces, level) {
+			glog.Infof("LSP database synchronized, restart complete")
+			finishRestart(triggerSPF)
+		} else if time.Now().After(deadline) {
+			glog.Infof("LSP database not synchronized after %d seconds, completing restart anyway", RESTART_T2)
+			finishRestart(triggerSPF)
+		}
+	}
+}
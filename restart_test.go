@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestRestartP2PHelper(t *testing.T) {
+	// R1 and R2 are UP, then R1 restarts and asks R2 to keep the adjacency
+	r1sid, r2sid := "1111.1111.1111", "1111.1111.1112"
+	r1Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	r2Intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 2, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	processP2PHello(r2Intf, r2sid, buildTestP2PHello(r1Intf, r1sid))
+	processP2PHello(r1Intf, r1sid, buildTestP2PHello(r2Intf, r2sid))
+	processP2PHello(r2Intf, r2sid, buildTestP2PHello(r1Intf, r1sid))
+	r1Restarted := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, circuitID: 1, adjacencies: []*Adjacency{&Adjacency{state: "NEW"}}}
+	startRestart()
+	hello := buildTestP2PHello(r1Restarted, r1sid)
+	hello.FirstTLV = linkTLVs([]*IsisTLV{hello.FirstTLV, getRestartTLV(r1Restarted, LEVEL_1_2)})
+	restarting = false
+	if _, state := processP2PHello(r2Intf, r2sid, hello); state != "UP" || !r2Intf.adjacencies[0].restartRequested {
+		t.Fatalf("Expected R2 to stay UP and help, got %s", state)
+	}
+	// R2 acknowledges the request and R1 comes straight back UP
+	ack := getRestartTLV(r2Intf, LEVEL_1)
+	if ack == nil || ack.valueTLV[0] != RESTART_ACK || !hasRestartAck(ack, r1sid) || hasRestartAck(ack, r2sid) {
+		t.Fatalf("Expected an acknowledgement for R1, got %v", ack)
+	}
+	startRestart()
+	hello = buildTestP2PHello(r2Intf, r2sid)
+	hello.FirstTLV = linkTLVs([]*IsisTLV{hello.FirstTLV, ack})
+	if _, state := processP2PHello(r1Restarted, r1sid, hello); state != "UP" || !r1Restarted.restartAcked {
+		t.Fatalf("Expected R1 UP, got %s", state)
+	}
+	if getRestartTLV(r1Restarted, LEVEL_1) != nil {
+		t.Fatalf("Expected R1 to stop requesting a restart")
+	}
+	restarting = false
+}
+
+func TestRestartSynchronized(t *testing.T) {
+	updateDBInit()
+	startRestart()
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1,
+		adjacencies: []*Adjacency{&Adjacency{state: "UP", level: LEVEL_1, neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}}}}
+	if isRestartSynchronized([]*Intf{intf}, LEVEL_1) {
+		t.Fatalf("Expected to wait for an acknowledgement and CSNPs")
+	}
+	// Our neighbor describes its database with one LSP
+	neighborDB := &IsisDB{Level: LEVEL_1}
+	lsp := buildEmptyLSP(LEVEL_1, 5, "1111.1111.1112")
+	neighborDB.Root = AvlInsert(neighborDB.Root, lspIDToKey(lsp.LspID), lsp, false)
+	intf.restartAcked = true
+	noteRestartCsnp(intf, LEVEL_1, buildCsnps(neighborDB, "1111.1111.1112")[0])
+	if !intf.restartCsnp[0] || isRestartSynchronized([]*Intf{intf}, LEVEL_1) {
+		t.Fatalf("Expected to wait for the LSP")
+	}
+	UpdateDB.Root = AvlInsert(UpdateDB.Root, lspIDToKey(lsp.LspID), lsp, false)
+	if !isRestartSynchronized([]*Intf{intf}, LEVEL_1) {
+		t.Fail()
+	}
+	restarting = false
+}
+
+func TestRestartSuppressesLsps(t *testing.T) {
+	initConfig()
+	cfg.sid = "1111.1111.1112"
+	cfg.interfaces = []*Intf{}
+	updateDBInit()
+	startRestart()
+	generateLocalLsp()
+	if len(getLspFragments(UpdateDB, cfg.sid)) != 0 {
+		t.Fatalf("Expected no LSPs while restarting")
+	}
+	// Our LSP from before the restart comes back from a neighbor
+	noteOwnLsp(buildEmptyLSP(LEVEL_1, 10, cfg.sid))
+	restarting = false
+	generateLocalLsp()
+	fragments := getLspFragments(UpdateDB, cfg.sid)
+	if len(fragments) == 0 || binary.BigEndian.Uint32(fragments[0].CoreLsp.LspHeader.SequenceNumber[:]) <= 10 {
+		t.Fatalf("Expected our LSP to replace the one from before the restart")
+	}
+	initConfig()
+}
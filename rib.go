@@ -0,0 +1,330 @@
+// The IS-IS routing information base.
+// Picks the best routes SPF found and reconciles them with the kernel through netlink.
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"net"
+	"strconv"
+	"sync"
+)
+
+const (
+	RTPROT_ISIS = 187 // As in /etc/iproute2/rt_protos
+)
+
+var retainRoutes = flag.Bool("retain_routes", false, "Leave our routes in the kernel on exit, to keep forwarding through a graceful restart")
+
+type RibNextHop struct {
+	ip        net.IP
+	linkIndex int
+	labels    []int // Pushed onto an IP route, or swapped to on an MPLS route
+}
+
+type RibRoute struct {
+	prefix   *net.IPNet // Destination of an IPv4 or IPv6 route, nil for an MPLS route
+	label    int        // Incoming label of an MPLS route
+	nextHops []*RibNextHop
+	distance uint32
+}
+
+func (r RibRoute) String() string {
+	route := r.key()
+	for _, nextHop := range r.nextHops {
+		route += " via " + nextHop.ip.String()
+		if len(nextHop.labels) != 0 {
+			route += fmt.Sprintf(" labels %v", nextHop.labels)
+		}
+	}
+	return route + fmt.Sprintf(" distance %d", r.distance)
+}
+
+type ribSource struct {
+	level byte
+	mtID  uint16
+}
+
+// The routes the last SPF of each level and topology found and the routes
+// we have in the kernel, keyed by destination
+var ribRoutes = make(map[ribSource]map[string]*RibRoute)
+var installedRoutes = make(map[string]*RibRoute)
+var ribLock sync.Mutex
+
+func (r *RibRoute) key() string {
+	if r.prefix == nil {
+		return "label " + strconv.Itoa(r.label)
+	}
+	return r.prefix.String()
+}
+
+func sameNextHop(a *RibNextHop, b *RibNextHop) bool {
+	if !a.ip.Equal(b.ip) || a.linkIndex != b.linkIndex || len(a.labels) != len(b.labels) {
+		return false
+	}
+	for i := range a.labels {
+		if a.labels[i] != b.labels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasNextHop(nextHops []*RibNextHop, nextHop *RibNextHop) bool {
+	for _, n := range nextHops {
+		if n.ip.Equal(nextHop.ip) && n.linkIndex == nextHop.linkIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func sameRoute(a *RibRoute, b *RibRoute) bool {
+	// Whether two routes to the same destination forward the same way, in
+	// whatever order their next hops are
+	if len(a.nextHops) != len(b.nextHops) {
+		return false
+	}
+	for _, x := range a.nextHops {
+		found := false
+		for _, y := range b.nextHops {
+			found = found || sameNextHop(x, y)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *RibNextHop) via() *netlink.Via {
+	if n.ip.To4() == nil {
+		return &netlink.Via{AddrFamily: netlink.FAMILY_V6, Addr: n.ip}
+	}
+	return &netlink.Via{AddrFamily: netlink.FAMILY_V4, Addr: n.ip}
+}
+
+func (r *RibRoute) toNetlink() *netlink.Route {
+	// A route with several next hops is a multipath route
+	route := &netlink.Route{Protocol: RTPROT_ISIS}
+	if r.prefix == nil {
+		label := r.label
+		route.Family = netlink.FAMILY_MPLS
+		route.MPLSDst = &label
+	} else {
+		route.Dst = r.prefix
+	}
+	if len(r.nextHops) == 1 {
+		nextHop := r.nextHops[0]
+		route.LinkIndex = nextHop.linkIndex
+		if r.prefix == nil {
+			route.Via = nextHop.via()
+			if len(nextHop.labels) != 0 {
+				route.NewDst = &netlink.MPLSDestination{Labels: nextHop.labels}
+			}
+		} else {
+			route.Gw = nextHop.ip
+			if len(nextHop.labels) != 0 {
+				route.Encap = &netlink.MPLSEncap{Labels: nextHop.labels}
+			}
+		}
+		return route
+	}
+	for _, nextHop := range r.nextHops {
+		info := &netlink.NexthopInfo{LinkIndex: nextHop.linkIndex}
+		if r.prefix == nil {
+			info.Via = nextHop.via()
+			if len(nextHop.labels) != 0 {
+				info.NewDst = &netlink.MPLSDestination{Labels: nextHop.labels}
+			}
+		} else {
+			info.Gw = nextHop.ip
+			if len(nextHop.labels) != 0 {
+				info.Encap = &netlink.MPLSEncap{Labels: nextHop.labels}
+			}
+		}
+		route.MultiPath = append(route.MultiPath, info)
+	}
+	return route
+}
+
+func nextHopFromNetlink(gw net.IP, via netlink.Destination, linkIndex int, newDst netlink.Destination, encap netlink.Encap) *RibNextHop {
+	nextHop := &RibNextHop{ip: gw, linkIndex: linkIndex}
+	if v, ok := via.(*netlink.Via); ok {
+		nextHop.ip = v.Addr
+	}
+	if dst, ok := newDst.(*netlink.MPLSDestination); ok {
+		nextHop.labels = dst.Labels
+	}
+	if e, ok := encap.(*netlink.MPLSEncap); ok {
+		nextHop.labels = e.Labels
+	}
+	return nextHop
+}
+
+func routeFromNetlink(route netlink.Route, family int) *RibRoute {
+	// One of our routes as listed by the kernel
+	r := &RibRoute{prefix: route.Dst}
+	if family == netlink.FAMILY_MPLS && route.MPLSDst != nil {
+		r.prefix = nil
+		r.label = *route.MPLSDst
+	} else if r.prefix == nil {
+		// The default route is listed without a destination
+		r.prefix = &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+		if family == netlink.FAMILY_V6 {
+			r.prefix = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+		}
+	}
+	if len(route.MultiPath) == 0 {
+		r.nextHops = []*RibNextHop{nextHopFromNetlink(route.Gw, route.Via, route.LinkIndex, route.NewDst, route.Encap)}
+	}
+	for _, info := range route.MultiPath {
+		r.nextHops = append(r.nextHops, nextHopFromNetlink(info.Gw, info.Via, info.LinkIndex, info.NewDst, info.Encap))
+	}
+	return r
+}
+
+func addRibRoute(routes map[string]*RibRoute, route *RibRoute) {
+	// Add a route found by SPF unless there already is a shorter one. The next
+	// hops of routes as short are combined, up to the maximum paths
+	existing, ok := routes[route.key()]
+	if ok && existing.distance < route.distance {
+		return
+	}
+	if ok && existing.distance == route.distance {
+		nextHops := append(make([]*RibNextHop, 0, len(existing.nextHops)+len(route.nextHops)), existing.nextHops...)
+		for _, nextHop := range route.nextHops {
+			if len(nextHops) < getMaxPaths() && !hasNextHop(nextHops, nextHop) {
+				nextHops = append(nextHops, nextHop)
+			}
+		}
+		route = &RibRoute{prefix: route.prefix, label: route.label, nextHops: nextHops, distance: route.distance}
+	}
+	routes[route.key()] = route
+}
+
+func setRibRoutes(level byte, mtID uint16, routes map[string]*RibRoute) {
+	ribLock.Lock()
+	defer ribLock.Unlock()
+	ribRoutes[ribSource{level: level, mtID: mtID}] = routes
+}
+
+func selectRibRoutes(levels byte, topologies []uint16) map[string]*RibRoute {
+	// The best route to each destination from the levels and topologies still
+	// running, forgetting the others. Level 1 routes are preferred over level 2
+	// and otherwise the shortest wins. Requires the RIB lock to be held
+	for source := range ribRoutes {
+		if !hasTopology(topologies, source.mtID) || source.level&levels == 0 {
+			delete(ribRoutes, source)
+		}
+	}
+	selected := make(map[string]*RibRoute)
+	for _, level := range getLevels(levels) {
+		levelRoutes := make(map[string]*RibRoute)
+		for _, mtID := range topologies {
+			for _, route := range ribRoutes[ribSource{level: level, mtID: mtID}] {
+				addRibRoute(levelRoutes, route)
+			}
+		}
+		for key, route := range levelRoutes {
+			if _, ok := selected[key]; !ok {
+				selected[key] = route
+			}
+		}
+	}
+	return selected
+}
+
+func getRibChanges(installed map[string]*RibRoute, wanted map[string]*RibRoute) ([]*RibRoute, []*RibRoute) {
+	// The routes to add or replace and the routes to delete to get from the
+	// installed routes to the wanted ones
+	replace := make([]*RibRoute, 0)
+	remove := make([]*RibRoute, 0)
+	for key, route := range wanted {
+		if existing, ok := installed[key]; !ok || !sameRoute(existing, route) {
+			replace = append(replace, route)
+		}
+	}
+	for key, route := range installed {
+		if _, ok := wanted[key]; !ok {
+			remove = append(remove, route)
+		}
+	}
+	return replace, remove
+}
+
+func applyRib(wanted map[string]*RibRoute) {
+	// Program the differences into the kernel. A route which failed to be
+	// added is tried again the next time. Requires the RIB lock to be held
+	replace, remove := getRibChanges(installedRoutes, wanted)
+	for _, route := range remove {
+		glog.V(1).Infof("RIB: Deleting %v", route)
+		if err := netlink.RouteDel(route.toNetlink()); err != nil {
+			glog.Errorf("Error deleting route %v: %v", route, err)
+		}
+		delete(installedRoutes, route.key())
+	}
+	for _, route := range replace {
+		glog.V(1).Infof("RIB: Installing %v", route)
+		if err := netlink.RouteReplace(route.toNetlink()); err != nil {
+			glog.Errorf("Error adding route %v: %v", route, err)
+			continue
+		}
+		installedRoutes[route.key()] = route
+	}
+}
+
+func syncRib(levels byte, topologies []uint16) {
+	ribLock.Lock()
+	defer ribLock.Unlock()
+	applyRib(selectRibRoutes(levels, topologies))
+}
+
+func flushRib() {
+	// Withdraw all our routes
+	ribLock.Lock()
+	defer ribLock.Unlock()
+	ribRoutes = make(map[ribSource]map[string]*RibRoute)
+	applyRib(make(map[string]*RibRoute))
+}
+
+func getConnectedPrefixes(routes []netlink.Route) []*net.IPNet {
+	// The prefixes of the routes the kernel added for the addresses of a link.
+	// Routes from anywhere else, ours left by a previous instance in particular,
+	// are not ours to advertise as directly connected
+	prefixes := make([]*net.IPNet, 0)
+	for _, route := range routes {
+		if route.Dst != nil && route.Protocol == unix.RTPROT_KERNEL {
+			prefixes = append(prefixes, route.Dst)
+		}
+	}
+	return prefixes
+}
+
+func ribInit() {
+	// Pick up the routes a previous instance left in the kernel. They are
+	// flushed now, or kept while gracefully restarting until SPF replaces them
+	ribLock.Lock()
+	filter := &netlink.Route{Protocol: RTPROT_ISIS}
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6, netlink.FAMILY_MPLS} {
+		routes, err := netlink.RouteListFiltered(family, filter, netlink.RT_FILTER_PROTOCOL)
+		if err != nil {
+			glog.V(1).Infof("Unable to list routes of family %d: %v", family, err)
+			continue
+		}
+		for _, route := range routes {
+			r := routeFromNetlink(route, family)
+			installedRoutes[r.key()] = r
+		}
+	}
+	glog.Infof("Found %d routes from a previous instance", len(installedRoutes))
+	ribLock.Unlock()
+	if !isRestarting() {
+		flushRib()
+	}
+}
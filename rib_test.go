@@ -0,0 +1,117 @@
+package main
+
+import (
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"net"
+	"testing"
+)
+
+func buildRibRoute(prefix string, nextHop string, distance uint32) *RibRoute {
+	_, dst, _ := net.ParseCIDR(prefix)
+	return &RibRoute{prefix: dst, nextHops: []*RibNextHop{&RibNextHop{ip: net.ParseIP(nextHop)}}, distance: distance}
+}
+
+func TestRibChanges(t *testing.T) {
+	installed := make(map[string]*RibRoute)
+	for _, route := range []*RibRoute{buildRibRoute("10.0.0.0/24", "172.18.0.1", 10), buildRibRoute("10.0.1.0/24", "172.18.0.1", 10)} {
+		installed[route.key()] = route
+	}
+	// A new metric alone leaves the kernel route alone, a new next hop replaces it
+	wanted := make(map[string]*RibRoute)
+	for _, route := range []*RibRoute{buildRibRoute("10.0.0.0/24", "172.18.0.1", 20), buildRibRoute("10.0.1.0/24", "172.18.0.2", 10),
+		buildRibRoute("10.0.2.0/24", "172.18.0.1", 10)} {
+		wanted[route.key()] = route
+	}
+	replace, remove := getRibChanges(installed, wanted)
+	if len(replace) != 2 || len(remove) != 0 {
+		t.Fatalf("Unexpected changes %v %v", replace, remove)
+	}
+	for _, route := range replace {
+		if route.key() == "10.0.0.0/24" {
+			t.Fatalf("Unexpected replace of %v", route)
+		}
+	}
+	// A prefix which is gone is withdrawn
+	delete(wanted, "10.0.0.0/24")
+	if _, remove = getRibChanges(installed, wanted); len(remove) != 1 || remove[0].key() != "10.0.0.0/24" {
+		t.Fatalf("Expected 10.0.0.0/24 to be withdrawn, got %v", remove)
+	}
+}
+
+func TestRibSelection(t *testing.T) {
+	initConfig()
+	l1 := make(map[string]*RibRoute)
+	addRibRoute(l1, buildRibRoute("10.0.0.0/24", "172.18.0.1", 30))
+	addRibRoute(l1, buildRibRoute("10.0.0.0/24", "172.18.0.3", 40))
+	l2 := make(map[string]*RibRoute)
+	addRibRoute(l2, buildRibRoute("10.0.0.0/24", "172.18.0.2", 10))
+	addRibRoute(l2, buildRibRoute("10.0.2.0/24", "172.18.0.2", 10))
+	setRibRoutes(LEVEL_1, MT_STANDARD, l1)
+	setRibRoutes(LEVEL_2, MT_STANDARD, l2)
+	ribLock.Lock()
+	defer ribLock.Unlock()
+	// Level 1 wins over a shorter level 2 route
+	selected := selectRibRoutes(LEVEL_1_2, getTopologies())
+	if len(selected) != 2 || selected["10.0.0.0/24"].nextHops[0].ip.String() != "172.18.0.1" {
+		t.Fatalf("Unexpected routes %v", selected)
+	}
+	// Once level 1 stops the level 2 route takes over
+	selected = selectRibRoutes(LEVEL_2, getTopologies())
+	if len(selected) != 2 || selected["10.0.0.0/24"].nextHops[0].ip.String() != "172.18.0.2" || len(ribRoutes) != 1 {
+		t.Fatalf("Unexpected routes %v", selected)
+	}
+	ribRoutes = make(map[ribSource]map[string]*RibRoute)
+}
+
+func TestRibNetlinkRoute(t *testing.T) {
+	// Our routes come back the same when listed from the kernel
+	labeled := buildRibRoute("10.0.0.1/32", "172.18.0.1", 10)
+	labeled.nextHops[0].labels = []int{16001}
+	mpls := &RibRoute{label: 16001, nextHops: []*RibNextHop{&RibNextHop{ip: net.ParseIP("172.18.0.1"), linkIndex: 2, labels: []int{20001}}}}
+	for _, route := range []*RibRoute{labeled, mpls} {
+		family := netlink.FAMILY_V4
+		if route.prefix == nil {
+			family = netlink.FAMILY_MPLS
+		}
+		kernel := route.toNetlink()
+		if kernel.Protocol != RTPROT_ISIS {
+			t.Fatalf("Expected the IS-IS protocol, got %d", kernel.Protocol)
+		}
+		if listed := routeFromNetlink(*kernel, family); listed.key() != route.key() || !sameRoute(listed, route) {
+			t.Fatalf("Expected %v, got %v", route, listed)
+		}
+	}
+}
+
+func TestRibMultipath(t *testing.T) {
+	// Equal cost routes to the same prefix are combined
+	initConfig()
+	routes := make(map[string]*RibRoute)
+	addRibRoute(routes, buildRibRoute("10.0.0.0/24", "172.18.0.1", 10))
+	addRibRoute(routes, buildRibRoute("10.0.0.0/24", "172.18.0.2", 10))
+	addRibRoute(routes, buildRibRoute("10.0.0.0/24", "172.18.0.2", 10))
+	addRibRoute(routes, buildRibRoute("10.0.0.0/24", "172.18.0.3", 20))
+	route := routes["10.0.0.0/24"]
+	if len(route.nextHops) != 2 {
+		t.Fatalf("Expected two next hops, got %v", route)
+	}
+	listed := routeFromNetlink(*route.toNetlink(), netlink.FAMILY_V4)
+	listed.nextHops[0], listed.nextHops[1] = listed.nextHops[1], listed.nextHops[0]
+	if !sameRoute(listed, route) {
+		t.Fatalf("Expected %v, got %v", route, listed)
+	}
+}
+
+func TestConnectedPrefixes(t *testing.T) {
+	// Routes kept in the kernel through a graceful restart are not picked up as
+	// our own connected prefixes
+	_, connected, _ := net.ParseCIDR("172.18.0.0/16")
+	retained := buildRibRoute("10.0.1.0/24", "172.18.0.2", 20)
+	retained.nextHops[0].linkIndex = 2
+	routes := []netlink.Route{netlink.Route{LinkIndex: 2, Dst: connected, Protocol: unix.RTPROT_KERNEL}, *retained.toNetlink(),
+		netlink.Route{LinkIndex: 2, Gw: net.ParseIP("172.18.0.1"), Protocol: unix.RTPROT_BOOT}}
+	if prefixes := getConnectedPrefixes(routes); len(prefixes) != 1 || prefixes[0].String() != "172.18.0.0/16" {
+		t.Fatalf("Expected only 172.18.0.0/16, got %v", prefixes)
+	}
+}
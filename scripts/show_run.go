@@ -24,6 +24,16 @@ func main() {
 		fmt.Printf("Unable to get state: %v", err)
 	}
 	fmt.Println("System ID:", showSystemID.Sid)
+	fmt.Println("Hostname:", showSystemID.Hostname)
+	fmt.Println("Areas:", strings.Join(showSystemID.Area, " "))
+	fmt.Println("Metric style:", showSystemID.MetricStyle)
+	fmt.Println("Overload:", showSystemID.Overload)
+	if showSystemID.Srgb != "" {
+		fmt.Println("SRGB:", showSystemID.Srgb)
+	}
+	fmt.Println("Maximum paths:", showSystemID.MaxPaths)
+	fmt.Println("SPF throttle:", showSystemID.SpfThrottle)
+	fmt.Println("LSP throttle:", showSystemID.LspThrottle)
 	showIntf, err := c.GetIntf(context.Background(), &pb.IntfRequest{ShIntf: ""})
 	if err != nil {
 		fmt.Printf("Unable to get state: %v", err)
@@ -49,4 +59,12 @@ func main() {
 	for _, topo := range showTopo.Topo {
 		fmt.Println("LSP:", topo)
 	}
+	// Only running with multi-topology enabled
+	showTopo, err = c.GetTopo(context.Background(), &pb.TopoRequest{ShTopo: "", MtID: 2})
+	if err == nil {
+		fmt.Println("IPV6 TOPO:")
+		for _, topo := range showTopo.Topo {
+			fmt.Println("LSP:", topo)
+		}
+	}
 }
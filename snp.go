@@ -0,0 +1,403 @@
+// Sequence number PDUs in the IS-IS protocol.
+// CSNPs describe the whole LSP database and PSNPs request or acknowledge specific LSPs.
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/golang/glog"
+	"time"
+	"unsafe"
+)
+
+const (
+	L1_CSNP_PDU_TYPE  = 0x18
+	L2_CSNP_PDU_TYPE  = 0x19
+	L1_PSNP_PDU_TYPE  = 0x1A
+	L2_PSNP_PDU_TYPE  = 0x1B
+	CSNP_INTERVAL     = 10000 // Milliseconds in between CSNPs
+	LSP_ENTRY_SIZE    = 16    // Remaining lifetime (2) + LSP ID (8) + sequence number (4) + checksum (2)
+	MAX_TLV_ENTRIES   = 15    // 255 / LSP_ENTRY_SIZE
+	MAX_SNP_TLVS      = 3     // Keeps a SNP well below READ_BUF_SIZE
+	MAX_SNP_LSP_ENTRY = MAX_TLV_ENTRIES * MAX_SNP_TLVS
+)
+
+type IsisCsnpHeader struct {
+	LengthPDU  [2]byte
+	SourceID   [7]byte // System ID + circuit ID
+	StartLspID [8]byte
+	EndLspID   [8]byte
+}
+
+type IsisCsnpPDU struct {
+	Header     IsisPDUHeader
+	CsnpHeader IsisCsnpHeader
+	FirstTLV   *IsisTLV
+}
+
+type IsisPsnpHeader struct {
+	LengthPDU [2]byte
+	SourceID  [7]byte // System ID + circuit ID
+}
+
+type IsisPsnpPDU struct {
+	Header     IsisPDUHeader
+	PsnpHeader IsisPsnpHeader
+	FirstTLV   *IsisTLV
+}
+
+type LspEntry struct {
+	// Fields need to be exported for the binary encoding
+	RemainingLifetime [2]byte
+	LspID             [8]byte
+	SequenceNumber    [4]byte
+	Checksum          [2]byte
+}
+
+func buildSnpHeader(pduType byte) IsisPDUHeader {
+	return IsisPDUHeader{IntraDomainRouteingProtocolDiscriminator: 0x83,
+		LengthPDU:            0x00,
+		ProtocolID:           0x01,
+		SystemIDLength:       0x00, // 0 means default 6 bytes
+		TypePDU:              pduType,
+		Version:              0x01,
+		Reserved:             0x00,
+		MaximumAreaAddresses: 0x00} // 0 means default 3 addresses
+}
+
+func getLspEntry(lsp *IsisLsp) LspEntry {
+	return LspEntry{RemainingLifetime: lsp.CoreLsp.LspHeader.RemainingLifetime,
+		LspID:          lsp.LspID,
+		SequenceNumber: lsp.CoreLsp.LspHeader.SequenceNumber,
+		Checksum:       lsp.CoreLsp.LspHeader.Checksum}
+}
+
+func getLspEntriesTLVs(entries []LspEntry) *IsisTLV {
+	// Pack the LSP entries into as many TLV 9s as required, each
+	// TLV can hold at most MAX_TLV_ENTRIES
+	var firstTLV, previousTLV *IsisTLV
+	for len(entries) > 0 {
+		count := len(entries)
+		if count > MAX_TLV_ENTRIES {
+			count = MAX_TLV_ENTRIES
+		}
+		var buf bytes.Buffer
+		for _, entry := range entries[:count] {
+			binary.Write(&buf, binary.BigEndian, entry)
+		}
+		tlv := &IsisTLV{typeTLV: ISIS_LSP_ENTRIES_TLV, lengthTLV: byte(count * LSP_ENTRY_SIZE), valueTLV: buf.Bytes()}
+		if firstTLV == nil {
+			firstTLV = tlv
+		} else {
+			previousTLV.nextTLV = tlv
+		}
+		previousTLV = tlv
+		entries = entries[count:]
+	}
+	return firstTLV
+}
+
+func getLspEntries(firstTLV *IsisTLV) []LspEntry {
+	// Given a linked list of TLVs, pull out all the LSP entries
+	entries := make([]LspEntry, 0)
+	for tlv := firstTLV; tlv != nil; tlv = tlv.nextTLV {
+		if tlv.typeTLV != ISIS_LSP_ENTRIES_TLV {
+			continue
+		}
+		buf := bytes.NewBuffer(tlv.valueTLV)
+		for i := 0; i < int(tlv.lengthTLV)/LSP_ENTRY_SIZE; i++ {
+			var entry LspEntry
+			binary.Read(buf, binary.BigEndian, &entry)
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func serializeCsnp(pdu *IsisCsnpPDU) []byte {
+	var buf bytes.Buffer
+	pdu.Header.LengthPDU = byte(unsafe.Sizeof(pdu.Header) + unsafe.Sizeof(pdu.CsnpHeader))
+	binary.BigEndian.PutUint16(pdu.CsnpHeader.LengthPDU[:], uint16(int(pdu.Header.LengthPDU)+getTLVsLength(pdu.FirstTLV)))
+	binary.Write(&buf, binary.BigEndian, pdu.Header)
+	binary.Write(&buf, binary.BigEndian, pdu.CsnpHeader)
+	serializeTLVs(&buf, pdu.FirstTLV)
+	return buf.Bytes()
+}
+
+func serializePsnp(pdu *IsisPsnpPDU) []byte {
+	var buf bytes.Buffer
+	pdu.Header.LengthPDU = byte(unsafe.Sizeof(pdu.Header) + unsafe.Sizeof(pdu.PsnpHeader))
+	binary.BigEndian.PutUint16(pdu.PsnpHeader.LengthPDU[:], uint16(int(pdu.Header.LengthPDU)+getTLVsLength(pdu.FirstTLV)))
+	binary.Write(&buf, binary.BigEndian, pdu.Header)
+	binary.Write(&buf, binary.BigEndian, pdu.PsnpHeader)
+	serializeTLVs(&buf, pdu.FirstTLV)
+	return buf.Bytes()
+}
+
+func deserializeCsnp(raw_bytes []byte) *IsisCsnpPDU {
+	// Skip the ethernet header, same as the other PDUs
+	buf := bytes.NewBuffer(raw_bytes[14:])
+	var csnp IsisCsnpPDU
+	binary.Read(buf, binary.BigEndian, &csnp.Header)
+	binary.Read(buf, binary.BigEndian, &csnp.CsnpHeader)
+	ethernetHeaderSize := 14
+	tlv_offset := ethernetHeaderSize + int(unsafe.Sizeof(csnp.Header)) + int(unsafe.Sizeof(csnp.CsnpHeader))
+	csnp.FirstTLV = parseTLVs(raw_bytes, tlv_offset)
+	return &csnp
+}
+
+func deserializePsnp(raw_bytes []byte) *IsisPsnpPDU {
+	buf := bytes.NewBuffer(raw_bytes[14:])
+	var psnp IsisPsnpPDU
+	binary.Read(buf, binary.BigEndian, &psnp.Header)
+	binary.Read(buf, binary.BigEndian, &psnp.PsnpHeader)
+	ethernetHeaderSize := 14
+	tlv_offset := ethernetHeaderSize + int(unsafe.Sizeof(psnp.Header)) + int(unsafe.Sizeof(psnp.PsnpHeader))
+	psnp.FirstTLV = parseTLVs(raw_bytes, tlv_offset)
+	return &psnp
+}
+
+func getSourceID(sid string) [7]byte {
+	// Circuit ID is left as zero
+	var sourceID [7]byte
+	systemID := systemIDToBytes(sid)
+	copy(sourceID[:], systemID[:])
+	return sourceID
+}
+
+func buildCsnps(db *IsisDB, sid string) []*IsisCsnpPDU {
+	// Describe the whole update database, splitting it into multiple
+	// CSNPs with contiguous LSP ID ranges if it does not fit in one.
+	// The CSNPs are for the same level as the database
+	pduType := byte(L1_CSNP_PDU_TYPE)
+	if db.Level == LEVEL_2 {
+		pduType = L2_CSNP_PDU_TYPE
+	}
+	db.DBLock.Lock()
+	nodes := AvlGetAll(db.Root)
+	entries := make([]LspEntry, 0, len(nodes))
+	for _, node := range nodes {
+		entries = append(entries, getLspEntry(node.data.(*IsisLsp)))
+	}
+	db.DBLock.Unlock()
+	csnps := make([]*IsisCsnpPDU, 0)
+	startLspID := [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	for {
+		count := len(entries)
+		if count > MAX_SNP_LSP_ENTRY {
+			count = MAX_SNP_LSP_ENTRY
+		}
+		// The last CSNP covers up to the end of the LSP ID space
+		endLspID := [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+		if count < len(entries) {
+			endLspID = entries[count-1].LspID
+		}
+		csnp := &IsisCsnpPDU{Header: buildSnpHeader(pduType),
+			CsnpHeader: IsisCsnpHeader{SourceID: getSourceID(sid), StartLspID: startLspID, EndLspID: endLspID},
+			FirstTLV:   getLspEntriesTLVs(entries[:count])}
+		csnps = append(csnps, csnp)
+		entries = entries[count:]
+		if len(entries) == 0 {
+			break
+		}
+		// Next range starts right after the end of this one
+		binary.BigEndian.PutUint64(startLspID[:], binary.BigEndian.Uint64(endLspID[:])+1)
+	}
+	return csnps
+}
+
+func buildPsnp(db *IsisDB, sid string, lspIDs [][8]byte) *IsisPsnpPDU {
+	// Describe the requested LSPs. If we don't have an LSP at all
+	// advertise it with a zero sequence number, which is older than anything
+	// our neighbor can have, so it will send it to us
+	db.DBLock.Lock()
+	entries := make([]LspEntry, 0, len(lspIDs))
+	for _, lspID := range lspIDs {
+		tmp := AvlSearch(db.Root, lspIDToKey(lspID))
+		if tmp == nil {
+			entries = append(entries, LspEntry{LspID: lspID})
+		} else {
+			entries = append(entries, getLspEntry(tmp.(*IsisLsp)))
+		}
+	}
+	db.DBLock.Unlock()
+	pduType := byte(L1_PSNP_PDU_TYPE)
+	if db.Level == LEVEL_2 {
+		pduType = L2_PSNP_PDU_TYPE
+	}
+	return &IsisPsnpPDU{Header: buildSnpHeader(pduType),
+		PsnpHeader: IsisPsnpHeader{SourceID: getSourceID(sid)},
+		FirstTLV:   getLspEntriesTLVs(entries)}
+}
+
+func setFloodFlags(intf *Intf, level byte, lspID [8]byte, srm bool, ssn bool) {
+	// Requires the interface lock to be held
+	key := lspIDToKey(lspID)
+	lspFloodStates := intf.lspFloodStates[level-1]
+	if _, inMap := lspFloodStates[key]; !inMap {
+		lspFloodStates[key] = &LspFloodState{LspIDKey: key, LspID: lspID, SRM: srm, SSN: ssn}
+	} else {
+		lspFloodStates[key].SRM = srm
+		lspFloodStates[key].SSN = ssn
+	}
+}
+
+func processLspEntry(intf *Intf, db *IsisDB, entry LspEntry) {
+	// Compare a single LSP entry from a SNP against our database, requires both the update db
+	// lock and the interface lock to be held.
+	//  - We don't have it or ours is older --> request it by setting SSN
+	//  - Ours is newer --> send ours by setting SRM
+	//  - Same --> nothing to send, this also acks any outstanding SRM
+	tmp := AvlSearch(db.Root, lspIDToKey(entry.LspID))
+	entrySeq := binary.BigEndian.Uint32(entry.SequenceNumber[:])
+	if tmp == nil {
+		if entrySeq != 0 {
+			glog.V(1).Infof("SNP: requesting unknown lsp %s on %s", nodeIDName(entry.LspID[:7]), intf.name)
+			setFloodFlags(intf, db.Level, entry.LspID, false, true)
+		}
+		return
+	}
+	lsp := tmp.(*IsisLsp)
+	ourSeq := binary.BigEndian.Uint32(lsp.CoreLsp.LspHeader.SequenceNumber[:])
+	switch compareLsp(ourSeq, getRemainingLifetime(lsp), entrySeq, binary.BigEndian.Uint16(entry.RemainingLifetime[:])) {
+	case -1:
+		glog.V(1).Infof("SNP: requesting newer lsp %s on %s", nodeIDName(entry.LspID[:7]), intf.name)
+		setFloodFlags(intf, db.Level, entry.LspID, false, true)
+	case 1:
+		glog.V(1).Infof("SNP: neighbor on %s has an older %s, sending ours", intf.name, nodeIDName(entry.LspID[:7]))
+		setFloodFlags(intf, db.Level, entry.LspID, true, false)
+	default:
+		setFloodFlags(intf, db.Level, entry.LspID, false, false)
+	}
+}
+
+func processCsnp(intf *Intf, db *IsisDB, csnp *IsisCsnpPDU) {
+	// Any LSP which falls in the range of the CSNP but is not described by it,
+	// is an LSP our neighbor is missing so set SRM for it
+	entries := getLspEntries(csnp.FirstTLV)
+	start := lspIDToKey(csnp.CsnpHeader.StartLspID)
+	end := lspIDToKey(csnp.CsnpHeader.EndLspID)
+	db.DBLock.Lock()
+	intf.lock.Lock()
+	described := make(map[uint64]bool)
+	for _, entry := range entries {
+		described[lspIDToKey(entry.LspID)] = true
+		processLspEntry(intf, db, entry)
+	}
+	for _, node := range AvlGetAll(db.Root) {
+		if node.key < start || node.key > end || described[node.key] {
+			continue
+		}
+		lsp := node.data.(*IsisLsp)
+		if binary.BigEndian.Uint32(lsp.CoreLsp.LspHeader.SequenceNumber[:]) == 0 || getRemainingLifetime(lsp) == 0 {
+			// Purges only need to reach the neighbors which still have the LSP
+			continue
+		}
+		glog.V(1).Infof("CSNP: neighbor on %s is missing %s", intf.name, nodeIDName(lsp.LspID[:7]))
+		setFloodFlags(intf, db.Level, lsp.LspID, true, false)
+	}
+	intf.lock.Unlock()
+	db.DBLock.Unlock()
+}
+
+func processPsnp(intf *Intf, db *IsisDB, psnp *IsisPsnpPDU) {
+	db.DBLock.Lock()
+	intf.lock.Lock()
+	for _, entry := range getLspEntries(psnp.FirstTLV) {
+		processLspEntry(intf, db, entry)
+	}
+	intf.lock.Unlock()
+	db.DBLock.Unlock()
+}
+
+func isisSnpInput(receiveIntf *Intf, pdu []byte) {
+	glog.V(4).Infof(hex.Dump(pdu[:]))
+	pduType := pdu[14+4]
+	level := byte(LEVEL_1)
+	if pduType == L2_CSNP_PDU_TYPE || pduType == L2_PSNP_PDU_TYPE {
+		level = LEVEL_2
+	}
+	receiveIntf.lock.Lock()
+	running := receiveIntf.level&level != 0
+	receiveIntf.lock.Unlock()
+	if !running {
+		glog.V(1).Infof("Got a %s SNP on %s which does not run that level, dropping", levelToString(level), receiveIntf.name)
+		return
+	}
+	if pduType == L1_CSNP_PDU_TYPE || pduType == L2_CSNP_PDU_TYPE {
+		csnp := deserializeCsnp(pdu)
+		glog.V(2).Infof("Got %s CSNP from %s on %s", levelToString(level), systemIDName(csnp.CsnpHeader.SourceID[:6]), receiveIntf.name)
+		processCsnp(receiveIntf, getUpdateDB(level), csnp)
+		noteRestartCsnp(receiveIntf, level, csnp)
+	} else {
+		psnp := deserializePsnp(pdu)
+		glog.V(2).Infof("Got %s PSNP from %s on %s", levelToString(level), systemIDName(psnp.PsnpHeader.SourceID[:6]), receiveIntf.name)
+		processPsnp(receiveIntf, getUpdateDB(level), psnp)
+	}
+}
+
+func sendPsnp(intf *Intf, db *IsisDB, send chan []byte) {
+	// Gather up all the LSPs at the database's level with SSN set on this
+	// interface and request/acknowledge them with a PSNP
+	lspIDs := make([][8]byte, 0)
+	intf.lock.Lock()
+	for _, lspFloodState := range intf.lspFloodStates[db.Level-1] {
+		if lspFloodState.SSN && hasUpAdjacency(intf, db.Level) {
+			lspIDs = append(lspIDs, lspFloodState.LspID)
+			lspFloodState.SSN = false
+		}
+	}
+	dst := getMulticast(intf, db.Level)
+	intf.lock.Unlock()
+	key := getSendKey(getLevelKeychain(db.Level))
+	for len(lspIDs) > 0 {
+		count := len(lspIDs)
+		if count > MAX_SNP_LSP_ENTRY {
+			count = MAX_SNP_LSP_ENTRY
+		}
+		glog.V(1).Infof("Sending %s PSNP with %d entries out %s", levelToString(db.Level), count, intf.name)
+		psnp := buildPsnp(db, cfg.sid, lspIDs[:count])
+		psnp.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(key), psnp.FirstTLV})
+		send <- buildEthernetFrame(dst, getMac(intf.name), signPDU(serializePsnp(psnp), key))
+		lspIDs = lspIDs[count:]
+	}
+}
+
+func sendCsnps(intf *Intf, db *IsisDB, sid string, send chan []byte) {
+	intf.lock.Lock()
+	dst := getMulticast(intf, db.Level)
+	intf.lock.Unlock()
+	key := getSendKey(getLevelKeychain(db.Level))
+	for _, csnp := range buildCsnps(db, sid) {
+		glog.V(2).Infof("Sending %s CSNP out %s", levelToString(db.Level), intf.name)
+		csnp.FirstTLV = linkTLVs([]*IsisTLV{getAuthTLV(key), csnp.FirstTLV})
+		send <- buildEthernetFrame(dst, getMac(intf.name), signPDU(serializeCsnp(csnp), key))
+	}
+}
+
+func isisCsnpSend(intf *Intf, send chan []byte) {
+	// Periodically describe our whole database to our neighbors
+	// so that they can request anything they are missing.
+	// Only the DIS sends them on broadcast circuits, point-to-point
+	// circuits send CSNPs when the adjacency comes up
+	for {
+		time.Sleep(CSNP_INTERVAL * time.Millisecond)
+		intf.lock.Lock()
+		cfg.lock.Lock()
+		sid := cfg.sid
+		ready := make([]byte, 0)
+		for _, level := range getLevels(intf.level) {
+			if sid != "" && hasUpAdjacency(intf, level) && intf.circuitType == BROADCAST_CIRCUIT && isDIS(intf, level, sid) {
+				ready = append(ready, level)
+			}
+		}
+		cfg.lock.Unlock()
+		intf.lock.Unlock()
+		for _, level := range ready {
+			sendCsnps(intf, getUpdateDB(level), sid, send)
+		}
+	}
+}
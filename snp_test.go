@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func buildTestDB(sids []string, seq uint32) *IsisDB {
+	db := &IsisDB{Level: LEVEL_1}
+	for _, sid := range sids {
+		lsp := buildEmptyLSP(LEVEL_1, seq, sid)
+		db.Root = AvlInsert(db.Root, lsp.Key, lsp, true)
+	}
+	return db
+}
+
+func TestCsnpSerialize(t *testing.T) {
+	db := buildTestDB([]string{"1111.1111.1111", "1111.1111.1112"}, 1)
+	csnps := buildCsnps(db, "1111.1111.1113")
+	if len(csnps) != 1 {
+		t.Fatalf("Expected a single CSNP, got %d", len(csnps))
+	}
+	frame := buildEthernetFrame([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x14}, []byte{0, 0, 0, 0, 0, 1}, serializeCsnp(csnps[0]))
+	csnp := deserializeCsnp(frame)
+	entries := getLspEntries(csnp.FirstTLV)
+	t.Logf("CSNP entries %v", entries)
+	if len(entries) != 2 || systemIDToString(entries[1].LspID[:6]) != "1111.1111.1112" ||
+		systemIDToString(csnp.CsnpHeader.SourceID[:6]) != "1111.1111.1113" {
+		t.Fail()
+	}
+}
+
+func TestCsnpSplit(t *testing.T) {
+	sids := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		sids = append(sids, fmt.Sprintf("1111.1111.%04x", i))
+	}
+	csnps := buildCsnps(buildTestDB(sids, 1), "1111.1111.1113")
+	if len(csnps) != 3 {
+		t.Fatalf("Expected 3 CSNPs, got %d", len(csnps))
+	}
+	// Ranges need to be contiguous and cover the whole LSP ID space
+	if lspIDToKey(csnps[0].CsnpHeader.StartLspID) != 0 || lspIDToKey(csnps[2].CsnpHeader.EndLspID) != ^uint64(0) {
+		t.Fail()
+	}
+	for i := 1; i < len(csnps); i++ {
+		if lspIDToKey(csnps[i].CsnpHeader.StartLspID) != lspIDToKey(csnps[i-1].CsnpHeader.EndLspID)+1 {
+			t.Fail()
+		}
+	}
+}
+
+func TestProcessCsnp(t *testing.T) {
+	// We have 1111 (seq 2) and 1112 (seq 1), our neighbor has a newer 1111 and
+	// 1113 which we don't know about, but is missing 1112
+	db := buildTestDB([]string{"1111.1111.1111", "1111.1111.1112"}, 1)
+	db.Root = AvlInsert(db.Root, systemIDToKey("1111.1111.1111"), buildEmptyLSP(LEVEL_1, 2, "1111.1111.1111"), true)
+	neighborDB := buildTestDB([]string{"1111.1111.1111", "1111.1111.1113"}, 3)
+	csnp := buildCsnps(neighborDB, "1111.1111.1114")[0]
+	intf := &Intf{name: "eth0", lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	processCsnp(intf, db, csnp)
+	printLspFloodStates(intf, LEVEL_1)
+	if !intf.lspFloodStates[0][systemIDToKey("1111.1111.1111")].SSN ||
+		!intf.lspFloodStates[0][systemIDToKey("1111.1111.1113")].SSN ||
+		!intf.lspFloodStates[0][systemIDToKey("1111.1111.1112")].SRM {
+		t.Fail()
+	}
+}
+
+func TestProcessPsnp(t *testing.T) {
+	// An entry matching what we have acknowledges it, clearing SRM
+	db := buildTestDB([]string{"1111.1111.1111"}, 5)
+	intf := &Intf{name: "eth0", lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	lspID := systemIDToLspID("1111.1111.1111")
+	setFloodFlags(intf, LEVEL_1, lspID, true, false)
+	var seq [4]byte
+	binary.BigEndian.PutUint32(seq[:], 5)
+	psnp := &IsisPsnpPDU{FirstTLV: getLspEntriesTLVs([]LspEntry{LspEntry{LspID: lspID, SequenceNumber: seq}})}
+	processPsnp(intf, db, psnp)
+	if intf.lspFloodStates[0][systemIDToKey("1111.1111.1111")].SRM {
+		t.Fail()
+	}
+}
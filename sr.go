@@ -0,0 +1,303 @@
+// Segment routing with the MPLS data plane in the IS-IS protocol (RFC 8667).
+// Advertises our SRGB, prefix SIDs and adjacency SIDs and programs the label routes after SPF.
+// +build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/glog"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+)
+
+const (
+	SR_CAPABILITIES_SUB_TLV = 2  // In TLV 242
+	SR_ALGORITHM_SUB_TLV    = 19 // In TLV 242
+	SID_LABEL_SUB_TLV       = 1  // Within the SR capabilities sub-TLV
+	PREFIX_SID_SUB_TLV      = 3  // In TLV 135
+	ADJ_SID_SUB_TLV         = 31 // In TLV 22
+	LAN_ADJ_SID_SUB_TLV     = 32 // In TLV 22, for the neighbors on a LAN
+	SR_MPLS_IPV4_FLAG       = 0x80
+	PREFIX_SID_FLAG_NODE    = 0x40 // The prefix identifies the router, set for host prefixes
+	PREFIX_SID_FLAG_NO_PHP  = 0x20
+	PREFIX_SID_FLAG_VALUE   = 0x08 // The SID is a label rather than an index
+	PREFIX_SID_FLAG_LOCAL   = 0x04
+	ADJ_SID_FLAG_VALUE      = 0x20
+	ADJ_SID_FLAG_LOCAL      = 0x10
+	SR_ALGORITHM_SPF        = 0
+	DEFAULT_SRGB_START      = 16000
+	DEFAULT_SRGB_RANGE      = 8000
+	ADJ_SID_LABEL_BASE      = 24000 // Adjacency SIDs are allocated from here up, after the default SRGB
+	MIN_MPLS_LABEL          = 16    // The labels below are reserved
+	MAX_MPLS_LABEL          = 0xfffff
+	MPLS_SYSCTL_PATH        = "/proc/sys/net/mpls/"
+	SR_ENABLED              = "enabled"
+	SR_DISABLED             = "disabled"
+)
+
+type PrefixSID struct {
+	prefix net.IPNet // Only set for the prefix SIDs we configure
+	index  uint32
+	flags  byte
+}
+
+type LabelRange struct {
+	start uint32
+	size  uint32
+}
+
+// The last adjacency SID label we allocated
+var lastAdjSID uint32 = ADJ_SID_LABEL_BASE - 1
+var adjSIDLock sync.Mutex
+
+func getSRGB() []LabelRange {
+	// A single range is originated
+	return []LabelRange{LabelRange{start: cfg.srgbStart, size: cfg.srgbRange}}
+}
+
+func checkSRGB(start uint32, size uint32) error {
+	// The SRGB has to end before the adjacency SIDs start
+	if size == 0 || start < MIN_MPLS_LABEL || start+size > ADJ_SID_LABEL_BASE {
+		return fmt.Errorf("SRGB of %d labels from %d out of range, must be within %d-%d", size, start, MIN_MPLS_LABEL, ADJ_SID_LABEL_BASE-1)
+	}
+	return nil
+}
+
+func getSIDLabel(srgb []LabelRange, index uint32) (uint32, bool) {
+	// The label of a SID index, the ranges of an SRGB are used one after another
+	for _, labels := range srgb {
+		if index < labels.size {
+			return labels.start + index, true
+		}
+		index -= labels.size
+	}
+	return 0, false
+}
+
+func getRouterID() net.IP {
+	// The first prefix SID, usually a loopback, or else the first interface address
+	for _, sid := range cfg.prefixSIDs {
+		return sid.prefix.IP
+	}
+	for _, intf := range cfg.interfaces {
+		if intf.prefix.To4() != nil {
+			return intf.prefix
+		}
+	}
+	return net.IPv4zero
+}
+
+func getRouterCapabilityTLV(routerID net.IP, srgb []LabelRange) *IsisTLV {
+	// TLV 242, a 4 byte router ID and flags then the SR capabilities sub-TLV with
+	// each range of the SRGB as a 3 byte size and a SID/label sub-TLV with its
+	// first label, followed by the SR algorithm sub-TLV
+	tlv := &IsisTLV{typeTLV: ISIS_ROUTER_CAPABILITY_TLV}
+	tlv.valueTLV = append(append(tlv.valueTLV, routerID.To4()...), 0x00)
+	capabilities := []byte{SR_MPLS_IPV4_FLAG}
+	for _, labels := range srgb {
+		capabilities = append(capabilities, byte(labels.size>>16), byte(labels.size>>8), byte(labels.size),
+			SID_LABEL_SUB_TLV, 3, byte(labels.start>>16), byte(labels.start>>8), byte(labels.start))
+	}
+	tlv.valueTLV = append(tlv.valueTLV, SR_CAPABILITIES_SUB_TLV, byte(len(capabilities)))
+	tlv.valueTLV = append(tlv.valueTLV, capabilities...)
+	tlv.valueTLV = append(tlv.valueTLV, SR_ALGORITHM_SUB_TLV, 1, SR_ALGORITHM_SPF)
+	tlv.lengthTLV = byte(len(tlv.valueTLV))
+	return tlv
+}
+
+func getSubTLV(subTLVs []byte, typeSubTLV byte) []byte {
+	// The value of the first sub-TLV of a type, nil if it is missing or malformed
+	for i := 0; i+2 <= len(subTLVs); i += 2 + int(subTLVs[i+1]) {
+		if i+2+int(subTLVs[i+1]) > len(subTLVs) {
+			return nil
+		}
+		if subTLVs[i] == typeSubTLV {
+			return subTLVs[i+2 : i+2+int(subTLVs[i+1])]
+		}
+	}
+	return nil
+}
+
+func getCapabilitySRGB(tlv *IsisTLV) []LabelRange {
+	// The SRGB advertised in a TLV 242, nil without the SR capabilities sub-TLV
+	if tlv.lengthTLV < 5 {
+		return nil
+	}
+	capabilities := getSubTLV(tlv.valueTLV[5:], SR_CAPABILITIES_SUB_TLV)
+	if len(capabilities) < 1 || capabilities[0]&SR_MPLS_IPV4_FLAG == 0 {
+		return nil
+	}
+	srgb := make([]LabelRange, 0)
+	for i := 1; i+8 <= len(capabilities) && capabilities[i+3] == SID_LABEL_SUB_TLV && capabilities[i+4] == 3; i += 8 {
+		size := uint32(capabilities[i])<<16 | uint32(capabilities[i+1])<<8 | uint32(capabilities[i+2])
+		start := (uint32(capabilities[i+5])<<16 | uint32(capabilities[i+6])<<8 | uint32(capabilities[i+7])) & MAX_MPLS_LABEL
+		srgb = append(srgb, LabelRange{start: start, size: size})
+	}
+	return srgb
+}
+
+func getNodeSRGB(db *IsisDB, systemID string) []LabelRange {
+	// The SRGB of a router from its LSP, requires the update db lock to be held
+	for _, lsp := range getLspFragments(db, systemID) {
+		for tlv := getTLV(lsp.CoreLsp.FirstTLV, ISIS_ROUTER_CAPABILITY_TLV); tlv != nil; tlv = getTLV(tlv.nextTLV, ISIS_ROUTER_CAPABILITY_TLV) {
+			if srgb := getCapabilitySRGB(tlv); srgb != nil {
+				return srgb
+			}
+		}
+	}
+	return nil
+}
+
+func getPrefixSIDSubTLV(sid *PrefixSID) []byte {
+	// Flags, the algorithm and a 4 byte index into the SRGB
+	subTLV := []byte{PREFIX_SID_SUB_TLV, 6, sid.flags, SR_ALGORITHM_SPF, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(subTLV[4:], sid.index)
+	return subTLV
+}
+
+func getPrefixSID(subTLVs []byte) *PrefixSID {
+	// The SPF algorithm index of a prefix SID sub-TLV, labels instead of indices are ignored
+	value := getSubTLV(subTLVs, PREFIX_SID_SUB_TLV)
+	if len(value) < 6 || value[0]&(PREFIX_SID_FLAG_VALUE|PREFIX_SID_FLAG_LOCAL) != 0 || value[1] != SR_ALGORITHM_SPF {
+		return nil
+	}
+	return &PrefixSID{index: binary.BigEndian.Uint32(value[2:6]), flags: value[0]}
+}
+
+func appendPrefixSIDs(reachTLV *IsisTLV) {
+	// Add the prefixes we configured a SID for to a TLV 128 or 135,
+	// only TLV 135 can carry the SID
+	for _, sid := range cfg.prefixSIDs {
+		if reachTLV.typeTLV == ISIS_EXTENDED_IP_REACH_TLV {
+			appendExtendedPrefix(reachTLV, &sid.prefix, DEFAULT_METRIC, false, getPrefixSIDSubTLV(sid))
+		} else if reachTLV.typeTLV == ISIS_IP_INTERNAL_REACH_TLV {
+			appendPrefix(reachTLV, &sid.prefix, DEFAULT_METRIC)
+		}
+	}
+}
+
+func allocateAdjSID(adj *Adjacency) {
+	// Requires the interface lock to be held
+	if adj.adjSID != 0 {
+		return
+	}
+	adjSIDLock.Lock()
+	lastAdjSID++
+	adj.adjSID = lastAdjSID
+	adjSIDLock.Unlock()
+}
+
+func getAdjSIDSubTLVs(adjacencies []*Adjacency, lan bool, used int) []byte {
+	// An adjacency SID sub-TLV with the label of a point-to-point adjacency,
+	// or a LAN adjacency SID sub-TLV for each router on a LAN with its system ID
+	// for as many as fit after the used bytes of other sub-TLVs. Requires the
+	// interface lock to be held
+	subTLVs := make([]byte, 0)
+	for _, adj := range adjacencies {
+		allocateAdjSID(adj)
+		label := []byte{byte(adj.adjSID >> 16), byte(adj.adjSID >> 8), byte(adj.adjSID)}
+		var subTLV []byte
+		if lan {
+			subTLV = append([]byte{LAN_ADJ_SID_SUB_TLV, 11, ADJ_SID_FLAG_VALUE | ADJ_SID_FLAG_LOCAL, 0}, adj.neighborSystemID...)
+		} else {
+			subTLV = []byte{ADJ_SID_SUB_TLV, 5, ADJ_SID_FLAG_VALUE | ADJ_SID_FLAG_LOCAL, 0}
+		}
+		subTLV = append(subTLV, label...)
+		if used+len(subTLVs)+len(subTLV) > MAX_TLV_LENGTH-11 {
+			glog.Infof("Out of room for adjacency SIDs on %s", adj.intfName)
+			break
+		}
+		subTLVs = append(subTLVs, subTLV...)
+	}
+	return subTLVs
+}
+
+func enableMpls(interfaces []*Intf) {
+	// The kernel only forwards labels up to its platform labels and only
+	// accepts labeled packets on interfaces with MPLS input enabled
+	if err := ioutil.WriteFile(MPLS_SYSCTL_PATH+"platform_labels", []byte(strconv.Itoa(MAX_MPLS_LABEL)), 0644); err != nil {
+		glog.Errorf("Unable to set the MPLS platform labels, is the mpls_router module loaded? %v", err)
+		return
+	}
+	for _, intf := range interfaces {
+		if err := ioutil.WriteFile(MPLS_SYSCTL_PATH+"conf/"+intf.name+"/input", []byte("1"), 0644); err != nil {
+			glog.Errorf("Unable to enable MPLS input on %s: %v", intf.name, err)
+		}
+	}
+}
+
+func installAdjSIDs(localInterfaces []*Intf, routes map[string]*RibRoute) {
+	// Pop our adjacency SIDs towards their neighbors
+	for _, intf := range localInterfaces {
+		intf.lock.Lock()
+		for _, adj := range intf.adjacencies {
+			if adj.state == "UP" && adj.adjSID != 0 && adj.neighborIP != nil {
+				addRibRoute(routes, &RibRoute{label: int(adj.adjSID), nextHops: []*RibNextHop{&RibNextHop{ip: adj.neighborIP, linkIndex: int(intf.circuitID)}}})
+			}
+		}
+		intf.lock.Unlock()
+	}
+}
+
+func getOutLabels(updateDB *IsisDB, systemID string, adj *Adjacency, sid *PrefixSID) ([]int, bool) {
+	// The labels a prefix SID of a system is sent to a next hop with, none if the
+	// next hop advertised it and wants us to pop. Requires the update db lock to be held
+	nextHop := systemIDToString(adj.neighborSystemID)
+	if nextHop == systemID && sid.flags&PREFIX_SID_FLAG_NO_PHP == 0 {
+		return nil, true
+	}
+	label, ok := getSIDLabel(getNodeSRGB(updateDB, nextHop), sid.index)
+	if !ok {
+		glog.V(1).Infof("No label for SID index %d from %s", sid.index, systemIDName(adj.neighborSystemID))
+		return nil, false
+	}
+	return []int{int(label)}, true
+}
+
+func installLabelsFromPath(updateDB *IsisDB, path *Triple, routes map[string]*RibRoute) {
+	// After installRouteFromPath, add the labels of the prefix SIDs the system
+	// at the end of a path advertises to the RIB: a swap or pop of our label and
+	// a push onto the route to the prefix, through each next hop. Labels are
+	// only used for IPv4 prefixes. Requires the update db lock to be held
+	for _, prefix := range getIPPrefixes(updateDB, path.systemID) {
+		if prefix.sid == nil {
+			continue
+		}
+		label, ok := getSIDLabel(getSRGB(), prefix.sid.index)
+		if !ok {
+			glog.Infof("SID index %d of %v is outside our SRGB", prefix.sid.index, prefix.prefix)
+			continue
+		}
+		// Only if this path is the one the prefix is routed over
+		route := routes[prefix.prefix.String()]
+		if route != nil && route.distance != path.distance+prefix.metric {
+			route = nil
+		}
+		labelRoute := &RibRoute{label: int(label), distance: path.distance}
+		for _, adj := range path.nextHops {
+			if adj.neighborIP == nil {
+				continue
+			}
+			outLabels, ok := getOutLabels(updateDB, path.systemID, adj, prefix.sid)
+			if !ok {
+				continue
+			}
+			glog.V(2).Infof("Adding label %d for %v with out labels %v via %v", label, prefix.prefix, outLabels, adj.neighborIP)
+			labelRoute.nextHops = append(labelRoute.nextHops, &RibNextHop{ip: adj.neighborIP, linkIndex: getLinkIndex(adj.intfName), labels: outLabels})
+			if route != nil {
+				for _, nextHop := range route.nextHops {
+					if nextHop.ip.Equal(adj.neighborIP) {
+						nextHop.labels = outLabels
+					}
+				}
+			}
+		}
+		if len(labelRoute.nextHops) != 0 {
+			addRibRoute(routes, labelRoute)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRouterCapabilityTLV(t *testing.T) {
+	srgb := []LabelRange{LabelRange{start: 16000, size: 100}, LabelRange{start: 20000, size: 1000}}
+	tlv := getRouterCapabilityTLV(net.IP{10, 0, 0, 1}, srgb)
+	parsed := getCapabilitySRGB(tlv)
+	if tlv.typeTLV != ISIS_ROUTER_CAPABILITY_TLV || len(parsed) != 2 || parsed[0] != srgb[0] || parsed[1] != srgb[1] {
+		t.Fatalf("Unexpected SRGB %v", parsed)
+	}
+	// The ranges are used one after another
+	if label, ok := getSIDLabel(parsed, 150); !ok || label != 20050 {
+		t.Fatalf("Expected label 20050, got %d", label)
+	}
+	if _, ok := getSIDLabel(parsed, 1100); ok {
+		t.Fail()
+	}
+}
+
+func TestPrefixSID(t *testing.T) {
+	// Prefixes with and without a SID in the same TLV 135
+	tlv := &IsisTLV{typeTLV: ISIS_EXTENDED_IP_REACH_TLV}
+	appendExtendedPrefix(tlv, &net.IPNet{IP: net.IP{172, 19, 0, 0}, Mask: net.CIDRMask(16, 32)}, 10, false, nil)
+	sid := &PrefixSID{prefix: net.IPNet{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(32, 32)}, index: 7, flags: PREFIX_SID_FLAG_NODE}
+	appendExtendedPrefix(tlv, &sid.prefix, 10, false, getPrefixSIDSubTLV(sid))
+	appendExtendedPrefix(tlv, &net.IPNet{IP: net.IP{192, 168, 1, 0}, Mask: net.CIDRMask(24, 32)}, 20, false, nil)
+	prefixes := getExtendedPrefixes(tlv)
+	if len(prefixes) != 3 || prefixes[0].sid != nil || prefixes[2].sid != nil || prefixes[2].metric != 20 {
+		t.Fatalf("Unexpected prefixes %v", prefixes)
+	}
+	if prefixes[1].prefix.String() != "10.0.0.1/32" || prefixes[1].sid == nil || prefixes[1].sid.index != 7 || prefixes[1].sid.flags != PREFIX_SID_FLAG_NODE {
+		t.Fatalf("Unexpected prefix SID %v", prefixes[1].sid)
+	}
+}
+
+func TestAdjSID(t *testing.T) {
+	initConfig()
+	cfg.segmentRouting = true
+	neighborSystemID := []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}
+	intf := &Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: neighborSystemID}}}
+	tlv := getExtendedNeighborTLV([]*Intf{intf}, LEVEL_1)
+	label := intf.adjacencies[0].adjSID
+	if tlv.lengthTLV != 11+7 || tlv.valueTLV[10] != 7 || tlv.valueTLV[11] != ADJ_SID_SUB_TLV || label < ADJ_SID_LABEL_BASE ||
+		uint32(tlv.valueTLV[15])<<16|uint32(tlv.valueTLV[16])<<8|uint32(tlv.valueTLV[17]) != label {
+		t.Fatalf("Unexpected TLV %v", tlv)
+	}
+	// The label stays the same and the neighbor is still parsed
+	getExtendedNeighborTLV([]*Intf{intf}, LEVEL_1)
+	if neighbors := getExtendedNeighbors(tlv); intf.adjacencies[0].adjSID != label || len(neighbors) != 1 || neighbors[0].systemID != "1111.1111.1112" {
+		t.Fatalf("Unexpected neighbors %v", neighbors)
+	}
+	// On a LAN the pseudonode carries a LAN adjacency SID for each router
+	lan := &Intf{circuitType: BROADCAST_CIRCUIT, level: LEVEL_1, lanID: [2][7]byte{{0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x01}},
+		adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: neighborSystemID}}}
+	tlv = getExtendedNeighborTLV([]*Intf{lan}, LEVEL_1)
+	if tlv.valueTLV[10] != 13 || tlv.valueTLV[11] != LAN_ADJ_SID_SUB_TLV || string(tlv.valueTLV[15:21]) != string(neighborSystemID) {
+		t.Fatalf("Unexpected TLV %v", tlv)
+	}
+	initConfig()
+}
+
+func TestSRLabels(t *testing.T) {
+	// TOPO: R1 -- R2 -- R3, R2 has an SRGB from 20000 and R3 a node SID with index 5
+	updateDBInit()
+	r2sid, r3sid := "1111.1111.1112", "1111.1111.1113"
+	r2 := buildEmptyLSP(LEVEL_1, 1, r2sid)
+	r2.CoreLsp.FirstTLV = getRouterCapabilityTLV(net.IP{10, 0, 0, 2}, []LabelRange{LabelRange{start: 20000, size: 1000}})
+	UpdateDB.Root = AvlInsert(UpdateDB.Root, systemIDToKey(r2sid), r2, false)
+	adj := &Adjacency{state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, neighborIP: net.IP{10, 0, 0, 2}}
+	sid := &PrefixSID{index: 5, flags: PREFIX_SID_FLAG_NODE}
+	// Through R2 we swap to R2's label for the index
+	if labels, ok := getOutLabels(UpdateDB, r3sid, adj, sid); !ok || len(labels) != 1 || labels[0] != 20005 {
+		t.Fatalf("Expected label 20005, got %v", labels)
+	}
+	// R2's own SID is popped before it unless it asked us not to
+	if labels, ok := getOutLabels(UpdateDB, r2sid, adj, sid); !ok || labels != nil {
+		t.Fatalf("Expected a pop, got %v", labels)
+	}
+	sid.flags |= PREFIX_SID_FLAG_NO_PHP
+	if labels, ok := getOutLabels(UpdateDB, r2sid, adj, sid); !ok || len(labels) != 1 || labels[0] != 20005 {
+		t.Fatalf("Expected label 20005, got %v", labels)
+	}
+	// Without an SRGB from the next hop there is no label to use
+	if _, ok := getOutLabels(UpdateDB, r3sid, &Adjacency{neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x14}}, sid); ok {
+		t.Fail()
+	}
+}
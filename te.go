@@ -0,0 +1,290 @@
+// Traffic engineering extensions in the IS-IS protocol (RFC 5305).
+// TE attributes in TLV 22, a TE database per level and constrained SPF for ComputePath.
+// +build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/glog"
+	"math"
+	"net"
+	"strings"
+	"sync"
+)
+
+const (
+	ADMIN_GROUP_SUB_TLV              = 3
+	IPV4_INTF_ADDR_SUB_TLV           = 6
+	IPV4_NEIGHBOR_ADDR_SUB_TLV       = 8
+	MAX_BANDWIDTH_SUB_TLV            = 9
+	MAX_RESERVABLE_BANDWIDTH_SUB_TLV = 10
+	TE_METRIC_SUB_TLV                = 18
+	PATH_METRIC_TE                   = "te"
+	PATH_METRIC_IGP                  = "igp"
+)
+
+var TEDB *IsisDB   // Level 1
+var L2TEDB *IsisDB // Level 2
+
+type TELink struct {
+	neighbor   string // System ID or pseudonode ID of the other end
+	metric     uint32
+	teMetric   uint32 // The IGP metric unless a TE metric is advertised
+	adminGroup uint32
+	// Bandwidths in bytes per second, as advertised
+	maxBandwidth        float32
+	reservableBandwidth float32
+	localIP             net.IP
+	remoteIP            net.IP
+}
+
+type TENode struct {
+	systemID   string
+	overloaded bool
+	links      []*TELink
+}
+
+func (n TENode) String() string {
+	return fmt.Sprintf("SystemID %s Links %d", withHostname(n.systemID), len(n.links))
+}
+
+type PathConstraints struct {
+	bandwidth  float32 // Reservable bandwidth every link needs, in bytes per second
+	includeAny uint32
+	includeAll uint32
+	excludeAny uint32
+	teMetric   bool // Whether the TE metric is used rather than the IGP metric
+}
+
+type PathHop struct {
+	systemID string
+	cost     uint32
+	link     *TELink // The link it is reached over, nil for the head end
+}
+
+func (h PathHop) String() string {
+	hop := fmt.Sprintf("%s cost %d", withHostname(h.systemID), h.cost)
+	if h.link != nil && h.link.localIP != nil {
+		hop += " via " + h.link.localIP.String()
+		if h.link.remoteIP != nil {
+			hop += " to " + h.link.remoteIP.String()
+		}
+	}
+	return hop
+}
+
+func teDBInit() {
+	TEDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_1}
+	L2TEDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_2}
+}
+
+func getTEDB(level byte) *IsisDB {
+	if level == LEVEL_2 {
+		return L2TEDB
+	}
+	return TEDB
+}
+
+func hasTEAttributes(intf *Intf) bool {
+	return intf.teMetric != 0 || intf.maxBandwidth != 0 || intf.reservableBandwidth != 0 || intf.adminGroup != 0
+}
+
+func getTESubTLVs(intf *Intf, neighborIP net.IP) []byte {
+	// The TE sub-TLVs of a link, if the interface has any TE attributes. Only
+	// point-to-point links know the neighbor address. Requires the interface lock
+	if !hasTEAttributes(intf) {
+		return nil
+	}
+	subTLVs := make([]byte, 0)
+	if intf.adminGroup != 0 {
+		subTLVs = append(subTLVs, ADMIN_GROUP_SUB_TLV, 4, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(subTLVs[len(subTLVs)-4:], intf.adminGroup)
+	}
+	if intf.prefix.To4() != nil {
+		subTLVs = append(append(subTLVs, IPV4_INTF_ADDR_SUB_TLV, 4), intf.prefix.To4()...)
+	}
+	if neighborIP.To4() != nil {
+		subTLVs = append(append(subTLVs, IPV4_NEIGHBOR_ADDR_SUB_TLV, 4), neighborIP.To4()...)
+	}
+	// Configured in bits per second, advertised in bytes per second
+	for _, bandwidth := range []struct {
+		subTLV byte
+		bps    uint64
+	}{{MAX_BANDWIDTH_SUB_TLV, intf.maxBandwidth}, {MAX_RESERVABLE_BANDWIDTH_SUB_TLV, intf.reservableBandwidth}} {
+		if bandwidth.bps != 0 {
+			subTLVs = append(subTLVs, bandwidth.subTLV, 4, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(subTLVs[len(subTLVs)-4:], math.Float32bits(float32(bandwidth.bps)/8))
+		}
+	}
+	if intf.teMetric != 0 {
+		subTLVs = append(subTLVs, TE_METRIC_SUB_TLV, 3, byte(intf.teMetric>>16), byte(intf.teMetric>>8), byte(intf.teMetric))
+	}
+	return subTLVs
+}
+
+func getTELink(neighbor *Neighbor) *TELink {
+	// The TE attributes of a link from the sub-TLVs of its TLV 22 entry,
+	// anything malformed is skipped
+	link := &TELink{neighbor: neighbor.systemID, metric: neighbor.metric, teMetric: neighbor.metric}
+	for i := 0; i+2 <= len(neighbor.subTLVs); i += 2 + int(neighbor.subTLVs[i+1]) {
+		length := int(neighbor.subTLVs[i+1])
+		if i+2+length > len(neighbor.subTLVs) {
+			glog.Infof("Malformed sub-TLVs for %s: %v", neighbor.systemID, neighbor.subTLVs)
+			break
+		}
+		value := neighbor.subTLVs[i+2 : i+2+length]
+		switch {
+		case neighbor.subTLVs[i] == ADMIN_GROUP_SUB_TLV && length == 4:
+			link.adminGroup = binary.BigEndian.Uint32(value)
+		case neighbor.subTLVs[i] == IPV4_INTF_ADDR_SUB_TLV && length == 4:
+			link.localIP = net.IP(append([]byte{}, value...))
+		case neighbor.subTLVs[i] == IPV4_NEIGHBOR_ADDR_SUB_TLV && length == 4:
+			link.remoteIP = net.IP(append([]byte{}, value...))
+		case neighbor.subTLVs[i] == MAX_BANDWIDTH_SUB_TLV && length == 4:
+			link.maxBandwidth = math.Float32frombits(binary.BigEndian.Uint32(value))
+		case neighbor.subTLVs[i] == MAX_RESERVABLE_BANDWIDTH_SUB_TLV && length == 4:
+			link.reservableBandwidth = math.Float32frombits(binary.BigEndian.Uint32(value))
+		case neighbor.subTLVs[i] == TE_METRIC_SUB_TLV && length == 3:
+			link.teMetric = uint32(value[0])<<16 | uint32(value[1])<<8 | uint32(value[2])
+		}
+	}
+	return link
+}
+
+func buildTEDB(updateDB *IsisDB, teDB *IsisDB) {
+	// Rebuild the TE database from the standard topology links of every
+	// node's LSP fragments
+	updateDB.DBLock.Lock()
+	nodes := make(map[string]*TENode)
+	var root *AvlNode
+	for _, avlNode := range AvlGetAll(updateDB.Root) {
+		lsp := avlNode.data.(*IsisLsp)
+		nodeID := nodeIDToString(lsp.LspID[:7])
+		node, ok := nodes[nodeID]
+		if !ok {
+			node = &TENode{systemID: nodeID, links: make([]*TELink, 0)}
+			nodes[nodeID] = node
+			root = AvlInsert(root, systemIDToKey(nodeID), node, true)
+		}
+		if lsp.LspID[7] == 0 && !isPseudonode(nodeID) {
+			node.overloaded = lspOverloaded(lsp)
+		}
+		for _, neighbor := range lookupNeighbors(lsp, MT_STANDARD) {
+			node.links = append(node.links, getTELink(neighbor))
+		}
+	}
+	updateDB.DBLock.Unlock()
+	teDB.DBLock.Lock()
+	teDB.Root = root
+	teDB.DBLock.Unlock()
+}
+
+func linkAllowed(link *TELink, constraints *PathConstraints) bool {
+	if link.reservableBandwidth < constraints.bandwidth {
+		return false
+	}
+	if link.adminGroup&constraints.excludeAny != 0 {
+		return false
+	}
+	if constraints.includeAny != 0 && link.adminGroup&constraints.includeAny == 0 {
+		return false
+	}
+	return link.adminGroup&constraints.includeAll == constraints.includeAll
+}
+
+func hasLinkTo(node *TENode, systemID string) bool {
+	for _, link := range node.links {
+		if link.neighbor == systemID {
+			return true
+		}
+	}
+	return false
+}
+
+func computePath(teDB *IsisDB, source string, destination string, constraints *PathConstraints) ([]*PathHop, error) {
+	// Constrained SPF from source to destination over the links which meet
+	// the constraints and are advertised by both ends. Links out of a
+	// pseudonode carry no TE attributes, the link into it from the router
+	// does. Overloaded nodes are never used for transit. Nothing is reserved
+	// or signalled, the bandwidth is the reservable bandwidth as advertised
+	teDB.DBLock.Lock()
+	defer teDB.DBLock.Unlock()
+	nodes := make(map[string]*TENode)
+	for _, avlNode := range AvlGetAll(teDB.Root) {
+		node := avlNode.data.(*TENode)
+		nodes[node.systemID] = node
+	}
+	if nodes[source] == nil {
+		return nil, fmt.Errorf("unknown source %s", source)
+	}
+	if nodes[destination] == nil {
+		return nil, fmt.Errorf("unknown destination %s", destination)
+	}
+	tent := map[string]*PathHop{source: &PathHop{systemID: source}}
+	previous := make(map[string]string)
+	paths := make(map[string]*PathHop)
+	for len(tent) > 0 {
+		var best *PathHop
+		for _, hop := range tent {
+			if best == nil || hop.cost < best.cost || (hop.cost == best.cost && hop.systemID < best.systemID) {
+				best = hop
+			}
+		}
+		delete(tent, best.systemID)
+		paths[best.systemID] = best
+		if best.systemID == destination {
+			break
+		}
+		node := nodes[best.systemID]
+		if node.overloaded && best.systemID != source {
+			continue
+		}
+		for _, link := range node.links {
+			if _, done := paths[link.neighbor]; done {
+				continue
+			}
+			if !isPseudonode(node.systemID) && !linkAllowed(link, constraints) {
+				continue
+			}
+			neighbor := nodes[link.neighbor]
+			if neighbor == nil || !hasLinkTo(neighbor, node.systemID) {
+				continue
+			}
+			cost := best.cost + link.metric
+			if constraints.teMetric {
+				cost = best.cost + link.teMetric
+			}
+			if hop, ok := tent[link.neighbor]; !ok || cost < hop.cost {
+				tent[link.neighbor] = &PathHop{systemID: link.neighbor, cost: cost, link: link}
+				previous[link.neighbor] = node.systemID
+			}
+		}
+	}
+	if paths[destination] == nil {
+		return nil, fmt.Errorf("no path from %s to %s meets the constraints", source, destination)
+	}
+	hops := []*PathHop{paths[destination]}
+	for hop := destination; hop != source; {
+		hop = previous[hop]
+		hops = append([]*PathHop{paths[hop]}, hops...)
+	}
+	glog.V(1).Infof("CSPF: Path from %s to %s %v", source, destination, hops)
+	return hops, nil
+}
+
+func resolveSystemID(name string) (string, error) {
+	// A system ID, or the hostname of one
+	if len(strings.Replace(name, ".", "", 2)) == 12 && strings.Count(name, ".") == 2 {
+		return name, nil
+	}
+	hostnamesLock.Lock()
+	defer hostnamesLock.Unlock()
+	for systemID, hostname := range hostnames {
+		if hostname == name {
+			return systemID, nil
+		}
+	}
+	return "", fmt.Errorf("unknown system %s", name)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	pb "github.com/connorwstein/go-is-is/config"
+	"golang.org/x/net/context"
+	"net"
+	"testing"
+)
+
+func TestTESubTLVs(t *testing.T) {
+	initConfig()
+	intf := &Intf{circuitType: P2P_CIRCUIT, prefix: net.IP{10, 0, 0, 1}, teMetric: 30, maxBandwidth: 1000000000, reservableBandwidth: 800000000, adminGroup: 0x5,
+		adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, neighborIP: net.IP{10, 0, 0, 2}}}}
+	neighbors := getExtendedNeighbors(getExtendedNeighborTLV([]*Intf{intf}, LEVEL_1))
+	if len(neighbors) != 1 {
+		t.Fatalf("Unexpected neighbors %v", neighbors)
+	}
+	link := getTELink(neighbors[0])
+	if link.metric != DEFAULT_METRIC || link.teMetric != 30 || link.adminGroup != 0x5 || link.maxBandwidth != 125000000 || link.reservableBandwidth != 100000000 ||
+		!link.localIP.Equal(intf.prefix) || !link.remoteIP.Equal(intf.adjacencies[0].neighborIP) {
+		t.Fatalf("Unexpected link %+v", link)
+	}
+	// Without TE attributes the TE metric is the link metric
+	plain := &Intf{circuitType: P2P_CIRCUIT, prefix: net.IP{10, 0, 0, 1}, adjacencies: intf.adjacencies}
+	tlv := getExtendedNeighborTLV([]*Intf{plain}, LEVEL_1)
+	if link := getTELink(getExtendedNeighbors(tlv)[0]); tlv.lengthTLV != 11 || link.teMetric != DEFAULT_METRIC || link.localIP != nil {
+		t.Fatalf("Unexpected link %+v", link)
+	}
+}
+
+func buildTELsp(systemID string, intfs []*Intf) *IsisLsp {
+	lsp := buildEmptyLSP(LEVEL_1, 1, systemID)
+	lsp.CoreLsp.FirstTLV = getExtendedNeighborTLV(intfs, LEVEL_1)
+	return lsp
+}
+
+func buildTELink(local byte, neighbor byte, teMetric uint32, reservableBandwidth uint64, adminGroup uint32) *Intf {
+	return &Intf{circuitType: P2P_CIRCUIT, prefix: net.IP{10, 0, local, neighbor}, teMetric: teMetric, reservableBandwidth: reservableBandwidth, adminGroup: adminGroup,
+		adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x10 + neighbor}}}}
+}
+
+func TestComputePath(t *testing.T) {
+	// TOPO: R1 -- R2 -- R4 and R1 -- R3 -- R4, all at the same IGP metric. R1 -- R2 has
+	// little bandwidth and a high TE metric, the R2 links are in group 0x1, the R3 links 0x2
+	initConfig()
+	updateDBInit()
+	teDBInit()
+	lsps := []*IsisLsp{
+		buildTELsp("1111.1111.1111", []*Intf{buildTELink(1, 2, 50, 100000000, 0x1), buildTELink(1, 3, 0, 1000000000, 0x2)}),
+		buildTELsp("1111.1111.1112", []*Intf{buildTELink(2, 1, 50, 100000000, 0x1), buildTELink(2, 4, 0, 1000000000, 0x1)}),
+		buildTELsp("1111.1111.1113", []*Intf{buildTELink(3, 1, 0, 1000000000, 0x2), buildTELink(3, 4, 0, 1000000000, 0x2)}),
+		buildTELsp("1111.1111.1114", []*Intf{buildTELink(4, 2, 0, 1000000000, 0x1), buildTELink(4, 3, 0, 1000000000, 0x2)}),
+	}
+	for _, lsp := range lsps {
+		UpdateDB.Root = AvlInsert(UpdateDB.Root, lspIDToKey(lsp.LspID), lsp, false)
+	}
+	buildTEDB(UpdateDB, TEDB)
+	for _, test := range []struct {
+		constraints PathConstraints
+		via         string
+		cost        uint32
+	}{
+		{PathConstraints{}, "1111.1111.1112", 20},
+		{PathConstraints{teMetric: true}, "1111.1111.1113", 20},
+		{PathConstraints{bandwidth: 500000000 / 8}, "1111.1111.1113", 20},
+		{PathConstraints{excludeAny: 0x2, teMetric: true}, "1111.1111.1112", 60},
+		{PathConstraints{includeAny: 0x3}, "1111.1111.1112", 20},
+	} {
+		hops, err := computePath(TEDB, "1111.1111.1111", "1111.1111.1114", &test.constraints)
+		if err != nil || len(hops) != 3 || hops[1].systemID != test.via || hops[2].cost != test.cost {
+			t.Fatalf("Expected a path via %s with cost %d for %+v, got %v %v", test.via, test.cost, test.constraints, hops, err)
+		}
+		if hops[1].link.localIP.String() != "10.0.1."+test.via[13:] {
+			t.Fatalf("Unexpected link %+v", hops[1].link)
+		}
+	}
+	// No link is in both groups
+	if _, err := computePath(TEDB, "1111.1111.1111", "1111.1111.1114", &PathConstraints{includeAll: 0x3}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestTEAttributeChange(t *testing.T) {
+	// A new TE metric on one of our links regenerates our LSPs, after which SPF
+	// runs and rebuilds the TE database ComputePath uses
+	initConfig()
+	cfg.sid = "1111.1111.1111"
+	cfg.metricStyle = METRIC_STYLE_WIDE
+	updateDBInit()
+	teDBInit()
+	intf := buildTELink(1, 2, 0, 0, 0)
+	intf.name, intf.level = "eth0", LEVEL_1
+	intf.lspFloodStates = [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}
+	cfg.interfaces = []*Intf{intf}
+	if _, err := (&server{}).ConfigureIntf(context.Background(), &pb.IntfCfgRequest{Name: "eth0", TeMetric: 50}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lspTrigger:
+	default:
+		t.Fatalf("Expected our LSPs to be regenerated")
+	}
+	triggerSPF := make(chan bool, 1)
+	runLocalLsp(triggerSPF)
+	select {
+	case <-triggerSPF:
+	default:
+		t.Fatalf("Expected SPF to run")
+	}
+	buildTEDB(UpdateDB, TEDB)
+	node := AvlSearch(TEDB.Root, systemIDToKey(cfg.sid))
+	if node == nil || len(node.(*TENode).links) != 1 || node.(*TENode).links[0].teMetric != 50 {
+		t.Fatalf("Expected our link with a TE metric of 50, got %v", node)
+	}
+	initConfig()
+}
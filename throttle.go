@@ -0,0 +1,109 @@
+// SPF and LSP generation throttling.
+// Triggers are coalesced into one run, with waits backing off exponentially.
+// +build linux
+
+package main
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"time"
+)
+
+const (
+	THROTTLE_SPF               = "spf"
+	THROTTLE_LSP               = "lsp"
+	DEFAULT_SPF_INITIAL_WAIT   = 50 // Milliseconds
+	DEFAULT_SPF_SECONDARY_WAIT = 200
+	DEFAULT_SPF_MAX_WAIT       = 5000
+	DEFAULT_LSP_INITIAL_WAIT   = 50
+	DEFAULT_LSP_SECONDARY_WAIT = 200
+	DEFAULT_LSP_MAX_WAIT       = 5000
+	MAX_THROTTLE_WAIT          = 120000
+)
+
+type ThrottleTimers struct {
+	initialWait   uint32 // Milliseconds
+	secondaryWait uint32
+	maxWait       uint32
+}
+
+func (t ThrottleTimers) String() string {
+	return fmt.Sprintf("initial %dms secondary %dms max %dms", t.initialWait, t.secondaryWait, t.maxWait)
+}
+
+type throttle struct {
+	wait    time.Duration // Before the next run
+	lastRun time.Time
+}
+
+// Triggers for the generation of our LSPs, a pending one covers any more
+var lspTrigger = make(chan bool, 1)
+
+func scheduleSPF(triggerSPF chan bool) {
+	select {
+	case triggerSPF <- true:
+	default:
+		// Already pending
+	}
+}
+
+func scheduleLocalLsp() {
+	select {
+	case lspTrigger <- true:
+	default:
+	}
+}
+
+func getThrottleTimers(name string) ThrottleTimers {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+	if name == THROTTLE_LSP {
+		return cfg.lspThrottle
+	}
+	return cfg.spfThrottle
+}
+
+func (t *throttle) getWait(timers ThrottleTimers, now time.Time) time.Duration {
+	// The wait for a trigger arriving now, backing off the wait for the next one.
+	// After a quiet period of the maximum wait it is the initial wait, then the
+	// secondary wait, then twice the one before each time up to the maximum
+	initialWait := time.Duration(timers.initialWait) * time.Millisecond
+	secondaryWait := time.Duration(timers.secondaryWait) * time.Millisecond
+	maxWait := time.Duration(timers.maxWait) * time.Millisecond
+	if t.lastRun.IsZero() || now.Sub(t.lastRun) >= maxWait {
+		t.wait = initialWait
+	}
+	wait := t.wait
+	if wait > maxWait {
+		// The timers were lowered since
+		wait = maxWait
+	}
+	if t.wait < secondaryWait {
+		t.wait = secondaryWait
+	} else {
+		t.wait *= 2
+	}
+	if t.wait > maxWait {
+		t.wait = maxWait
+	}
+	return wait
+}
+
+func isisThrottle(name string, trigger chan bool, run func()) {
+	// Wait out the throttle for each trigger and then run, once for every
+	// trigger that came in meanwhile
+	var t throttle
+	for {
+		<-trigger
+		wait := t.getWait(getThrottleTimers(name), time.Now())
+		glog.V(2).Infof("Throttle: Running %s in %v", name, wait)
+		time.Sleep(wait)
+		select {
+		case <-trigger:
+		default:
+		}
+		run()
+		t.lastRun = time.Now()
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleWait(t *testing.T) {
+	timers := ThrottleTimers{initialWait: 50, secondaryWait: 200, maxWait: 1000}
+	var th throttle
+	now := time.Now()
+	// Back to back runs back off up to the maximum wait
+	for _, expected := range []time.Duration{50, 200, 400, 800, 1000, 1000} {
+		if wait := th.getWait(timers, now); wait != expected*time.Millisecond {
+			t.Fatalf("Expected a wait of %dms, got %v", expected, wait)
+		}
+		now = now.Add(10 * time.Millisecond)
+		th.lastRun = now
+	}
+	// A quiet period starts over
+	now = now.Add(time.Second)
+	if wait := th.getWait(timers, now); wait != 50*time.Millisecond {
+		t.Fatalf("Expected the initial wait, got %v", wait)
+	}
+	// Lower timers apply straight away
+	th.lastRun = now
+	timers.maxWait = 100
+	if wait := th.getWait(timers, now); wait != 100*time.Millisecond {
+		t.Fatalf("Expected the maximum wait, got %v", wait)
+	}
+}
+
+func TestThrottleCoalesce(t *testing.T) {
+	// Triggers arriving while a run is pending make a single run
+	initConfig()
+	trigger := make(chan bool, 1)
+	runs := make(chan bool, 10)
+	go isisThrottle(THROTTLE_SPF, trigger, func() { runs <- true })
+	for i := 0; i < 5; i++ {
+		scheduleSPF(trigger)
+	}
+	<-runs
+	select {
+	case <-runs:
+		t.Fatalf("Expected a single run")
+	case <-time.After(time.Duration(DEFAULT_SPF_SECONDARY_WAIT) * time.Millisecond):
+	}
+}
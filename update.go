@@ -9,20 +9,37 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"flag"
 	"fmt"
 	"github.com/golang/glog"
 	"net"
+	"strings"
 	"sync"
 	"time"
 	"unsafe"
 )
 
 const (
-	LSP_REFRESH = 5000
+	L1_LSP_PDU_TYPE = 0x12
+	L2_LSP_PDU_TYPE = 0x14
+	LSP_REFRESH     = 5000
+	DEFAULT_METRIC  = 10
+	// Bounds on the originating LSP buffer size in bytes, the LSP fragments we generate
+	// can't be bigger than what fits in an ethernet frame
+	DEFAULT_LSP_BUFFER_SIZE = 1492
+	MIN_LSP_BUFFER_SIZE     = 512
+	MAX_LSP_BUFFER_SIZE     = 1500
+	LSP_HEADER_SIZE         = 27 // Common PDU header and LSP header
+	MAX_LSP_FRAGMENTS       = 256
 )
 
-var UpdateDB *IsisDB
-var sequenceNumber uint32
+var UpdateDB *IsisDB   // Level 1
+var L2UpdateDB *IsisDB // Level 2
+
+var sequenceNumber [2]uint32 // Indexed by level - 1
+var fragmentCount [2]int     // Fragments in our current LSP, indexed by level - 1
+
+var lspBufferSize = flag.Int("lsp_buffer_size", DEFAULT_LSP_BUFFER_SIZE, "Originating LSP buffer size, the largest LSP fragment we generate")
 
 type IsisLspHeader struct {
 	LengthPDU         [2]byte
@@ -44,16 +61,29 @@ type IsisLsp struct {
 	Key     uint64 // Used for key in the UpdateDB
 	LspID   [8]byte
 	CoreLsp *IsisLspCore
+	// Seconds left before a purged LSP is deleted
+	zeroAgeLifetime uint16
 }
 
 func (lsp IsisLsp) String() string {
 	var lspString bytes.Buffer
-	lspString.WriteString(fmt.Sprintf("%s", systemIDToString(lsp.LspID[:6])))
+	lspString.WriteString(fmt.Sprintf("%s Seq %d Lifetime %d Checksum 0x%04x\n", nodeIDName(lsp.LspID[:7]),
+		binary.BigEndian.Uint32(lsp.CoreLsp.LspHeader.SequenceNumber[:]), getRemainingLifetime(&lsp),
+		binary.BigEndian.Uint16(lsp.CoreLsp.LspHeader.Checksum[:])))
+	if lspOverloaded(&lsp) {
+		lspString.WriteString("\tOverloaded\n")
+	}
 	var curr *IsisTLV = lsp.CoreLsp.FirstTLV
 	for curr != nil {
 		lspString.WriteString(fmt.Sprintf("\tTLV %d\n", curr.typeTLV))
 		lspString.WriteString(fmt.Sprintf("\tTLV size %d\n", curr.lengthTLV))
-		if curr.typeTLV == ISIS_IP_INTERNAL_REACH_TLV {
+		if curr.typeTLV == ISIS_AREA_ADDRESSES_TLV {
+			for _, area := range parseAreaAddresses(curr) {
+				lspString.WriteString(fmt.Sprintf("\t\tArea %s\n", areaToString(area)))
+			}
+		} else if curr.typeTLV == ISIS_HOSTNAME_TLV {
+			lspString.WriteString(fmt.Sprintf("\t\tHostname %s\n", string(curr.valueTLV)))
+		} else if curr.typeTLV == ISIS_IP_INTERNAL_REACH_TLV {
 			// This is a external reachability tlv
 			// TODO: fix hard coding here
 			for i := 0; i < int(curr.lengthTLV)/12; i++ {
@@ -63,13 +93,41 @@ func (lsp IsisLsp) String() string {
 				metric := curr.valueTLV[i*12+8 : i*12+12]
 				lspString.WriteString(fmt.Sprintf("\t\t%s Metric %d\n", prefix.String(), binary.BigEndian.Uint32(metric[:])))
 			}
+		} else if curr.typeTLV == ISIS_EXTENDED_IP_REACH_TLV {
+			for _, prefix := range getExtendedPrefixes(curr) {
+				lspString.WriteString(fmt.Sprintf("\t\t%s Metric %d Up/Down %v\n", prefix.prefix.String(), prefix.metric, prefix.upDown))
+			}
+		} else if curr.typeTLV == ISIS_IPV6_REACH_TLV {
+			for _, prefix := range getIPv6PrefixesFromTLV(curr) {
+				lspString.WriteString(fmt.Sprintf("\t\t%s Metric %d Up/Down %v\n", prefix.prefix.String(), prefix.metric, prefix.upDown))
+			}
+		} else if curr.typeTLV == ISIS_IPV6_INTF_ADDR_TLV {
+			for _, address := range getIPv6InterfaceAddresses(curr) {
+				lspString.WriteString(fmt.Sprintf("\t\tIPv6 address %s\n", address.String()))
+			}
+		} else if curr.typeTLV == ISIS_MT_TLV {
+			lspString.WriteString(fmt.Sprintf("\t\tTopologies %s\n", mtIDsToString(getMTIDs(curr))))
+		} else if curr.typeTLV == ISIS_MT_IS_REACH_TLV {
+			for _, neighbor := range getExtendedNeighbors(stripMTID(curr)) {
+				lspString.WriteString(fmt.Sprintf("\t\t%s %s Metric %d\n", topologyToString(getMTID(curr)), withHostname(neighbor.systemID), neighbor.metric))
+			}
+		} else if curr.typeTLV == ISIS_MT_IPV6_REACH_TLV {
+			for _, prefix := range getIPv6PrefixesFromTLV(stripMTID(curr)) {
+				lspString.WriteString(fmt.Sprintf("\t\t%s %s Metric %d Up/Down %v\n", topologyToString(getMTID(curr)), prefix.prefix.String(), prefix.metric, prefix.upDown))
+			}
+		} else if curr.typeTLV == ISIS_PROTOCOLS_SUPPORTED_TLV {
+			lspString.WriteString(fmt.Sprintf("\t\tNLPIDs % x\n", curr.valueTLV))
+		} else if curr.typeTLV == ISIS_EXTENDED_IS_REACH_TLV {
+			for _, neighbor := range getExtendedNeighbors(curr) {
+				lspString.WriteString(fmt.Sprintf("\t\t%s Metric %d\n", withHostname(neighbor.systemID), neighbor.metric))
+			}
 		} else if curr.typeTLV == ISIS_NEIGHBORS_TLV {
 			// This is a neighbors tlv, its length - 1 (to exclude the first virtualByteFlag) will be a multiple of 11
 			for i := 0; i < int(curr.lengthTLV-1)/11; i++ {
-				// print out the neighbor system ids and metric
+				// print out the neighbor node ids and metric
 				metric := curr.valueTLV[i*11+4]
-				systemID := curr.valueTLV[(i*11 + 1 + 4):(i*11 + 1 + 4 + 6)]
-				lspString.WriteString(fmt.Sprintf("\t\t%s Metric %d\n", systemIDToString(systemID), metric))
+				nodeID := curr.valueTLV[(i*11 + 1 + 4):(i*11 + 1 + 4 + 7)]
+				lspString.WriteString(fmt.Sprintf("\t\t%s Metric %d\n", nodeIDName(nodeID), metric))
 			}
 		}
 		curr = curr.nextTLV
@@ -80,38 +138,47 @@ func (lsp IsisLsp) String() string {
 type IsisDB struct {
 	DBLock sync.Mutex
 	Root   *AvlNode
+	Level  byte // Each level has its own databases
 	// May want to add more information here
 }
 
 type Neighbor struct {
 	systemID string
 	metric   uint32
+	subTLVs  []byte // Adjacency SIDs and TE attributes of the link in TLV 22
 }
 
 func updateDBInit() {
-	UpdateDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil}
+	UpdateDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_1}
+	L2UpdateDB = &IsisDB{DBLock: sync.Mutex{}, Root: nil, Level: LEVEL_2}
+}
+
+func getUpdateDB(level byte) *IsisDB {
+	if level == LEVEL_2 {
+		return L2UpdateDB
+	}
+	return UpdateDB
 }
 
-func floodNewLsp(receiveIntf *Intf, receivedLsp *IsisLsp) {
+func floodNewLsp(receiveIntf *Intf, level byte, receivedLsp *IsisLsp) {
 	// Add this new LSP to all interfaces floodStates, and set SRM to true for all of them EXCEPT this interface which we
-	// received it from
+	// received it from. Only interfaces running the level of the LSP flood it
 	for _, intf := range cfg.interfaces {
 		glog.V(2).Infof("Locking interface %s", intf.name)
 		intf.lock.Lock()
 		if receiveIntf.name == intf.name {
+			// We don't need to send it back and if we had requested it
+			// with a PSNP that request has now been satisfied. Point-to-point
+			// circuits need to explicitly acknowledge it with a PSNP though
+			setFloodFlags(intf, level, receivedLsp.LspID, false, intf.circuitType == P2P_CIRCUIT)
+		} else if intf.level&level != 0 {
+			glog.Infof("Flooding new %s lsp %s out interface: %s", levelToString(level), nodeIDName(receivedLsp.LspID[:7]), intf.name)
 			// If it is already there, just set SRM to true
-			if _, inMap := intf.lspFloodStates[receivedLsp.Key]; !inMap {
-				intf.lspFloodStates[receivedLsp.Key] = &LspFloodState{LspIDKey: receivedLsp.Key, LspID: receivedLsp.LspID, SRM: false, SSN: false}
-			} else {
-				intf.lspFloodStates[receivedLsp.Key].SRM = false
-			}
-		} else {
-			glog.Infof("Flooding new lsp %s out interface: %s", systemIDToString(receivedLsp.LspID[:6]), intf.name)
-			// If it is already there, just set SRM to true
-			if _, inMap := intf.lspFloodStates[receivedLsp.Key]; !inMap {
-				intf.lspFloodStates[receivedLsp.Key] = &LspFloodState{LspIDKey: receivedLsp.Key, LspID: receivedLsp.LspID, SRM: true, SSN: false}
+			lspFloodStates := intf.lspFloodStates[level-1]
+			if _, inMap := lspFloodStates[receivedLsp.Key]; !inMap {
+				lspFloodStates[receivedLsp.Key] = &LspFloodState{LspIDKey: receivedLsp.Key, LspID: receivedLsp.LspID, SRM: true, SSN: false}
 			} else {
-				intf.lspFloodStates[receivedLsp.Key].SRM = true
+				lspFloodStates[receivedLsp.Key].SRM = true
 			}
 		}
 		glog.V(2).Infof("Unlocking interface %s", intf.name)
@@ -119,46 +186,136 @@ func floodNewLsp(receiveIntf *Intf, receivedLsp *IsisLsp) {
 	}
 }
 
+func receiveLsp(receiveIntf *Intf, db *IsisDB, receivedLsp *IsisLsp) bool {
+	// Check if we already have this LSP, if not, then insert it
+	// into our own DB an flood it along to all the other interfaces we have
+	// If we already have a copy and the sequence number is newer, overwrite.
+	// If we have a newer copy, send the newer copy back to the source.
+	// Returns whether the database changed such that SPF needs to run
+	spf := false
+	receivedLifetime := getRemainingLifetime(receivedLsp)
+	db.DBLock.Lock()
+	tmp := AvlSearch(db.Root, receivedLsp.Key)
+	if tmp == nil && receivedLifetime == 0 {
+		// A purge of something we never had, nothing to remove. Point-to-point
+		// circuits still need to acknowledge it
+		glog.Infof("Received purge of unknown lsp %s", nodeIDName(receivedLsp.LspID[:7]))
+		receiveIntf.lock.Lock()
+		if receiveIntf.circuitType == P2P_CIRCUIT {
+			setFloodFlags(receiveIntf, db.Level, receivedLsp.LspID, false, true)
+		}
+		receiveIntf.lock.Unlock()
+	} else if tmp == nil {
+		// Don't have this LSP so lets add it
+		glog.Infof("Adding new lsp %s (%v) to DB", nodeIDName(receivedLsp.LspID[:7]), receivedLsp.Key)
+		db.Root = AvlInsert(db.Root, receivedLsp.Key, receivedLsp, false)
+		updateHostnames(receivedLsp)
+		printUpdateDB(db.Root)
+		// Receiving a brand new LSP triggers an SPF
+		spf = true
+		floodNewLsp(receiveIntf, db.Level, receivedLsp)
+	} else {
+		// We do have this LSP, check if the sequence number is newer than the current version we have if it is then update
+		lsp := tmp.(*IsisLsp)
+		ourSeq := binary.BigEndian.Uint32(lsp.CoreLsp.LspHeader.SequenceNumber[:])
+		receivedSeq := binary.BigEndian.Uint32(receivedLsp.CoreLsp.LspHeader.SequenceNumber[:])
+		comparison := compareLsp(receivedSeq, receivedLifetime, ourSeq, getRemainingLifetime(lsp))
+		// Two different LSPs with the same sequence number. Our own gets a new
+		// sequence number, anyone else's is purged so the originator does the same
+		checksumMismatch := comparison == 0 && receivedLifetime != 0 &&
+			receivedLsp.CoreLsp.LspHeader.Checksum != lsp.CoreLsp.LspHeader.Checksum
+		switch {
+		case checksumMismatch:
+			glog.Infof("Received lsp %s with the same sequence number but a different checksum", nodeIDName(receivedLsp.LspID[:7]))
+			if !isOwnLsp(lsp, cfg.sid) {
+				purgeLsp(db, lsp)
+			}
+			spf = true
+		case comparison == 1:
+			// Received one is newer, update and flood
+			glog.Infof("Overwriting new lsp %s (%v) to DB", nodeIDName(receivedLsp.LspID[:7]), receivedLsp.Key)
+			if receivedLifetime == 0 {
+				// Someone else purged it, hold on to the purge before deleting it
+				receivedLsp.zeroAgeLifetime = ZERO_AGE_LIFETIME
+			}
+			db.Root = AvlInsert(db.Root, receivedLsp.Key, receivedLsp, true)
+			updateHostnames(receivedLsp)
+			printUpdateDB(db.Root)
+			// Receiving newer LSP also triggers an SPF
+			spf = true
+			floodNewLsp(receiveIntf, db.Level, receivedLsp)
+		case comparison == -1:
+			// Our neighbor is out of date, send ours back out the receiving interface
+			glog.Infof("Received older lsp %s on %s, sending ours back", nodeIDName(receivedLsp.LspID[:7]), receiveIntf.name)
+			receiveIntf.lock.Lock()
+			setFloodFlags(receiveIntf, db.Level, lsp.LspID, true, false)
+			receiveIntf.lock.Unlock()
+		default:
+			// Same LSP, on a LAN this acts as an implicit acknowledgement so there is
+			// no need for us to send it out this interface. On point-to-point circuits
+			// our neighbor is still waiting for an acknowledgement
+			receiveIntf.lock.Lock()
+			setFloodFlags(receiveIntf, db.Level, lsp.LspID, false, receiveIntf.circuitType == P2P_CIRCUIT)
+			receiveIntf.lock.Unlock()
+		}
+	}
+	db.DBLock.Unlock()
+	return spf
+}
+
 func isisUpdateInput(receiveIntf *Intf, update chan []byte, triggerSPF chan bool) {
 	// Need to flood it along to every interface, except the one it came from
 	// The one it came from is the one we are listening on
 	// This lsp is a raw buffer [READ_BUF_SIZE]byte, need to deserialize
+	// SNPs are also part of the update process and arrive on the same channel
 	for {
-		lsp := <-update
-		receivedLsp := deserializeLsp(lsp[:])
-		glog.V(2).Infof("Got lsp update %s sequence number %d", systemIDToString(receivedLsp.LspID[:6]), binary.BigEndian.Uint32(receivedLsp.CoreLsp.LspHeader.SequenceNumber[:]))
-		glog.V(4).Infof(hex.Dump(lsp[:]))
-		// Check if we already have this LSP, if not, then insert it
-		// into our own DB an flood it along to all the other interfaces we have
-		// If we already have a copy and the sequence number is newer, overwrite.
-		// TODO: If we have a newer copy, send the newer copy back to the source
-		spf := false
-		UpdateDB.DBLock.Lock()
-		tmp := AvlSearch(UpdateDB.Root, receivedLsp.Key)
-		if tmp == nil {
-			// Don't have this LSP so lets add it
-			glog.Infof("Adding new lsp %s (%v) to DB", systemIDToString(receivedLsp.LspID[:6]), receivedLsp.Key)
-			UpdateDB.Root = AvlInsert(UpdateDB.Root, receivedLsp.Key, receivedLsp, false)
-			printUpdateDB(UpdateDB.Root)
-			// Receiving a brand new LSP triggers an SPF
-			spf = true
-			floodNewLsp(receiveIntf, receivedLsp)
-		} else {
-			// We do have this LSP, check if the sequence number is newer than the current version we have if it is then update
-			lsp := tmp.(*IsisLsp)
-			if binary.BigEndian.Uint32(lsp.CoreLsp.LspHeader.SequenceNumber[:]) < binary.BigEndian.Uint32(receivedLsp.CoreLsp.LspHeader.SequenceNumber[:]) {
-				// Received one is newer, update and flood
-				glog.Infof("Overwriting new lsp %s (%v) to DB", systemIDToString(receivedLsp.LspID[:6]), receivedLsp.Key)
-				UpdateDB.Root = AvlInsert(UpdateDB.Root, receivedLsp.Key, receivedLsp, true)
-				printUpdateDB(UpdateDB.Root)
-				// Receiving newer LSP also triggers an SPF
-				spf = true
-				floodNewLsp(receiveIntf, receivedLsp)
+		pdu := <-update
+		level := byte(LEVEL_1)
+		if pdu[14+4] == L2_LSP_PDU_TYPE {
+			level = LEVEL_2
+		} else if pdu[14+4] != L1_LSP_PDU_TYPE {
+			isisSnpInput(receiveIntf, pdu)
+			continue
+		}
+		receiveIntf.lock.Lock()
+		running := receiveIntf.level&level != 0
+		receiveIntf.lock.Unlock()
+		if !running {
+			glog.V(1).Infof("Got a %s lsp on %s which does not run that level, dropping", levelToString(level), receiveIntf.name)
+			continue
+		}
+		if !verifyLspChecksum(pdu[14:]) {
+			receiveIntf.lock.Lock()
+			receiveIntf.lspChecksumErrors++
+			receiveIntf.lock.Unlock()
+			glog.Infof("Got a %s lsp %s on %s with a bad checksum, dropping", levelToString(level), nodeIDName(pdu[14+12:14+19]), receiveIntf.name)
+			continue
+		}
+		receivedLsp := deserializeLsp(pdu[:])
+		glog.V(2).Infof("Got lsp update %s sequence number %d", nodeIDName(receivedLsp.LspID[:7]), binary.BigEndian.Uint32(receivedLsp.CoreLsp.LspHeader.SequenceNumber[:]))
+		glog.V(4).Infof(hex.Dump(pdu[:]))
+		spf := receiveLsp(receiveIntf, getUpdateDB(level), receivedLsp)
+		if spf && receivedLsp.LspID[6] == 0 && isOwnLsp(receivedLsp, cfg.sid) {
+			if isRestarting() {
+				// Replaced once the database is synchronized
+				noteOwnLsp(receivedLsp)
+			} else if int(receivedLsp.LspID[7]) >= fragmentCount[level-1] {
+				// A fragment we no longer generate, get rid of it
+				if getRemainingLifetime(receivedLsp) != 0 {
+					purgeLocalFragments(getUpdateDB(level), int(receivedLsp.LspID[7]), int(receivedLsp.LspID[7])+1)
+				}
+			} else {
+				// Someone has a newer copy of our own LSP, perhaps from before we
+				// restarted or a purge. Take over its sequence number and regenerate
+				glog.Infof("Received a newer copy of our own %s lsp, regenerating", levelToString(level))
+				sequenceNumber[level-1] = binary.BigEndian.Uint32(receivedLsp.CoreLsp.LspHeader.SequenceNumber[:])
+				scheduleLocalLsp()
 			}
 		}
-		UpdateDB.DBLock.Unlock()
 		glog.V(2).Infof("SPF trigger %v", spf)
-		triggerSPF <- spf
+		if spf {
+			scheduleSPF(triggerSPF)
+		}
 	}
 }
 
@@ -166,32 +323,43 @@ func isisUpdate(intf *Intf, send chan []byte) {
 	for {
 		glog.V(2).Infof("Locking interface %s", intf.name)
 		intf.lock.Lock()
-		glog.Info("LSP DB:")
-		printUpdateDB(UpdateDB.Root)
-		glog.Infof("Intf %s Flood States", intf.name)
-		printLspFloodStates(intf)
-		// Check for SRM == true on this interface, if there
-		// then use the key to get the full LSP, send it and clear the flag
-		for _, lspFloodState := range intf.lspFloodStates {
-			// Need the adjacency to be UP as well
-			if lspFloodState.SRM && intf.adj.state == "UP" {
-				tmp := AvlSearch(UpdateDB.Root, lspFloodState.LspIDKey)
-				if tmp == nil {
-					glog.Errorf("Unable to find %s (%v) in lsp db", systemIDToString(lspFloodState.LspID[:6]), lspFloodState.LspIDKey)
-					glog.Errorf("Lsp DB:")
-					printUpdateDB(UpdateDB.Root)
-				} else {
-					lsp := tmp.(*IsisLsp)
-					// Send it out that particular interface
-					glog.Infof("Flooding %s out %s", systemIDToString(lspFloodState.LspID[:6]), intf.name)
-					send <- buildEthernetFrame(l1_multicast, getMac(intf.name), serializeLsp(lsp.CoreLsp))
-					// No ACK required for LAN interfaces
-					lspFloodState.SRM = false
+		levels := getLevels(intf.level)
+		for _, level := range levels {
+			db := getUpdateDB(level)
+			glog.Infof("%s LSP DB:", levelToString(level))
+			printUpdateDB(db.Root)
+			glog.Infof("Intf %s %s Flood States", intf.name, levelToString(level))
+			printLspFloodStates(intf, level)
+			// Check for SRM == true on this interface, if there
+			// then use the key to get the full LSP, send it and clear the flag
+			for _, lspFloodState := range intf.lspFloodStates[level-1] {
+				// Need an adjacency at this level to be UP as well
+				if lspFloodState.SRM && hasUpAdjacency(intf, level) {
+					tmp := AvlSearch(db.Root, lspFloodState.LspIDKey)
+					if tmp == nil {
+						glog.Errorf("Unable to find %s (%v) in lsp db", nodeIDName(lspFloodState.LspID[:7]), lspFloodState.LspIDKey)
+						glog.Errorf("Lsp DB:")
+						printUpdateDB(db.Root)
+					} else {
+						lsp := tmp.(*IsisLsp)
+						// Send it out that particular interface
+						glog.Infof("Flooding %s out %s", nodeIDName(lspFloodState.LspID[:7]), intf.name)
+						send <- buildEthernetFrame(getMulticast(intf, level), getMac(intf.name), serializeLsp(lsp.CoreLsp))
+						// No ACK required for LAN interfaces. Point-to-point interfaces leave SRM
+						// set so it is retransmitted every LSP_REFRESH until a PSNP acknowledges it
+						if intf.circuitType == BROADCAST_CIRCUIT {
+							lspFloodState.SRM = false
+						}
+					}
 				}
 			}
 		}
 		glog.V(2).Infof("Unlocking interface %s", intf.name)
 		intf.lock.Unlock()
+		// Request anything we are missing and acknowledge anything flagged with SSN
+		for _, level := range levels {
+			sendPsnp(intf, getUpdateDB(level), send)
+		}
 		time.Sleep(LSP_REFRESH * time.Millisecond)
 	}
 }
@@ -218,25 +386,23 @@ func deserializeLsp(raw_bytes []byte) *IsisLsp {
 }
 
 func serializeLsp(lsp *IsisLspCore) []byte {
+	// Our own LSPs have a zero checksum until they are first serialized,
+	// received LSPs keep the checksum they were originated with
 	var buf bytes.Buffer
+	lsp.Header.LengthPDU = byte(unsafe.Sizeof(lsp.Header) + unsafe.Sizeof(lsp.LspHeader))
+	binary.BigEndian.PutUint16(lsp.LspHeader.LengthPDU[:], uint16(int(lsp.Header.LengthPDU)+getTLVsLength(lsp.FirstTLV)))
 	binary.Write(&buf, binary.BigEndian, lsp.Header)
 	binary.Write(&buf, binary.BigEndian, lsp.LspHeader)
-	tlv := lsp.FirstTLV
-	for tlv != nil {
-		glog.V(2).Info("Serializing tlv:", tlv.typeTLV)
-		binary.Write(&buf, binary.BigEndian, tlv.typeTLV)
-		binary.Write(&buf, binary.BigEndian, tlv.lengthTLV)
-		binary.Write(&buf, binary.BigEndian, tlv.valueTLV)
-		tlv = tlv.nextTLV
+	serializeTLVs(&buf, lsp.FirstTLV)
+	pdu := buf.Bytes()
+	if lsp.LspHeader.Checksum == [2]byte{} {
+		lsp.LspHeader.Checksum = setLspChecksum(pdu)
 	}
-	return buf.Bytes()
+	return pdu
 }
 
 func systemIDToKey(systemID string) uint64 {
-	var lspID [8]byte
-	bytes := systemIDToBytes(systemID)
-	copy(lspID[:], bytes[:])
-	return lspIDToKey(lspID)
+	return lspIDToKey(systemIDToLspID(systemID))
 }
 
 func lspIDToKey(lspID [8]byte) uint64 {
@@ -245,49 +411,68 @@ func lspIDToKey(lspID [8]byte) uint64 {
 }
 
 func systemIDToLspID(systemID string) [8]byte {
+	// Also accepts pseudonode IDs i.e. 1111.1111.1111.03
 	var lspID [8]byte
-	bytes := systemIDToBytes(systemID)
-	copy(lspID[:], bytes[:])
+	nodeID, _ := hex.DecodeString(strings.Replace(systemID, ".", "", 7))
+	copy(lspID[:7], nodeID)
 	return lspID
 }
 
 func getNeighbors(neighborTLV *IsisTLV) []*Neighbor {
-	var neighbors []*Neighbor
+	neighbors := make([]*Neighbor, 0)
+	walkNeighbors(neighborTLV, func(nodeID []byte, metric uint32, subTLVs []byte) {
+		neighbors = append(neighbors, &Neighbor{systemID: nodeIDToString(nodeID), metric: metric})
+	})
+	return neighbors
+}
+
+func walkNeighbors(neighborTLV *IsisTLV, visit func(nodeID []byte, metric uint32, subTLVs []byte)) {
+	// TLV value is 1 virtual byte flag and then n multiples of 4 byte metric and 6 byte system id + 1 byte pseudo-node id
+	// Neighbors on a LAN are the pseudonode, which has a non-zero pseudo-node id
 	neighborCount := (int(neighborTLV.lengthTLV) - 1) / 11
 	glog.V(2).Infof("Neighbor count %d", neighborCount)
-	neighbors = make([]*Neighbor, neighborCount)
-	currentNeighbor := 0
-	currentByte := 1 // Skip first virtual byte
-	for currentNeighbor < neighborCount {
-		// TLV value is 1 virtual byte flag and then n multiples of 4 byte metric and 6 byte system id + 1 byte pseudo-node id
-		// Set pseudo-node id to 0 for now
-		var neighbor Neighbor
-		neighbor.metric = binary.BigEndian.Uint32(neighborTLV.valueTLV[currentByte : currentByte+4])
-		currentByte += 4
-		neighbor.systemID = systemIDToString(neighborTLV.valueTLV[currentByte : currentByte+6])
-		currentByte += 7 // Skip PSN
-		glog.V(2).Infof("Current byte %d", currentByte)
-		neighbors[currentNeighbor] = &neighbor
-		currentNeighbor++
+	for currentByte := 1; currentByte+11 <= 1+neighborCount*11; currentByte += 11 {
+		visit(neighborTLV.valueTLV[currentByte+4:currentByte+11], binary.BigEndian.Uint32(neighborTLV.valueTLV[currentByte:currentByte+4]), nil)
 	}
+}
+
+func lookupNeighbors(lsp *IsisLsp, mtID uint16) []*Neighbor {
+	// Given an LSP returns list of neighbors from all of its neighbor TLVs
+	neighbors := make([]*Neighbor, 0)
+	walkLspNeighbors(lsp, mtID, func(nodeID []byte, metric uint32, subTLVs []byte) {
+		neighbors = append(neighbors, &Neighbor{systemID: nodeIDToString(nodeID), metric: metric, subTLVs: subTLVs})
+	})
 	return neighbors
 }
 
-func lookupNeighbors(lsp *IsisLsp) []*Neighbor {
-	// Given an LSP returns list of neighbors
-	currentTLV := lsp.CoreLsp.FirstTLV
-	for currentTLV != nil {
-		if int(currentTLV.typeTLV) == ISIS_NEIGHBORS_TLV {
-			return getNeighbors(currentTLV)
+func walkLspNeighbors(lsp *IsisLsp, mtID uint16, visit func(nodeID []byte, metric uint32, subTLVs []byte)) bool {
+	// Visit the neighbors of an LSP in a topology, returning whether it has
+	// any neighbor TLV. The TLV 22s are used if there are any, otherwise the
+	// TLV 2s. Other topologies have TLV 222s, except for pseudonodes which are
+	// in every topology
+	if mtID != MT_STANDARD && lsp.LspID[6] == 0 {
+		return walkMTNeighbors(lsp, mtID, visit)
+	}
+	found := false
+	wide := getTLV(lsp.CoreLsp.FirstTLV, ISIS_EXTENDED_IS_REACH_TLV) != nil
+	for currentTLV := lsp.CoreLsp.FirstTLV; currentTLV != nil; currentTLV = currentTLV.nextTLV {
+		if wide && currentTLV.typeTLV == ISIS_EXTENDED_IS_REACH_TLV {
+			walkExtendedNeighbors(currentTLV, visit)
+			found = true
+		} else if !wide && int(currentTLV.typeTLV) == ISIS_NEIGHBORS_TLV {
+			walkNeighbors(currentTLV, visit)
+			found = true
 		}
-		currentTLV = currentTLV.nextTLV
 	}
-	glog.V(2).Infof("No neighbor tlv found in LSP %s", systemIDToString(lsp.LspID[:6]))
-	return nil
+	if !found {
+		glog.V(2).Infof("No neighbor tlv found in LSP %s", nodeIDName(lsp.LspID[:7]))
+	}
+	return found
 }
 
 func getIPReachTLV(interfaces []*Intf) *IsisTLV {
 	// Doesn't handle duplicate prefixes reachable via different interfaces
+	// Each TLV holds at most 21 prefixes, any more go in additional TLVs
 	var ipReachTLV IsisTLV
 	ipReachTLV.nextTLV = nil
 	ipReachTLV.typeTLV = 128
@@ -299,98 +484,188 @@ func getIPReachTLV(interfaces []*Intf) *IsisTLV {
 			// Dst will be nil for loopback
 			if route != nil {
 				// Add this route to the TLV
-				// 4 bytes metric information
 				// 4 bytes for ip prefix
 				// 4 bytes for ip subnet mask
-				ipReachTLV.valueTLV = append(ipReachTLV.valueTLV, route.IP[:]...)
-				ipReachTLV.valueTLV = append(ipReachTLV.valueTLV, route.Mask[:]...)
-				metric := [4]byte{0x00, 0x00, 0x00, 0x0a}
-				ipReachTLV.valueTLV = append(ipReachTLV.valueTLV, metric[:]...) // Using metric of 10 always (1 hop)
+				// 4 bytes metric information
+				appendPrefix(&ipReachTLV, route, DEFAULT_METRIC) // Using metric of 10 always (1 hop)
 				glog.V(2).Infof("Adding route %v", route)
-				ipReachTLV.lengthTLV += 12
 			}
 		}
 	}
 	return &ipReachTLV
 }
 
-func getNeighborTLV(interfaces []*Intf) *IsisTLV {
+func appendPrefix(reachTLV *IsisTLV, prefix *net.IPNet, metric uint32) {
+	var value [12]byte
+	copy(value[0:4], prefix.IP.To4())
+	copy(value[4:8], prefix.Mask)
+	binary.BigEndian.PutUint32(value[8:12], metric)
+	appendTLVValue(reachTLV, value[:], nil)
+}
+
+func appendNeighbor(neighborsTLV *IsisTLV, metric uint32, nodeID []byte) {
+	// 4 byte metric and 7 byte node ID, the system ID + pseudo-node id
+	var value [11]byte
+	binary.BigEndian.PutUint32(value[0:4], metric)
+	copy(value[4:11], nodeID[:7])
+	glog.V(2).Infof("adding neighbor node id %s", nodeIDName(nodeID[:7]))
+	appendTLVValue(neighborsTLV, value[:], []byte{0x00}) // Every TLV 2 starts with the virtual byte flag
+}
+
+func getLocalNeighbors(interfaces []*Intf, level byte, mtID uint16) []*Neighbor {
+	// The neighbors we advertise at a level in a topology, whichever TLVs they go in
+	neighbors := make([]*Neighbor, 0)
+	for _, intf := range interfaces {
+		intf.lock.Lock()
+		metric := getTopologyMetric(intf, mtID)
+		if intf.circuitType == BROADCAST_CIRCUIT {
+			// On a LAN we only advertise the pseudonode, whose LSP in turn
+			// lists everyone on the LAN. Nothing to advertise until a DIS is known
+			if hasTopologyAdjacency(intf, level, mtID) && intf.lanID[level-1] != [7]byte{} {
+				neighbor := &Neighbor{systemID: nodeIDToString(intf.lanID[level-1][:]), metric: metric}
+				if mtID == MT_STANDARD {
+					neighbor.subTLVs = getTESubTLVs(intf, nil)
+				}
+				if cfg.segmentRouting && mtID == MT_STANDARD {
+					adjacencies := make([]*Adjacency, 0)
+					for _, adj := range intf.adjacencies {
+						if adj.state == "UP" && adj.level&level != 0 {
+							adjacencies = append(adjacencies, adj)
+						}
+					}
+					neighbor.subTLVs = append(neighbor.subTLVs, getAdjSIDSubTLVs(adjacencies, true, len(neighbor.subTLVs))...)
+				}
+				neighbors = append(neighbors, neighbor)
+			}
+		} else {
+			for _, adj := range intf.adjacencies {
+				// Only send the adjacencies that we actually have at this level
+				if adj.state == "UP" && adj.level&level != 0 && adjInTopology(intf, adj, mtID) {
+					neighbor := &Neighbor{systemID: systemIDToString(adj.neighborSystemID), metric: metric}
+					if mtID == MT_STANDARD {
+						neighbor.subTLVs = getTESubTLVs(intf, adj.neighborIP)
+					}
+					if cfg.segmentRouting && mtID == MT_STANDARD {
+						neighbor.subTLVs = append(neighbor.subTLVs, getAdjSIDSubTLVs([]*Adjacency{adj}, false, len(neighbor.subTLVs))...)
+					}
+					neighbors = append(neighbors, neighbor)
+				}
+			}
+		}
+		intf.lock.Unlock()
+	}
+	return neighbors
+}
+
+func getNeighborTLV(interfaces []*Intf, level byte) *IsisTLV {
 	var neighborsTLV IsisTLV
 	neighborsTLV.nextTLV = nil
 	neighborsTLV.typeTLV = 2
 	neighborsTLV.lengthTLV = 1 // Start at 1 to include virtual byte flag
 	var virtualByteFlag byte = 0x00
-	var pseudoNodeId byte = 0x00
 	neighborsTLV.valueTLV = append(neighborsTLV.valueTLV, virtualByteFlag)
-	// Loop though the interfaces looking for adjacencies to append
-	for _, intf := range interfaces {
-		// TLV value is 1 virtual byte flag and then n multiples of 4 byte metric and 6 byte system id + 1 byte pseudo-node id
-		// Set pseudo-node id to 0 for now
-		if intf.adj.state != "UP" {
-			// Only send the adjacencies that we actually have
-			continue
-		}
-		metric := [4]byte{0x00, 0x00, 0x00, 0x0a}
-		neighborsTLV.valueTLV = append(neighborsTLV.valueTLV, metric[:]...)
-		neighborsTLV.valueTLV = append(neighborsTLV.valueTLV, intf.adj.neighborSystemID[:]...)
-		glog.V(2).Infof("adding neighbor system id %s", systemIDToString(intf.adj.neighborSystemID[:]))
-		neighborsTLV.valueTLV = append(neighborsTLV.valueTLV, pseudoNodeId)
-		neighborsTLV.lengthTLV += 11
+	// TLV value is 1 virtual byte flag and then n multiples of 4 byte metric and 6 byte system id + 1 byte pseudo-node id
+	for _, neighbor := range getLocalNeighbors(interfaces, level, MT_STANDARD) {
+		nodeID := systemIDToLspID(neighbor.systemID)
+		appendNeighbor(&neighborsTLV, neighbor.metric, nodeID[:7])
 	}
 	return &neighborsTLV
 }
 
-func getPrefixesFromTLV(tlv *IsisTLV) []net.IPNet {
-	// Given a prefix tlv, return the prefixes as a slice of strings
-	prefixes := make([]net.IPNet, 0)
+func getPrefixesFromTLV(tlv *IsisTLV) []IPPrefix {
+	// Given a TLV 128, 135 or 236, return its prefixes along with their metrics
+	if tlv.typeTLV == ISIS_EXTENDED_IP_REACH_TLV {
+		return getExtendedPrefixes(tlv)
+	} else if tlv.typeTLV == ISIS_IPV6_REACH_TLV {
+		return getIPv6PrefixesFromTLV(tlv)
+	}
+	prefixes := make([]IPPrefix, 0)
 	prefixCount := int(tlv.lengthTLV) / 12 // Each prefix takes up 12 bytes
 	glog.V(2).Infof("Prefix count %d in tlv %v", prefixCount, tlv)
 	currentPrefix := 0
 	for currentPrefix < prefixCount {
-		// Skip the metrix
-		var currentPrefixValue net.IPNet
-		currentPrefixValue.IP = tlv.valueTLV[currentPrefix*12 : currentPrefix*12+4] // Metric is first, skip that
-		currentPrefixValue.Mask = tlv.valueTLV[currentPrefix*12+4 : currentPrefix*12+4+4]
-		glog.V(2).Infof("Current prefix %v", currentPrefixValue)
+		var currentPrefixValue IPPrefix
+		currentPrefixValue.prefix.IP = tlv.valueTLV[currentPrefix*12 : currentPrefix*12+4]
+		currentPrefixValue.prefix.Mask = tlv.valueTLV[currentPrefix*12+4 : currentPrefix*12+4+4]
+		currentPrefixValue.metric = binary.BigEndian.Uint32(tlv.valueTLV[currentPrefix*12+8 : currentPrefix*12+12])
+		glog.V(2).Infof("Current prefix %v", currentPrefixValue.prefix)
 		prefixes = append(prefixes, currentPrefixValue)
 		currentPrefix += 1
 	}
 	return prefixes
 }
 
-func getDirectlyConnectedPrefixes(systemID string) []net.IPNet {
-	// Lookup the lsp and extract the directly connected prefixes
-	tmp := AvlSearch(UpdateDB.Root, systemIDToKey(systemID))
-	if tmp == nil {
+func getLspFragments(db *IsisDB, nodeID string) []*IsisLsp {
+	// All the fragments of the LSP of a system or pseudonode, requires the update db lock to be held
+	start := systemIDToKey(nodeID)
+	fragments := make([]*IsisLsp, 0)
+	for _, node := range AvlGetRange(db.Root, start, start|0xff) {
+		fragments = append(fragments, node.data.(*IsisLsp))
+	}
+	return fragments
+}
+
+func getIPPrefixes(db *IsisDB, systemID string) []IPPrefix {
+	// Lookup the lsp fragments and extract the directly connected prefixes along with
+	// their metrics. If the system advertises any TLV 135s they are used instead of
+	// its TLV 128s, which in transition mode hold the same prefixes
+	fragments := getLspFragments(db, systemID)
+	if len(fragments) == 0 {
 		glog.V(1).Infof("No such LSP %s in LSP database", systemID)
 		return nil
 	}
-	lsp := tmp.(*IsisLsp)
-	currentTLV := lsp.CoreLsp.FirstTLV
-	for currentTLV != nil {
-		if int(currentTLV.typeTLV) == ISIS_IP_INTERNAL_REACH_TLV {
-			return getPrefixesFromTLV(currentTLV)
+	reachType := byte(ISIS_IP_INTERNAL_REACH_TLV)
+	for _, lsp := range fragments {
+		if getTLV(lsp.CoreLsp.FirstTLV, ISIS_EXTENDED_IP_REACH_TLV) != nil {
+			reachType = ISIS_EXTENDED_IP_REACH_TLV
 		}
-		currentTLV = currentTLV.nextTLV
 	}
-	glog.V(2).Infof("No prefix tlv found in LSP %s", systemID)
-	return nil
+	var prefixes []IPPrefix
+	for _, lsp := range fragments {
+		for currentTLV := lsp.CoreLsp.FirstTLV; currentTLV != nil; currentTLV = currentTLV.nextTLV {
+			if currentTLV.typeTLV == reachType {
+				prefixes = append(prefixes, getPrefixesFromTLV(currentTLV)...)
+			}
+		}
+	}
+	if prefixes == nil {
+		glog.V(2).Infof("No prefix tlv found in LSP %s", systemID)
+	}
+	return prefixes
+}
+
+func getDirectlyConnectedPrefixes(db *IsisDB, systemID string) []net.IPNet {
+	var prefixes []net.IPNet
+	for _, prefix := range getIPPrefixes(db, systemID) {
+		prefixes = append(prefixes, prefix.prefix)
+	}
+	return prefixes
 }
 
-func buildEmptyLSP(sequenceNumber uint32, sourceSystemID string) *IsisLsp {
+func buildEmptyLSP(level byte, sequenceNumber uint32, sourceSystemID string) *IsisLsp {
 	var newLsp IsisLsp
 	newLsp.LspID = systemIDToLspID(sourceSystemID)
+	pduType := byte(L1_LSP_PDU_TYPE)
+	if level == LEVEL_2 {
+		pduType = L2_LSP_PDU_TYPE
+	}
 	isisPDUHeader := IsisPDUHeader{IntraDomainRouteingProtocolDiscriminator: 0x83,
 		LengthPDU:            0x00,
 		ProtocolID:           0x01,
 		SystemIDLength:       0x00, // 0 means default 6 bytes
-		TypePDU:              0x12, // l1 LSP
+		TypePDU:              pduType,
 		Version:              0x01, //
 		Reserved:             0x00,
 		MaximumAreaAddresses: 0x00} // 0 means default 3 addresses
 	var seq [4]byte
 	binary.BigEndian.PutUint32(seq[:], sequenceNumber)
-	lspHeader := IsisLspHeader{SequenceNumber: seq}
+	// IS type in the low 2 bits, 01 for level 1 only and 11 for level 2 capable
+	isType := byte(0x01)
+	if level == LEVEL_2 {
+		isType = 0x03
+	}
+	lspHeader := IsisLspHeader{SequenceNumber: seq, PAttOLType: isType}
+	binary.BigEndian.PutUint16(lspHeader.RemainingLifetime[:], MAX_AGE)
 	lspHeader.LspID = newLsp.LspID
 	core := IsisLspCore{Header: isisPDUHeader,
 		LspHeader: lspHeader,
@@ -400,38 +675,184 @@ func buildEmptyLSP(sequenceNumber uint32, sourceSystemID string) *IsisLsp {
 	return &newLsp
 }
 
+func getLspBufferSize() int {
+	if *lspBufferSize < MIN_LSP_BUFFER_SIZE {
+		return MIN_LSP_BUFFER_SIZE
+	} else if *lspBufferSize > MAX_LSP_BUFFER_SIZE {
+		return MAX_LSP_BUFFER_SIZE
+	}
+	return *lspBufferSize
+}
+
+func fragmentTLVs(firstTLV *IsisTLV, maxLength int) []*IsisTLV {
+	// Split a chain of TLVs into chains of at most maxLength bytes, one per
+	// LSP fragment. TLVs are never split across fragments. Returns the first
+	// TLV of each fragment, there is always at least one even if it is empty
+	fragments := []*IsisTLV{nil}
+	length := 0
+	var previous *IsisTLV
+	for tlv := firstTLV; tlv != nil; {
+		next := tlv.nextTLV
+		tlv.nextTLV = nil
+		if length+2+len(tlv.valueTLV) > maxLength && fragments[len(fragments)-1] != nil {
+			if len(fragments) == MAX_LSP_FRAGMENTS {
+				glog.Errorf("Out of LSP fragments, dropping the remaining TLVs")
+				break
+			}
+			fragments = append(fragments, nil)
+			length = 0
+		}
+		if fragments[len(fragments)-1] == nil {
+			fragments[len(fragments)-1] = tlv
+		} else {
+			previous.nextTLV = tlv
+		}
+		previous = tlv
+		length += 2 + len(tlv.valueTLV)
+		tlv = next
+	}
+	return fragments
+}
+
+func isisLocalLsp(triggerSPF chan bool) {
+	// Generate our LSPs when triggered, throttled by the LSP throttle
+	isisThrottle(THROTTLE_LSP, lspTrigger, func() { runLocalLsp(triggerSPF) })
+}
+
+func runLocalLsp(triggerSPF chan bool) {
+	// Our routes have to follow a change to our own links or prefixes as
+	// well, so SPF runs once the new LSPs are in the database
+	generateLocalLsp()
+	scheduleSPF(triggerSPF)
+}
+
 func generateLocalLsp() {
-	// Triggered on adjacency change
-	// Build a local LSP from the information in adjacency database
-	// Leaving fragment and PSN set to zero for now
+	// Triggered on adjacency change, through scheduleLocalLsp
+	// Build a local LSP for each level we run from the information in adjacency database
+	// Split across as many fragments as it takes to keep each one within the LSP buffer size
 	// Sequence number is incremented every time this function is called
 	// TODO: See if there is a better way to do this --> probably need to move everything to use byte slices, these fixed arrays are a pain in the ass
-	sequenceNumber += 1
-	newLsp := buildEmptyLSP(sequenceNumber, cfg.sid)
-	// Also include the adjacency tlvs (assuming metric of 10 always)
-	reachTLV := getIPReachTLV(cfg.interfaces)
-	neighborTLV := getNeighborTLV(cfg.interfaces)
-	reachTLV.nextTLV = neighborTLV
-	newLsp.CoreLsp.FirstTLV = reachTLV
-	UpdateDB.DBLock.Lock()
-	UpdateDB.Root = AvlInsert(UpdateDB.Root, newLsp.Key, newLsp, true)
-	tmp := AvlSearch(UpdateDB.Root, newLsp.Key)
-	UpdateDB.DBLock.Unlock()
+	if isRestarting() {
+		glog.V(1).Infof("Restarting, not generating our LSPs until the database is synchronized")
+		return
+	}
+	for _, level := range getLevels(cfg.level) {
+		sequenceNumber[level-1] += 1
+		// Area addresses, supported protocols and the hostname come first so they
+		// end up in fragment zero
+		tlvs := []*IsisTLV{getAreaAddressesTLV(getAreas()), getProtocolsSupportedTLV(true)}
+		if cfg.multiTopology {
+			tlvs = append(tlvs, getMTTLV(getTopologies()))
+		}
+		if cfg.hostname != "" {
+			tlvs = append(tlvs, getHostnameTLV(cfg.hostname))
+		}
+		if cfg.segmentRouting {
+			tlvs = append(tlvs, getRouterCapabilityTLV(getRouterID(), getSRGB()))
+		}
+		var ipv6Addresses []net.IP
+		for _, intf := range cfg.interfaces {
+			ipv6Addresses = append(ipv6Addresses, intf.ipv6Addresses...)
+		}
+		tlvs = append(tlvs, getIPv6InterfaceTLV(ipv6Addresses))
+		// Then the prefixes and neighbors in the TLVs of our metric style
+		// (assuming metric of 10 always)
+		var reachTLVs []*IsisTLV
+		if originatesNarrow(cfg.metricStyle) {
+			reachTLVs = append(reachTLVs, getIPReachTLV(cfg.interfaces))
+		}
+		if originatesWide(cfg.metricStyle) {
+			reachTLVs = append(reachTLVs, getExtendedIPReachTLV(cfg.interfaces))
+		}
+		// TLV 236 already has wide metrics, so it is used with any metric style.
+		// With multi-topology the IPv6 prefixes go in the TLV 237 of their topology
+		if cfg.multiTopology {
+			reachTLVs = append(reachTLVs, getMTIPv6ReachTLV(cfg.interfaces, MT_IPV6_UNICAST))
+		} else {
+			reachTLVs = append(reachTLVs, getIPv6ReachTLV(cfg.interfaces))
+		}
+		for _, reachTLV := range reachTLVs {
+			if level == LEVEL_2 && cfg.level == LEVEL_1_2 {
+				// Level 1/2 routers advertise their level 1 area into the backbone
+				appendAreaPrefixes(reachTLV)
+			}
+			if cfg.segmentRouting {
+				appendPrefixSIDs(reachTLV)
+			}
+			tlvs = append(tlvs, reachTLV)
+		}
+		if originatesNarrow(cfg.metricStyle) {
+			tlvs = append(tlvs, getNeighborTLV(cfg.interfaces, level))
+		}
+		if originatesWide(cfg.metricStyle) {
+			tlvs = append(tlvs, getExtendedNeighborTLV(cfg.interfaces, level))
+		}
+		if cfg.multiTopology {
+			tlvs = append(tlvs, getMTNeighborTLV(cfg.interfaces, level, MT_IPV6_UNICAST))
+		}
+		firstTLV := linkTLVs(tlvs)
+		// Leaving room in every fragment for the authentication TLV
+		fragments := fragmentTLVs(firstTLV, getLspBufferSize()-LSP_HEADER_SIZE-getAuthTLVLength(level))
+		for i, fragmentTLV := range fragments {
+			newLsp := buildEmptyLSP(level, sequenceNumber[level-1], cfg.sid)
+			newLsp.LspID[7] = byte(i)
+			newLsp.CoreLsp.LspHeader.LspID = newLsp.LspID
+			newLsp.Key = lspIDToKey(newLsp.LspID)
+			if cfg.level == LEVEL_1_2 {
+				newLsp.CoreLsp.LspHeader.PAttOLType = 0x03
+			}
+			if isOverloaded() {
+				newLsp.CoreLsp.LspHeader.PAttOLType |= OVERLOAD_BIT
+			}
+			newLsp.CoreLsp.FirstTLV = fragmentTLV
+			installLocalLsp(getUpdateDB(level), newLsp)
+		}
+		purgeLocalFragments(getUpdateDB(level), len(fragments), fragmentCount[level-1])
+		fragmentCount[level-1] = len(fragments)
+		// Along with the pseudonode LSPs for any LANs we are the DIS on
+		for _, pseudonodeLsp := range generatePseudonodeLsps(level, cfg.sid) {
+			installLocalLsp(getUpdateDB(level), pseudonodeLsp)
+		}
+	}
+}
+
+func purgeLocalFragments(db *IsisDB, from int, to int) {
+	// Purge the fragments of our own LSP we no longer need
+	db.DBLock.Lock()
+	defer db.DBLock.Unlock()
+	for i := from; i < to; i++ {
+		lspID := systemIDToLspID(cfg.sid)
+		lspID[7] = byte(i)
+		if tmp := AvlSearch(db.Root, lspIDToKey(lspID)); tmp != nil {
+			purgeLsp(db, tmp.(*IsisLsp))
+		}
+	}
+}
+
+func installLocalLsp(db *IsisDB, newLsp *IsisLsp) {
+	// Store one of our own LSPs and flood it on all interfaces running its level
+	// Serializing fills in the checksum which SNPs describe it with
+	authenticateLsp(newLsp, db.Level)
+	serializeLsp(newLsp.CoreLsp)
+	db.DBLock.Lock()
+	db.Root = AvlInsert(db.Root, newLsp.Key, newLsp, true)
+	updateHostnames(newLsp)
+	tmp := AvlSearch(db.Root, newLsp.Key)
+	db.DBLock.Unlock()
+	seq := binary.BigEndian.Uint32(newLsp.CoreLsp.LspHeader.SequenceNumber[:])
 	if tmp == nil {
-		glog.V(1).Infof("Failed to generate local LSP %s", systemIDToString(newLsp.LspID[:6]))
+		glog.V(1).Infof("Failed to generate local %s LSP %s", levelToString(db.Level), nodeIDName(newLsp.LspID[:7]))
 	} else {
 		lsp := tmp.(*IsisLsp)
-		glog.V(1).Infof("Successfully generated local LSP %s seq num %d", systemIDToString(lsp.LspID[:6]), sequenceNumber)
+		glog.V(1).Infof("Successfully generated local %s LSP %s seq num %d", levelToString(db.Level), nodeIDName(lsp.LspID[:7]), seq)
 	}
 	// Lsp has been created, need to flood it on all interfaces
 	for _, intf := range cfg.interfaces {
 		intf.lock.Lock()
 		// Add this LSP to the interfaces flood state
 		// If it is already there, just set SRM to true
-		if _, inMap := intf.lspFloodStates[newLsp.Key]; !inMap {
-			intf.lspFloodStates[newLsp.Key] = &LspFloodState{LspIDKey: newLsp.Key, LspID: newLsp.LspID, SRM: true, SSN: false}
-		} else {
-			intf.lspFloodStates[newLsp.Key].SRM = true
+		if intf.level&db.Level != 0 {
+			setFloodFlags(intf, db.Level, newLsp.LspID, true, false)
 		}
 		intf.lock.Unlock()
 	}
@@ -447,8 +868,8 @@ func printUpdateDB(root *AvlNode) {
 	}
 }
 
-func printLspFloodStates(intf *Intf) {
-	for _, v := range intf.lspFloodStates {
-		glog.Infof("%s --> SRM %v", systemIDToString(v.LspID[:6]), v.SRM)
+func printLspFloodStates(intf *Intf, level byte) {
+	for _, v := range intf.lspFloodStates[level-1] {
+		glog.Infof("%s --> SRM %v SSN %v", nodeIDName(v.LspID[:7]), v.SRM, v.SSN)
 	}
 }
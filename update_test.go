@@ -3,17 +3,21 @@ package main
 import (
 	"bytes"
 	"encoding/binary"
+	"flag"
+	pb "github.com/connorwstein/go-is-is/config"
 	"github.com/golang/glog"
+	"golang.org/x/net/context"
 	"net"
+	"strconv"
 	"testing"
 )
 
 func TestUpdateLocalLspGen(t *testing.T) {
 	initConfig()
 	cfg.sid = "1111.1111.1112"
-	adj := Adjacency{state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11}}
+	adj := Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11}}
 	initInterfaces()
-	cfg.interfaces[0].adj = &adj
+	cfg.interfaces[0].adjacencies = []*Adjacency{&adj}
 	updateDBInit()
 	generateLocalLsp()
 
@@ -24,7 +28,19 @@ func TestUpdateLocalLspGen(t *testing.T) {
 	glog.V(2).Infof("%v", cfg.interfaces[0].lspFloodStates[0])
 	glog.V(2).Infof("%v", lsp)
 	// SRM Flag should be set on eth0
-	if !cfg.interfaces[0].lspFloodStates[binary.BigEndian.Uint64(testLspID[:])].SRM {
+	if !cfg.interfaces[0].lspFloodStates[0][binary.BigEndian.Uint64(testLspID[:])].SRM {
+		t.Fail()
+	}
+}
+
+func TestReachTLVSplit(t *testing.T) {
+	// At most 21 prefixes fit in a single TLV 128
+	intf := &Intf{}
+	for i := 0; i < 30; i++ {
+		intf.routes = append(intf.routes, &net.IPNet{IP: net.IP{10, 0, byte(i), 0}, Mask: net.IPMask{0xff, 0xff, 0xff, 0x00}})
+	}
+	tlv := getIPReachTLV([]*Intf{intf})
+	if tlv.lengthTLV != 21*12 || tlv.nextTLV == nil || tlv.nextTLV.lengthTLV != 9*12 || tlv.nextTLV.typeTLV != ISIS_IP_INTERNAL_REACH_TLV {
 		t.Fail()
 	}
 }
@@ -51,16 +67,100 @@ func TestReachTLV(t *testing.T) {
 }
 
 func TestNeighborTLV(t *testing.T) {
+	initConfig()
 	numInterfaces := 2
 	interfaces := make([]*Intf, numInterfaces)
 	systemID := []byte{0x01, 0x01, 0x01, 0x01, 0x01, 0x01}
 	// Need a couple adjacencies with neighbor system IDs
 	for i := 0; i < numInterfaces; i++ {
-		interfaces[i] = &Intf{adj: &Adjacency{state: "UP", neighborSystemID: systemID}}
+		interfaces[i] = &Intf{circuitType: P2P_CIRCUIT, adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, state: "UP", neighborSystemID: systemID}}}
 	}
-	tlv := getNeighborTLV(interfaces)
+	tlv := getNeighborTLV(interfaces, LEVEL_1)
 	t.Logf("Neighbors TLV %v", tlv)
 	if !(bytes.Equal(tlv.valueTLV[5:5+6], systemID) && bytes.Equal(tlv.valueTLV[12+4:12+4+6], systemID)) {
 		t.Fail()
 	}
 }
+
+func TestLspFragments(t *testing.T) {
+	initConfig()
+	cfg.sid = "1111.1111.1112"
+	intf := &Intf{name: "eth0", level: LEVEL_1, lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)}}
+	for i := 0; i < 100; i++ {
+		intf.routes = append(intf.routes, &net.IPNet{IP: net.IP{10, 0, byte(i), 0}, Mask: net.IPMask{0xff, 0xff, 0xff, 0x00}})
+	}
+	cfg.interfaces = []*Intf{intf}
+	updateDBInit()
+	defer flag.Set("lsp_buffer_size", strconv.Itoa(DEFAULT_LSP_BUFFER_SIZE))
+	flag.Set("lsp_buffer_size", "512")
+	generateLocalLsp()
+	fragments := getLspFragments(UpdateDB, cfg.sid)
+	// 5 prefix TLVs, only one of the full 254 byte ones fits in each 512 byte fragment
+	if len(fragments) != 4 || fragments[3].LspID[7] != 3 {
+		t.Fatalf("Expected 4 fragments, got %d", len(fragments))
+	}
+	for _, fragment := range fragments {
+		if length := len(serializeLsp(fragment.CoreLsp)); length > 512 {
+			t.Fatalf("Fragment %d is %d bytes", fragment.LspID[7], length)
+		}
+	}
+	if len(getDirectlyConnectedPrefixes(UpdateDB, cfg.sid)) != 100 {
+		t.Fatalf("Expected all 100 prefixes across the fragments")
+	}
+	// Fragments we no longer need are purged
+	intf.routes = intf.routes[:1]
+	generateLocalLsp()
+	fragments = getLspFragments(UpdateDB, cfg.sid)
+	if len(fragments) != 4 || getRemainingLifetime(fragments[0]) == 0 ||
+		getRemainingLifetime(fragments[1]) != 0 || getRemainingLifetime(fragments[3]) != 0 {
+		t.Fail()
+	}
+}
+
+func TestLocalLspRunsSPF(t *testing.T) {
+	// A new link metric regenerates our LSPs, which then runs SPF so our route moves
+	initConfig()
+	cfg.sid = "1111.1111.1111"
+	cfg.metricStyle = METRIC_STYLE_WIDE
+	updateDBInit()
+	intf := &Intf{name: "eth0", circuitType: P2P_CIRCUIT, level: LEVEL_1, metric: DEFAULT_METRIC,
+		lspFloodStates: [2]map[uint64]*LspFloodState{make(map[uint64]*LspFloodState), make(map[uint64]*LspFloodState)},
+		adjacencies: []*Adjacency{&Adjacency{level: LEVEL_1, metric: DEFAULT_METRIC, state: "UP",
+			neighborSystemID: []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x12}, intfName: "eth0", neighborIP: net.IP{172, 18, 0, 2}}}}
+	cfg.interfaces = []*Intf{intf}
+	r2 := buildEmptyLSP(LEVEL_1, 1, "1111.1111.1112")
+	neighborTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IS_REACH_TLV}
+	appendExtendedNeighbor(neighborTLV, DEFAULT_METRIC, []byte{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0}, nil)
+	reachTLV := &IsisTLV{typeTLV: ISIS_EXTENDED_IP_REACH_TLV}
+	appendExtendedPrefix(reachTLV, &net.IPNet{IP: net.IP{10, 0, 2, 0}, Mask: net.CIDRMask(24, 32)}, DEFAULT_METRIC, false, nil)
+	r2.CoreLsp.FirstTLV = linkTLVs([]*IsisTLV{neighborTLV, reachTLV})
+	UpdateDB.Root = AvlInsert(UpdateDB.Root, r2.Key, r2, false)
+	triggerSPF := make(chan bool, 1)
+	runLocalLsp(triggerSPF)
+	<-triggerSPF
+	topoDB := &IsisDB{Level: LEVEL_1}
+	computeSPF(UpdateDB, topoDB, cfg.sid, cfg.interfaces, MT_STANDARD)
+	if route := spfStates[topoDB].routes["10.0.2.0/24"]; route == nil || route.distance != 20 {
+		t.Fatalf("Expected a route at 20, got %v", route)
+	}
+	if _, err := (&server{}).ConfigureIntf(context.Background(), &pb.IntfCfgRequest{Name: "eth0", Metric: 100}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lspTrigger:
+	default:
+		t.Fatalf("Expected our LSPs to be regenerated")
+	}
+	runLocalLsp(triggerSPF)
+	select {
+	case <-triggerSPF:
+	default:
+		t.Fatalf("Expected SPF to run")
+	}
+	computeSPF(UpdateDB, topoDB, cfg.sid, cfg.interfaces, MT_STANDARD)
+	if route := spfStates[topoDB].routes["10.0.2.0/24"]; route == nil || route.distance != 110 {
+		t.Fatalf("Expected a route at 110, got %v", route)
+	}
+	forgetSPFState(topoDB)
+	initConfig()
+}